@@ -0,0 +1,42 @@
+package archive
+
+import (
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// DBChain reads the canonical chain straight from the database of a node,
+// without setting up the blockchain
+type DBChain struct {
+	db ethdb.Database
+}
+
+func NewDBChain(db ethdb.Database) *DBChain {
+	return &DBChain{db: db}
+}
+
+// Header returns the head of the chain, or an empty header for
+// an empty database
+func (c *DBChain) Header() *types.Header {
+	hash, ok := rawdb.ReadHeadHash(c.db)
+	if !ok {
+		return &types.Header{}
+	}
+
+	header, err := rawdb.ReadHeader(c.db, hash)
+	if err != nil {
+		return &types.Header{}
+	}
+
+	return header
+}
+
+func (c *DBChain) GetBlockByNumber(blockNumber uint64, full bool) (*types.Block, bool) {
+	hash, ok := rawdb.ReadCanonicalHash(c.db, blockNumber)
+	if !ok {
+		return nil, false
+	}
+
+	return rawdb.ReadBlockByHash(c.db, hash)
+}
@@ -0,0 +1,220 @@
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/sunvim/dogesyncer/pkg/server/proto"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+var (
+	errEmptyArchive     = errors.New("no blocks exported")
+	errInvalidSequence  = errors.New("blocks are not a continuous sequence")
+	errInvalidExportEnd = errors.New("to must be greater than or equal to from")
+)
+
+// hashWriter hashes and counts everything written through it
+type hashWriter struct {
+	w    io.Writer
+	h    hash.Hash
+	size uint64
+}
+
+func newHashWriter(w io.Writer) *hashWriter {
+	return &hashWriter{
+		w: w,
+		h: sha256.New(),
+	}
+}
+
+func (hw *hashWriter) Write(p []byte) (int, error) {
+	n, err := hw.w.Write(p)
+	hw.h.Write(p[:n])
+	hw.size += uint64(n)
+
+	return n, err
+}
+
+func (hw *hashWriter) sum() string {
+	return hex.EncodeToString(hw.h.Sum(nil))
+}
+
+// Writer writes a continuous range of blocks in the archive format
+type Writer struct {
+	path       string
+	file       *os.File
+	hw         *hashWriter
+	compressor io.WriteCloser
+	out        io.Writer
+	manifest   Manifest
+}
+
+// NewWriter creates the archive file at path
+func NewWriter(path string, compression Compression) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{
+		path: path,
+		file: file,
+		hw:   newHashWriter(file),
+		manifest: Manifest{
+			Compression: compression,
+		},
+	}
+
+	switch compression {
+	case CompressionNone:
+		w.out = w.hw
+	case CompressionGzip:
+		w.compressor = gzip.NewWriter(w.hw)
+		w.out = w.compressor
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(w.hw)
+		if err != nil {
+			file.Close()
+
+			return nil, err
+		}
+
+		w.compressor = zw
+		w.out = zw
+	default:
+		file.Close()
+
+		return nil, fmt.Errorf("%w: %s", errUnknownCompression, compression)
+	}
+
+	return w, nil
+}
+
+// WriteBlock appends the block to the archive
+func (w *Writer) WriteBlock(block *types.Block) error {
+	return w.WriteRaw(block.MarshalRLP(), block.Number(), block.Number())
+}
+
+// WriteRaw appends the encoded blocks [from, to] to the archive
+func (w *Writer) WriteRaw(data []byte, from, to uint64) error {
+	if w.manifest.Blocks > 0 && from != w.manifest.To+1 {
+		return fmt.Errorf("%w: expected %d, got %d", errInvalidSequence, w.manifest.To+1, from)
+	}
+
+	if _, err := w.out.Write(data); err != nil {
+		return err
+	}
+
+	if w.manifest.Blocks == 0 {
+		w.manifest.From = from
+	}
+
+	w.manifest.To = to
+	w.manifest.Blocks += to - from + 1
+
+	return nil
+}
+
+// Close flushes the archive and writes its manifest
+func (w *Writer) Close() (*Manifest, error) {
+	if w.compressor != nil {
+		if err := w.compressor.Close(); err != nil {
+			w.file.Close()
+
+			return nil, err
+		}
+	}
+
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+
+		return nil, err
+	}
+
+	if err := w.file.Close(); err != nil {
+		return nil, err
+	}
+
+	if w.manifest.Blocks == 0 {
+		return nil, errEmptyArchive
+	}
+
+	w.manifest.Size = w.hw.size
+	w.manifest.SHA256 = w.hw.sum()
+
+	if err := writeManifest(w.path, &w.manifest); err != nil {
+		return nil, err
+	}
+
+	return &w.manifest, nil
+}
+
+type chainReader interface {
+	Header() *types.Header
+	GetBlockByNumber(blockNumber uint64, full bool) (*types.Block, bool)
+}
+
+// ExportChain writes the canonical blocks [from, to] of the chain, to set
+// to zero exports up to the current head
+func ExportChain(ctx context.Context, chain chainReader, from, to uint64, w *Writer) error {
+	if to == 0 {
+		to = chain.Header().Number
+	}
+
+	if to < from {
+		return errInvalidExportEnd
+	}
+
+	for i := from; i <= to; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		block, ok := chain.GetBlockByNumber(i, true)
+		if !ok {
+			return fmt.Errorf("block %d not found", i)
+		}
+
+		if err := w.WriteBlock(block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportFromGRPC writes the blocks [from, to] streamed by the Export
+// service of a running node, to set to zero exports up to its head
+func ExportFromGRPC(ctx context.Context, client proto.SystemClient, from, to uint64, w *Writer) error {
+	stream, err := client.Export(ctx, &proto.ExportRequest{
+		From: from,
+		To:   to,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := w.WriteRaw(event.Data, event.From, event.To); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/helper/progress"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// mockReader serves the blocks [0, head]
+type mockReader struct {
+	head uint64
+}
+
+func (m *mockReader) Header() *types.Header {
+	return &types.Header{Number: m.head}
+}
+
+func (m *mockReader) GetBlockByNumber(n uint64, full bool) (*types.Block, bool) {
+	if n > m.head {
+		return nil, false
+	}
+
+	return &types.Block{
+		Header:       &types.Header{Number: n, ExtraData: make([]byte, 128)},
+		Transactions: []*types.Transaction{},
+		Uncles:       []*types.Header{},
+	}, true
+}
+
+func TestExportChain_RoundTrip(t *testing.T) {
+	for _, compression := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		compression := compression
+
+		t.Run(string(compression), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "chain.rlp")
+
+			w, err := NewWriter(path, compression)
+			assert.NoError(t, err)
+
+			assert.NoError(t, ExportChain(context.Background(), &mockReader{head: 30}, 1, 0, w))
+
+			manifest, err := w.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(1), manifest.From)
+			assert.Equal(t, uint64(30), manifest.To)
+			assert.Equal(t, uint64(30), manifest.Blocks)
+			assert.Equal(t, compression, manifest.Compression)
+
+			stored, err := ReadManifest(path)
+			assert.NoError(t, err)
+			assert.Equal(t, manifest, stored)
+
+			chain := &mockChain{}
+			assert.NoError(t, RestoreChain(
+				hclog.NewNullLogger(),
+				chain,
+				path,
+				progress.NewProgressionWrapper(progress.ChainSyncRestore),
+			))
+			assert.Len(t, chain.written, 30)
+			assert.Equal(t, uint64(30), chain.head)
+		})
+	}
+}
+
+func TestExportChain_ChecksumMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain.rlp")
+
+	w, err := NewWriter(path, CompressionGzip)
+	assert.NoError(t, err)
+	assert.NoError(t, ExportChain(context.Background(), &mockReader{head: 5}, 1, 5, w))
+
+	_, err = w.Close()
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	data[len(data)/2] ^= 0xff
+	assert.NoError(t, os.WriteFile(path, data, 0600))
+
+	chain := &mockChain{}
+	err = RestoreChain(
+		hclog.NewNullLogger(),
+		chain,
+		path,
+		progress.NewProgressionWrapper(progress.ChainSyncRestore),
+	)
+	assert.ErrorIs(t, err, errChecksumMismatch)
+	assert.Empty(t, chain.written)
+}
+
+func TestWriter_Sequence(t *testing.T) {
+	w, err := NewWriter(filepath.Join(t.TempDir(), "chain.rlp"), CompressionNone)
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.WriteRaw([]byte{0xc0}, 3, 3))
+	assert.ErrorIs(t, w.WriteRaw([]byte{0xc0}, 5, 5), errInvalidSequence)
+
+	_, err = w.Close()
+	assert.NoError(t, err)
+
+	_, err = ParseCompression("lz4")
+	assert.ErrorIs(t, err, errUnknownCompression)
+}
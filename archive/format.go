@@ -0,0 +1,177 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the optional wrapper around the block stream of an archive
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	errUnknownCompression = errors.New("unknown compression")
+	errChecksumMismatch   = errors.New("archive checksum does not match the manifest")
+)
+
+// ParseCompression parses the name of a compression
+func ParseCompression(name string) (Compression, error) {
+	switch c := Compression(name); c {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+		return c, nil
+	case "":
+		return CompressionNone, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", errUnknownCompression, name)
+}
+
+// Manifest describes a complete archive, it is written next to the archive
+// once all blocks are written
+type Manifest struct {
+	From        uint64      `json:"from"`
+	To          uint64      `json:"to"`
+	Blocks      uint64      `json:"blocks"`
+	Compression Compression `json:"compression"`
+	Size        uint64      `json:"size"`
+	SHA256      string      `json:"sha256"`
+}
+
+// ManifestPath returns the manifest path of the archive
+func ManifestPath(archivePath string) string {
+	return archivePath + ".manifest.json"
+}
+
+// ReadManifest reads the manifest of the archive, it returns nil
+// if the archive has none
+func ReadManifest(archivePath string) (*Manifest, error) {
+	data, err := os.ReadFile(ManifestPath(archivePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+func writeManifest(archivePath string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(ManifestPath(archivePath), append(data, '\n'), 0600)
+}
+
+// verifyChecksum compares the archive file against its manifest
+func verifyChecksum(archivePath string, manifest *Manifest) error {
+	fp, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	hw := newHashWriter(io.Discard)
+	if _, err := io.Copy(hw, fp); err != nil {
+		return err
+	}
+
+	if hw.size != manifest.Size || hw.sum() != manifest.SHA256 {
+		return errChecksumMismatch
+	}
+
+	return nil
+}
+
+// openArchive opens the archive and unwraps its compression,
+// which is detected by the magic bytes of the file
+func openArchive(archivePath string) (io.ReadCloser, error) {
+	fp, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(fp)
+
+	magic, err := r.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		fp.Close()
+
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			fp.Close()
+
+			return nil, err
+		}
+
+		return &archiveReader{Reader: zr, closers: []io.Closer{zr, fp}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			fp.Close()
+
+			return nil, err
+		}
+
+		return &archiveReader{Reader: zr, closers: []io.Closer{zstdCloser{zr}, fp}}, nil
+	}
+
+	return &archiveReader{Reader: r, closers: []io.Closer{fp}}, nil
+}
+
+// archiveReader closes the decompressor and the underlying file
+type archiveReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (a *archiveReader) Close() error {
+	var err error
+
+	for _, c := range a.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+type zstdCloser struct {
+	*zstd.Decoder
+}
+
+func (z zstdCloser) Close() error {
+	z.Decoder.Close()
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/blockchain"
+	"github.com/sunvim/dogesyncer/helper/progress"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+const (
+	// restoreLogInterval is how often the restore progress is logged
+	restoreLogInterval = 10 * time.Second
+)
+
+type blockchainInterface interface {
+	SubscribeEvents() blockchain.Subscription
+	Header() *types.Header
+	VerifyFinalizedBlock(block *types.Block) error
+	WriteBlock(block *types.Block) error
+}
+
+// RestoreChain reads the blocks from the archive file and writes them to
+// the chain. Blocks the chain already has are skipped. The archive is checked
+// against its manifest, if it has one.
+func RestoreChain(
+	logger hclog.Logger,
+	chain blockchainInterface,
+	filePath string,
+	progression *progress.ProgressionWrapper,
+) error {
+	manifest, err := ReadManifest(filePath)
+	if err != nil {
+		return err
+	}
+
+	var total uint64
+
+	if manifest != nil {
+		if err := verifyChecksum(filePath, manifest); err != nil {
+			return err
+		}
+
+		total = manifest.Blocks
+	} else if total, err = countBlocks(filePath); err != nil {
+		return err
+	}
+
+	if total == 0 {
+		logger.Info("restore file is empty", "file", filePath)
+
+		return nil
+	}
+
+	fp, err := openArchive(filePath)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	return restoreChain(logger, chain, newBlockStream(fp), total, progression)
+}
+
+// countBlocks returns the number of blocks in the archive file
+func countBlocks(filePath string) (uint64, error) {
+	fp, err := openArchive(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer fp.Close()
+
+	var (
+		stream = newBlockStream(fp)
+		count  uint64
+	)
+
+	for {
+		if _, err := stream.skipItem(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return count, nil
+			}
+
+			return 0, fmt.Errorf("failed to read block %d of %s: %w", count, filePath, err)
+		}
+
+		count++
+	}
+}
+
+func restoreChain(
+	logger hclog.Logger,
+	chain blockchainInterface,
+	stream *blockStream,
+	total uint64,
+	progression *progress.ProgressionWrapper,
+) error {
+	var (
+		started  bool
+		written  uint64
+		lastLog  = time.Now()
+		startAt  = time.Now()
+		startNum = chain.Header().Number
+	)
+
+	defer func() {
+		if started {
+			progression.StopProgression()
+		}
+	}()
+
+	for {
+		block, err := stream.nextBlock()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !started {
+			// the export is a continuous range of blocks
+			progression.StartProgression(startNum, chain.SubscribeEvents())
+			progression.UpdateHighestProgression(block.Number() + total - 1)
+
+			started = true
+		}
+
+		if err := chain.VerifyFinalizedBlock(block); err != nil {
+			if errors.Is(err, blockchain.ErrExistBlock) {
+				continue
+			}
+
+			return fmt.Errorf("failed to verify block %d: %w", block.Number(), err)
+		}
+
+		if err := chain.WriteBlock(block); err != nil {
+			return fmt.Errorf("failed to write block %d: %w", block.Number(), err)
+		}
+
+		written++
+
+		if time.Since(lastLog) >= restoreLogInterval {
+			logger.Info(
+				"restoring blocks",
+				"current", block.Number(),
+				"highest", progression.GetProgression().HighestBlock,
+				"written", written,
+			)
+
+			lastLog = time.Now()
+		}
+	}
+
+	logger.Info(
+		"restore finished",
+		"written", written,
+		"head", chain.Header().Number,
+		"elapsed", time.Since(startAt),
+	)
+
+	return nil
+}
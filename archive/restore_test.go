@@ -0,0 +1,103 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/blockchain"
+	"github.com/sunvim/dogesyncer/helper/progress"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+type mockChain struct {
+	head    uint64
+	written []uint64
+}
+
+func (m *mockChain) SubscribeEvents() blockchain.Subscription {
+	return blockchain.NewMockSubscription()
+}
+
+func (m *mockChain) Header() *types.Header {
+	return &types.Header{Number: m.head}
+}
+
+func (m *mockChain) VerifyFinalizedBlock(block *types.Block) error {
+	if block.Number() <= m.head {
+		return blockchain.ErrExistBlock
+	}
+
+	return nil
+}
+
+func (m *mockChain) WriteBlock(block *types.Block) error {
+	m.written = append(m.written, block.Number())
+	m.head = block.Number()
+
+	return nil
+}
+
+// writeArchive writes the blocks [from, to] in the export format
+func writeArchive(t *testing.T, from, to uint64) (string, []byte) {
+	t.Helper()
+
+	var data []byte
+
+	for i := from; i <= to; i++ {
+		block := &types.Block{
+			Header: &types.Header{
+				Number:    i,
+				ExtraData: make([]byte, 64),
+			},
+			Transactions: []*types.Transaction{},
+			Uncles:       []*types.Header{},
+		}
+
+		// big enough blocks use the long list prefix
+		if i%2 == 0 {
+			block.Header.ExtraData = make([]byte, 1024)
+		}
+
+		data = block.MarshalRLPTo(data)
+	}
+
+	path := filepath.Join(t.TempDir(), "chain.rlp")
+	assert.NoError(t, os.WriteFile(path, data, 0600))
+
+	return path, data
+}
+
+func TestRestoreChain(t *testing.T) {
+	path, _ := writeArchive(t, 0, 20)
+
+	count, err := countBlocks(path)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(21), count)
+
+	chain := &mockChain{head: 5}
+	progression := progress.NewProgressionWrapper(progress.ChainSyncRestore)
+
+	assert.NoError(t, RestoreChain(hclog.NewNullLogger(), chain, path, progression))
+
+	expected := make([]uint64, 0, 15)
+	for i := uint64(6); i <= 20; i++ {
+		expected = append(expected, i)
+	}
+
+	assert.Equal(t, expected, chain.written)
+	assert.Nil(t, progression.GetProgression())
+}
+
+func TestRestoreChain_Truncated(t *testing.T) {
+	path, data := writeArchive(t, 1, 3)
+	assert.NoError(t, os.WriteFile(path, data[:len(data)-10], 0600))
+
+	chain := &mockChain{}
+	progression := progress.NewProgressionWrapper(progress.ChainSyncRestore)
+
+	assert.ErrorIs(t, RestoreChain(hclog.NewNullLogger(), chain, path, progression), errTruncatedBlock)
+	assert.Empty(t, chain.written)
+}
@@ -0,0 +1,132 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sunvim/dogesyncer/types"
+)
+
+const (
+	// maxBlockSize bounds the size of a single encoded block in a stream,
+	// anything larger is treated as a corrupted stream
+	maxBlockSize = 128 * 1024 * 1024
+)
+
+var (
+	errNotRLPList     = errors.New("stream item is not a rlp list")
+	errBlockTooLarge  = errors.New("stream item exceeds the max block size")
+	errTruncatedBlock = errors.New("stream ends within a block")
+)
+
+// blockStream reads blocks from the concatenated RLP format produced by
+// the Export service
+type blockStream struct {
+	r *bufio.Reader
+}
+
+func newBlockStream(r io.Reader) *blockStream {
+	return &blockStream{
+		r: bufio.NewReaderSize(r, 1024*1024),
+	}
+}
+
+// nextBlock returns the next block of the stream, or io.EOF once the stream
+// is fully consumed
+func (s *blockStream) nextBlock() (*types.Block, error) {
+	data, err := s.nextItem()
+	if err != nil {
+		return nil, err
+	}
+
+	block := &types.Block{}
+	if err := block.UnmarshalRLP(data); err != nil {
+		return nil, fmt.Errorf("failed to decode block: %w", err)
+	}
+
+	return block, nil
+}
+
+// nextItem reads the next raw RLP list of the stream
+func (s *blockStream) nextItem() ([]byte, error) {
+	prefix, err := s.r.Peek(1)
+	if err != nil {
+		// io.EOF on a clean item boundary
+		return nil, err
+	}
+
+	headerSize, payloadSize, err := s.itemSize(prefix[0])
+	if err != nil {
+		return nil, err
+	}
+
+	size := headerSize + payloadSize
+	if size > maxBlockSize {
+		return nil, errBlockTooLarge
+	}
+
+	data := make([]byte, size)
+
+	if _, err := io.ReadFull(s.r, data); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errTruncatedBlock
+		}
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// skipItem discards the next raw RLP list of the stream, it returns
+// the number of discarded bytes
+func (s *blockStream) skipItem() (uint64, error) {
+	prefix, err := s.r.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+
+	headerSize, payloadSize, err := s.itemSize(prefix[0])
+	if err != nil {
+		return 0, err
+	}
+
+	size := headerSize + payloadSize
+
+	if n, err := s.r.Discard(int(size)); err != nil {
+		if uint64(n) < size {
+			return 0, errTruncatedBlock
+		}
+
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// itemSize decodes the list prefix starting with b
+func (s *blockStream) itemSize(b byte) (uint64, uint64, error) {
+	switch {
+	case b < 0xc0:
+		return 0, 0, errNotRLPList
+	case b < 0xf8:
+		// short list, the prefix holds the payload size
+		return 1, uint64(b - 0xc0), nil
+	}
+
+	// long list, the prefix is followed by the big endian payload size
+	lenOfLen := int(b - 0xf7)
+
+	header, err := s.r.Peek(1 + lenOfLen)
+	if err != nil {
+		return 0, 0, errTruncatedBlock
+	}
+
+	var size [8]byte
+	copy(size[8-lenOfLen:], header[1:])
+
+	return uint64(1 + lenOfLen), binary.BigEndian.Uint64(size[:]), nil
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	lru "github.com/hashicorp/golang-lru"
@@ -35,6 +36,7 @@ type Blockchain struct {
 	currentDifficulty atomic.Value // The current difficulty of the chain (total difficulty)
 	stopped           atomic.Bool
 	wg                *sync.WaitGroup
+	writeLock         sync.Mutex // serializes block writes and reorgs
 
 	headersCache         *lru.Cache // LRU cache for the headers
 	blockNumberHashCache *lru.Cache // LRU cache for the CanonicalHash
@@ -42,6 +44,9 @@ type Blockchain struct {
 
 	gpAverage *gasPriceAverage // A reference to the average gas price
 
+	pruner *itrie.Pruner // keeps the executed states, nil in archive mode
+
+	metrics *Metrics
 }
 
 func (b *Blockchain) Config() *chain.Chain {
@@ -63,7 +68,14 @@ type Executor interface {
 	Stop()
 }
 
-func NewBlockchain(logger hclog.Logger, db ethdb.Database, chain *chain.Chain, executor Executor, state *itrie.State) (*Blockchain, error) {
+func NewBlockchain(
+	logger hclog.Logger,
+	db ethdb.Database,
+	chain *chain.Chain,
+	executor Executor,
+	state *itrie.State,
+	metrics *Metrics,
+) (*Blockchain, error) {
 	b := &Blockchain{
 		logger:   logger.Named("blockchain"),
 		chaindb:  db,
@@ -76,6 +88,7 @@ func NewBlockchain(logger hclog.Logger, db ethdb.Database, chain *chain.Chain, e
 			price: big.NewInt(0),
 			count: big.NewInt(0),
 		},
+		metrics: NewDummyMetrics(metrics),
 	}
 
 	err := b.initCaches(32)
@@ -87,6 +100,11 @@ func NewBlockchain(logger hclog.Logger, db ethdb.Database, chain *chain.Chain, e
 	return b, nil
 }
 
+// SetPruner pins the states of the executed blocks in the pruner
+func (b *Blockchain) SetPruner(pruner *itrie.Pruner) {
+	b.pruner = pruner
+}
+
 func (b *Blockchain) Close() error {
 	b.executor.Stop()
 	b.stop()
@@ -102,39 +120,6 @@ func (b *Blockchain) isStopped() bool {
 	return b.stopped.Load()
 }
 
-func (b *Blockchain) SelfCheck() {
-	var newheader *types.Header
-
-	latest, ok := rawdb.ReadHeadHash(b.chaindb)
-	if !ok {
-		panic("it shouldn't happen, can't read latest hash")
-	}
-	header, err := rawdb.ReadHeader(b.chaindb, latest)
-	if err != nil {
-		panic("it shouldn't happen, can't read header by specify hash")
-	}
-	_, exist := rawdb.ReadCanonicalHash(b.chaindb, header.Number)
-	if !exist { // missing latest header
-		for num := header.Number - 1; num > 0; num-- {
-			newheader, ok = b.GetHeaderByNumber(num)
-			if ok {
-				break
-			}
-		}
-	}
-
-	// issue: when restart , missing state
-	for index := header.Number; index > 0; index-- {
-		newheader, _ = b.GetHeaderByNumber(index)
-		_, err := rawdb.ReadState(b.chaindb, newheader.StateRoot)
-		if err == nil {
-			break
-		}
-	}
-	rawdb.WriteHeadHash(b.chaindb, newheader.Hash)
-	rawdb.WriteHeadNumber(b.chaindb, newheader.Number)
-}
-
 func (b *Blockchain) CurrentTD() *big.Int {
 	td, ok := b.currentDifficulty.Load().(*big.Int)
 	if !ok {
@@ -153,6 +138,11 @@ func (b *Blockchain) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error
 	// read body
 	bodies, err := rawdb.ReadBody(b.chaindb, hash)
 	if err != nil {
+		if header, ok := b.GetHeaderByHash(hash); ok && header.TxRoot == types.EmptyRootHash {
+			// empty blocks have no body stored
+			return []*types.Receipt{}, nil
+		}
+
 		return nil, err
 	}
 	// read receipts
@@ -169,23 +159,12 @@ func (b *Blockchain) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error
 }
 
 func (b *Blockchain) GetBodyByHash(hash types.Hash) (*types.Body, bool) {
-	// read body
-	bodies, err := rawdb.ReadBody(b.chaindb, hash)
-	if err != nil {
+	block, ok := rawdb.ReadBlockByHash(b.chaindb, hash)
+	if !ok {
 		return nil, false
 	}
-	// read transactions
-	txes := make([]*types.Transaction, len(bodies))
-	for i, txhash := range bodies {
-		tx, err := rawdb.ReadTransaction(b.chaindb, txhash)
-		if err != nil {
-			return nil, false
-		}
-		txes[i] = tx
-	}
-	return &types.Body{
-		Transactions: txes,
-	}, false
+
+	return block.Body(), true
 }
 
 func (b *Blockchain) GetHeaderByHash(hash types.Hash) (*types.Header, bool) {
@@ -211,6 +190,9 @@ func (b *Blockchain) GetHeaderByNumber(n uint64) (*types.Header, bool) {
 	return header, true
 }
 
+// WriteBlock executes and writes the block. A block extending the head
+// becomes the new head, a block on a side chain is only stored, unless its
+// total difficulty exceeds the current one, which reorganizes the chain.
 func (b *Blockchain) WriteBlock(block *types.Block) error {
 	if b.isStopped() {
 		return ErrClosed
@@ -218,45 +200,59 @@ func (b *Blockchain) WriteBlock(block *types.Block) error {
 	b.wg.Add(1)
 	defer b.wg.Done()
 
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
 	// nil checked by verify functions
 	header := block.Header
+	header.ComputeHash()
+
+	if _, ok := b.readTotalDifficulty(header.Hash); ok {
+		// already written, either canonical or on a side chain
+		return nil
+	}
+
+	parentTD, ok := b.readTotalDifficulty(header.ParentHash)
+	if !ok {
+		return ErrParentNotFound
+	}
+
+	td := parentTD.Uint64() + header.Difficulty
 
 	// Log the information
 	b.logger.Info("write block", "num", block.Number(), "parent", block.ParentHash())
 
-	// write body
-	if err := b.writeBody(block); err != nil {
-		return err
+	if current := b.Header(); header.ParentHash != current.Hash {
+		return b.writeSideBlock(block, td)
 	}
 
-	blockResult, err := b.executeBlockTransactions(block)
-	if err != nil {
-		return err
-	}
+	// the block is stored in a single batch, a crash never leaves half of it
+	batch := b.chaindb.Batch()
+	defer batch.Discard()
 
-	if buildroot.CalculateReceiptsRoot(blockResult.Receipts) != header.ReceiptsRoot {
-		return fmt.Errorf("mismatch receipt root %s != %s", header.ReceiptsRoot, blockResult.Root)
+	if err := b.writeBody(batch, block); err != nil {
+		return err
 	}
 
-	if buildroot.CalculateTransactionsRoot(block.Transactions) != header.TxRoot {
-		return fmt.Errorf("mismatch receipt root %s != %s", header.ReceiptsRoot, blockResult.Root)
+	blockResult, err := b.processBlock(block)
+	if err != nil {
+		return err
 	}
 
-	if blockResult.Root != header.StateRoot {
-		return fmt.Errorf("mismatch state root %s != %s", header.StateRoot, blockResult.Root)
+	if err := b.writeCanonicalData(batch, block, blockResult); err != nil {
+		return err
 	}
 
-	err = rawdb.WrteReceipts(b.chaindb, blockResult.Receipts)
-	if err != nil {
+	if err := b.writeHeader(batch, header, td); err != nil {
 		return err
 	}
 
-	// Write the header to the chain
-	header.ComputeHash()
-	if err := b.WriteHeader(header); err != nil {
+	if err := b.advanceHead(batch, header, td); err != nil {
 		return err
 	}
 
+	b.pushHeadEvent(header)
+
 	// Update the average gas price
 	b.updateGasPriceAvgWithBlock(block)
 
@@ -276,6 +272,69 @@ func (b *Blockchain) WriteBlock(block *types.Block) error {
 	return nil
 }
 
+// processBlock executes the block on top of its parent state and checks
+// the result against the roots in the header
+func (b *Blockchain) processBlock(block *types.Block) (*BlockResult, error) {
+	header := block.Header
+
+	start := time.Now()
+
+	blockResult, err := b.executeBlockTransactions(block)
+	if err != nil {
+		return nil, err
+	}
+
+	b.updateExecutionMetrics(header, time.Since(start))
+
+	// the state is committed whatever the checks below say, pin it so that
+	// it is pruned in time
+	if b.pruner != nil {
+		if err := b.pruner.Pin(header.Number, blockResult.Root); err != nil {
+			return nil, err
+		}
+	}
+
+	if root := buildroot.CalculateReceiptsRoot(blockResult.Receipts); root != header.ReceiptsRoot {
+		return nil, fmt.Errorf("mismatch receipt root %s != %s", header.ReceiptsRoot, root)
+	}
+
+	if root := buildroot.CalculateTransactionsRoot(block.Transactions); root != header.TxRoot {
+		return nil, fmt.Errorf("mismatch transaction root %s != %s", header.TxRoot, root)
+	}
+
+	if blockResult.Root != header.StateRoot {
+		return nil, fmt.Errorf("mismatch state root %s != %s", header.StateRoot, blockResult.Root)
+	}
+
+	return blockResult, nil
+}
+
+// updateExecutionMetrics records the execution time and the gas throughput
+// of the block
+func (b *Blockchain) updateExecutionMetrics(header *types.Header, elapsed time.Duration) {
+	b.metrics.BlockExecutionSeconds.Observe(elapsed.Seconds())
+
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		b.metrics.GasPerSecond.Set(float64(header.GasUsed) / seconds)
+	}
+}
+
+// writeCanonicalData writes the data which is only kept for canonical blocks,
+// the receipts, the transaction lookups and the bloom index
+func (b *Blockchain) writeCanonicalData(w ethdb.Writer, block *types.Block, blockResult *BlockResult) error {
+	header := block.Header
+
+	if err := rawdb.WrteReceipts(w, blockResult.Receipts); err != nil {
+		return err
+	}
+
+	if err := rawdb.WriteTxLookUp(w, header.Number, block.Transactions); err != nil {
+		return err
+	}
+
+	return rawdb.WriteBloomIndex(w, header.Number, header.Hash, header.LogsBloom)
+}
+
 // updateGasPriceAvgWithBlock extracts the gas price information from the
 // block, and updates the average gas price for the chain accordingly
 func (b *Blockchain) updateGasPriceAvgWithBlock(block *types.Block) {
@@ -407,24 +466,54 @@ func (b *Blockchain) executeBlockTransactions(block *types.Block) (*BlockResult,
 	b.wg.Add(1)
 	defer b.wg.Done()
 
+	txn, txs, err := b.BeginBlockTransition(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(txs) > 0 {
+		if _, err := b.executor.ProcessTransactions(txn, block.Header.GasLimit, txs); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.isStopped() {
+		// execute stop, should not commit
+		return nil, ErrClosed
+	}
+
+	// commit world state
+	_, root := txn.Commit()
+
+	return &BlockResult{
+		Root:     root,
+		Receipts: txn.Receipts(),
+		TotalGas: txn.TotalGas(),
+	}, nil
+}
+
+// BeginBlockTransition prepares the execution of the block on top of its
+// parent state. It returns the transition with the system contracts upgraded
+// and the transactions of the block in execution order.
+func (b *Blockchain) BeginBlockTransition(block *types.Block) (*state.Transition, []*types.Transaction, error) {
 	header := block.Header
 
 	parent, err := rawdb.ReadHeader(b.chaindb, header.ParentHash)
 	if err != nil {
-		return nil, ErrParentNotFound
+		return nil, nil, ErrParentNotFound
 	}
 
 	height := header.Number
 
 	blockCreator, err := ecrecoverFromHeader(header)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// prepare execution
 	txn, err := b.executor.BeginTxn(parent.StateRoot, block.Header, blockCreator)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// upgrade system contract first if needed
@@ -439,44 +528,21 @@ func (b *Blockchain) executeBlockTransactions(block *types.Block) (*BlockResult,
 	// there might be 2 system transactions, slash or deposit
 	systemTxs := make([]*types.Transaction, 0, 2)
 	// normal transactions which is not consensus associated
-	normalTxs := make([]*types.Transaction, 0, len(block.Transactions))
+	txs := make([]*types.Transaction, 0, len(block.Transactions))
 
 	// the include sequence should be same as execution, otherwise it failed on state root comparison
 	for _, tx := range block.Transactions {
 		if b.IsSystemTransaction(height, blockCreator, tx) {
 			systemTxs = append(systemTxs, tx)
-			continue
-		}
 
-		normalTxs = append(normalTxs, tx)
-	}
-
-	if len(normalTxs) > 0 {
-		// execute normal transaction first
-		if _, err := b.executor.ProcessTransactions(txn, header.GasLimit, normalTxs); err != nil {
-			return nil, err
-		}
-	}
-
-	if len(systemTxs) > 0 {
-		if _, err := b.executor.ProcessTransactions(txn, header.GasLimit, systemTxs); err != nil {
-			return nil, err
+			continue
 		}
-	}
 
-	if b.isStopped() {
-		// execute stop, should not commit
-		return nil, ErrClosed
+		txs = append(txs, tx)
 	}
 
-	// commit world state
-	_, root := txn.Commit()
-
-	return &BlockResult{
-		Root:     root,
-		Receipts: txn.Receipts(),
-		TotalGas: txn.TotalGas(),
-	}, nil
+	// normal transactions are executed first
+	return txn, append(txs, systemTxs...), nil
 }
 
 func (b *Blockchain) IsSystemTransaction(height uint64, coinbase types.Address, tx *types.Transaction) bool {
@@ -544,20 +610,15 @@ func (b *Blockchain) getSigner(height uint64) crypto.TxSigner {
 }
 
 // writeBody writes the block body to the DB.
-// Additionally, it also updates the txn lookup, for txnHash -> block lookups
-func (b *Blockchain) writeBody(block *types.Block) error {
-
-	err := rawdb.WriteTransactions(b.chaindb, block.Transactions)
-	if err != nil {
-		return err
-	}
+// The txn lookups are only written once the block is canonical
+func (b *Blockchain) writeBody(w ethdb.Writer, block *types.Block) error {
 
-	err = rawdb.WriteBody(b.chaindb, block.Hash(), block.Transactions)
+	err := rawdb.WriteTransactions(w, block.Transactions)
 	if err != nil {
 		return err
 	}
 
-	err = rawdb.WriteTxLookUp(b.chaindb, block.Number(), block.Transactions)
+	err = rawdb.WriteBody(w, block.Hash(), block.Transactions)
 	if err != nil {
 		return err
 	}
@@ -581,10 +642,10 @@ func (b *Blockchain) VerifyFinalizedBlock(block *types.Block) error {
 		return ErrNoBlockHeader
 	}
 
-	if latest, ok := rawdb.ReadHeadNumber(b.chaindb); ok {
-		if latest > block.Number() {
-			return ErrExistBlock
-		}
+	// blocks below the head are fine as long as they are new, they might
+	// belong to a side chain
+	if _, ok := b.readTotalDifficulty(block.Header.Hash); ok {
+		return ErrExistBlock
 	}
 
 	// Make sure the consensus layer verifies this block header
@@ -679,7 +740,6 @@ func (b *Blockchain) HandleGenesis() error {
 
 	head, ok := rawdb.ReadHeadHash(b.chaindb)
 	if ok { // non empty storage
-		b.SelfCheck()
 		genesis, ok := rawdb.ReadCanonicalHash(b.chaindb, 0)
 		if !ok {
 			return fmt.Errorf("failed to load genesis hash")
@@ -695,13 +755,28 @@ func (b *Blockchain) HandleGenesis() error {
 		}
 		b.logger.Info("current header", "hash", head.String(), "number", header.Number)
 
-		b.setCurHeader(header, header.Difficulty)
+		if !rawdb.ReadCumulativeTD(b.chaindb) {
+			if err := b.migrateTotalDifficulty(header); err != nil {
+				return fmt.Errorf("failed to migrate total difficulty: %w", err)
+			}
+		}
+
+		td, ok := b.readTotalDifficulty(header.Hash)
+		if !ok {
+			return fmt.Errorf("failed to get total difficulty of header %s", head.String())
+		}
+
+		b.setCurHeader(header, td.Uint64())
 
 	} else { // empty storage, write the genesis
 
 		if err := b.writeGenesis(b.config.Genesis); err != nil {
 			return err
 		}
+
+		if err := rawdb.WriteCumulativeTD(b.chaindb); err != nil {
+			return err
+		}
 	}
 
 	b.logger.Info("genesis", "hash", b.config.Genesis.Hash())
@@ -709,22 +784,105 @@ func (b *Blockchain) HandleGenesis() error {
 	return nil
 }
 
+// tdMigrationBatchSize is the number of total difficulties rewritten in
+// one batch by the migration
+const tdMigrationBatchSize = 10000
+
+// migrateTotalDifficulty rewrites the total difficulties of the canonical
+// chain up to the head as the sum over the chain. Older databases store the
+// difficulty of the block, which breaks the comparison of branches. The
+// migration is idempotent, an interrupted one starts over on the next boot.
+func (b *Blockchain) migrateTotalDifficulty(head *types.Header) error {
+	b.logger.Info("migrate total difficulty", "head", head.Number)
+
+	var (
+		batch = b.chaindb.Batch()
+		td    uint64
+	)
+
+	// the batch is replaced after every write
+	defer func() { batch.Discard() }()
+
+	for n := uint64(0); n <= head.Number; n++ {
+		header, ok := b.GetHeaderByNumber(n)
+		if !ok {
+			return fmt.Errorf("canonical header %d not found", n)
+		}
+
+		td += header.Difficulty
+
+		if err := rawdb.WriteTD(batch, header.Hash, td); err != nil {
+			return err
+		}
+
+		if n > 0 && n%tdMigrationBatchSize == 0 {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+
+			b.logger.Info("migrate total difficulty", "number", n, "head", head.Number)
+
+			batch = b.chaindb.Batch()
+		}
+	}
+
+	if err := rawdb.WriteCumulativeTD(batch); err != nil {
+		return err
+	}
+
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	b.difficultyCache.Purge()
+
+	return nil
+}
+
+// WriteHeader writes the header on top of its parent and makes it the new head
 func (b *Blockchain) WriteHeader(header *types.Header) error {
-	err := rawdb.WriteHeader(b.chaindb, header)
-	if err != nil {
-		return fmt.Errorf("failed to write header %s %v", header.Hash, err)
+	td := header.Difficulty
+	if header.Number > 0 {
+		parentTD, ok := b.readTotalDifficulty(header.ParentHash)
+		if !ok {
+			return ErrParentNotFound
+		}
+
+		td += parentTD.Uint64()
+	}
+
+	batch := b.chaindb.Batch()
+	defer batch.Discard()
+
+	if err := b.writeHeader(batch, header, td); err != nil {
+		return err
 	}
 
 	// Advance the head
-	if _, err = b.advanceHead(header); err != nil {
+	if err := b.advanceHead(batch, header, td); err != nil {
 		return err
 	}
 
-	// Create an event and send it to the stream
-	event := &Event{}
+	b.pushHeadEvent(header)
+
+	return nil
+}
+
+// writeHeader writes the header with its total difficulty
+func (b *Blockchain) writeHeader(w ethdb.Writer, header *types.Header, td uint64) error {
+	if err := rawdb.WriteHeader(w, header); err != nil {
+		return fmt.Errorf("failed to write header %s %v", header.Hash, err)
+	}
+
+	return rawdb.WriteTD(w, header.Hash, td)
+}
+
+// pushHeadEvent sends the new head to the event stream
+func (b *Blockchain) pushHeadEvent(header *types.Header) {
+	event := &Event{Type: EventHead}
 	event.AddNewHeader(header)
+	event.SetDifficulty(b.CurrentTD())
 	b.stream.push(event)
-	return nil
 }
 
 func (b *Blockchain) writeGenesis(genesis *chain.Genesis) error {
@@ -733,10 +891,6 @@ func (b *Blockchain) writeGenesis(genesis *chain.Genesis) error {
 	header.ComputeHash()
 	b.genesis = header.Hash
 
-	if err := rawdb.WriteTD(b.chaindb, header.Hash, 1); err != nil {
-		return fmt.Errorf("write td failed %v", err)
-	}
-
 	return b.WriteHeader(header)
 }
 
@@ -746,17 +900,13 @@ func (b *Blockchain) VerifyHeader(header *types.Header) error {
 		return nil
 	}
 
-	// check parent hash
-	hash, ok := rawdb.ReadCanonicalHash(b.chaindb, header.Number-1)
-	if !ok {
-		return fmt.Errorf("not found block %d ", header.Number-1)
-	}
-	parent, err := rawdb.ReadHeader(b.chaindb, hash)
+	// check parent, which is not necessarily canonical
+	parent, err := rawdb.ReadHeader(b.chaindb, header.ParentHash)
 	if err != nil {
-		return fmt.Errorf("get parent header %v", err)
+		return fmt.Errorf("get parent header %s of block %d: %w", header.ParentHash, header.Number, err)
 	}
-	if parent.Hash != header.ParentHash {
-		return fmt.Errorf("unexpected header %s != %s %s", parent.Hash, header.ParentHash, parent.ComputeHash().Hash)
+	if parent.Number+1 != header.Number {
+		return ErrInvalidBlockSequence
 	}
 	// check header self hash
 	if header.Hash != types.HeaderHash(header) {
@@ -781,31 +931,33 @@ func (b *Blockchain) addHeaderSnap(header *types.Header) error {
 	return rawdb.WriteSnap(b.chaindb, header.Number, s)
 }
 
-func (b *Blockchain) advanceHead(newHeader *types.Header) (*big.Int, error) {
-	err := rawdb.WriteHeadHash(b.chaindb, newHeader.Hash)
+// advanceHead makes the header with the total difficulty the head of the
+// chain. The head records are added to the batch, which is then written, so
+// the head only moves along with the rest of the batch.
+func (b *Blockchain) advanceHead(batch ethdb.Batch, newHeader *types.Header, td uint64) error {
+	err := rawdb.WriteCanonicalHash(batch, newHeader.Number, newHeader.Hash)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = rawdb.WriteHeadNumber(b.chaindb, newHeader.Number)
+	err = rawdb.WriteHeadHash(batch, newHeader.Hash)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = rawdb.WriteCanonicalHash(b.chaindb, newHeader.Number, newHeader.Hash)
+	err = rawdb.WriteHeadNumber(batch, newHeader.Number)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Calculate the new total difficulty
-	if err := rawdb.WriteTD(b.chaindb, newHeader.Hash, newHeader.Difficulty); err != nil {
-		return nil, err
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("failed to write block %d %s: %w", newHeader.Number, newHeader.Hash, err)
 	}
 
 	// Update the blockchain reference
-	b.setCurHeader(newHeader, newHeader.Difficulty)
+	b.setCurHeader(newHeader, td)
 
-	return nil, nil
+	return nil
 }
 
 func (b *Blockchain) readTotalDifficulty(headerHash types.Hash) (*big.Int, bool) {
@@ -833,9 +985,11 @@ func (b *Blockchain) readTotalDifficulty(headerHash types.Hash) (*big.Int, bool)
 	return dbDifficulty, true
 }
 
-func (b *Blockchain) setCurHeader(header *types.Header, diff uint64) {
+func (b *Blockchain) setCurHeader(header *types.Header, td uint64) {
 	b.currentHeader.Store(header.Copy())
-	b.currentDifficulty.Store(big.NewInt(int64(diff)))
+	b.currentDifficulty.Store(new(big.Int).SetUint64(td))
+
+	b.metrics.Height.Set(float64(header.Number))
 }
 
 func (b *Blockchain) Header() *types.Header {
@@ -853,7 +1007,36 @@ func (b *Blockchain) GetBlockByNumber(blockNumber uint64, full bool) (*types.Blo
 	if !ok {
 		return nil, false
 	}
-	return rawdb.ReadBlockByHash(b.chaindb, blkHash)
+
+	return b.GetBlockByHash(blkHash, full)
+}
+
+// GetBlockByHash returns the block by hash, the transactions are only
+// loaded when full is set
+func (b *Blockchain) GetBlockByHash(hash types.Hash, full bool) (*types.Block, bool) {
+	if !full {
+		header, ok := b.GetHeaderByHash(hash)
+		if !ok {
+			return nil, false
+		}
+
+		return &types.Block{Header: header}, true
+	}
+
+	return rawdb.ReadBlockByHash(b.chaindb, hash)
+}
+
+// GetBlockCreator returns the address of the validator which sealed the block
+func (b *Blockchain) GetBlockCreator(header *types.Header) (types.Address, error) {
+	return ecrecoverFromHeader(header)
+}
+
+// GetAvgGasPrice returns the average gas price for the chain
+func (b *Blockchain) GetAvgGasPrice() *big.Int {
+	b.gpAverage.RLock()
+	defer b.gpAverage.RUnlock()
+
+	return new(big.Int).Set(b.gpAverage.price)
 }
 
 // SubscribeEvents returns a blockchain event subscription
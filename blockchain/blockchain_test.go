@@ -0,0 +1,107 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func TestBlockchain_WriteHeader(t *testing.T) {
+	b := newTestBlockchain(t)
+
+	genesis := &types.Header{Difficulty: 1, Hash: types.StringToHash("0x01")}
+	assert.NoError(t, b.WriteHeader(genesis))
+
+	next := &types.Header{
+		Number:     1,
+		ParentHash: genesis.Hash,
+		Difficulty: 2,
+		Hash:       types.StringToHash("0x02"),
+	}
+	assert.NoError(t, b.WriteHeader(next))
+
+	// the header, its total difficulty and the head are all written
+	header, err := rawdb.ReadHeader(b.chaindb, next.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, next.ParentHash, header.ParentHash)
+
+	td, ok := rawdb.ReadTD(b.chaindb, next.Hash)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), td.Uint64())
+
+	hash, ok := rawdb.ReadCanonicalHash(b.chaindb, 1)
+	assert.True(t, ok)
+	assert.Equal(t, next.Hash, hash)
+
+	head, ok := rawdb.ReadHeadHash(b.chaindb)
+	assert.True(t, ok)
+	assert.Equal(t, next.Hash, head)
+
+	number, ok := rawdb.ReadHeadNumber(b.chaindb)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), number)
+
+	assert.Equal(t, next.Hash, b.Header().Hash)
+	assert.Equal(t, uint64(3), b.CurrentTD().Uint64())
+
+	// an unknown parent writes nothing
+	orphan := &types.Header{
+		Number:     2,
+		ParentHash: types.StringToHash("0xff"),
+		Difficulty: 1,
+		Hash:       types.StringToHash("0x03"),
+	}
+	assert.ErrorIs(t, b.WriteHeader(orphan), ErrParentNotFound)
+
+	_, err = rawdb.ReadHeader(b.chaindb, orphan.Hash)
+	assert.Error(t, err)
+}
+
+func TestBlockchain_MigrateTotalDifficulty(t *testing.T) {
+	b := newTestBlockchain(t)
+
+	// older databases store the difficulty of each block
+	var parent types.Hash
+
+	headers := make([]*types.Header, 0, 4)
+
+	for n := uint64(0); n < 4; n++ {
+		h := &types.Header{
+			Number:     n,
+			ParentHash: parent,
+			Difficulty: n + 1,
+			Hash:       types.BytesToHash([]byte{byte(n + 1)}),
+		}
+
+		assert.NoError(t, rawdb.WriteHeader(b.chaindb, h))
+		assert.NoError(t, rawdb.WriteTD(b.chaindb, h.Hash, h.Difficulty))
+		assert.NoError(t, rawdb.WriteCanonicalHash(b.chaindb, n, h.Hash))
+
+		headers = append(headers, h)
+		parent = h.Hash
+	}
+
+	assert.False(t, rawdb.ReadCumulativeTD(b.chaindb))
+
+	// cache a stale total difficulty
+	_, ok := b.GetTD(headers[3].Hash)
+	assert.True(t, ok)
+
+	assert.NoError(t, b.migrateTotalDifficulty(headers[3]))
+	assert.True(t, rawdb.ReadCumulativeTD(b.chaindb))
+
+	for i, expected := range []uint64{1, 3, 6, 10} {
+		td, ok := b.GetTD(headers[i].Hash)
+		assert.True(t, ok)
+		assert.Equal(t, expected, td.Uint64())
+	}
+
+	// running it again changes nothing
+	assert.NoError(t, b.migrateTotalDifficulty(headers[3]))
+
+	td, ok := b.GetTD(headers[3].Hash)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(10), td.Uint64())
+}
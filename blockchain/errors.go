@@ -1,6 +1,11 @@
 package blockchain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sunvim/dogesyncer/state"
+)
 
 var (
 	ErrNoBlock              = errors.New("no block data passed in")
@@ -18,4 +23,7 @@ var (
 	ErrNilStorageBuilder    = errors.New("nil storage builder")
 	ErrClosed               = errors.New("blockchain is closed")
 	ErrExistBlock           = errors.New("exist block")
+	ErrSetHeadAboveHead     = errors.New("new head is above the current head")
+	ErrNoHead               = errors.New("no head in the database")
+	ErrReorgTooDeep         = fmt.Errorf("reorg too deep: %w", state.ErrStateNotAvailable)
 )
@@ -0,0 +1,74 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/sunvim/dogesyncer/rawdb"
+)
+
+// ReloadHead makes the head written to the database by another process the
+// current head, so a reader of a shared database follows the writer. It
+// reports whether the head changed. Subscribers get the blocks added on top
+// of the previous head, or a reorg event when the writer switched branches
+// or rewound the chain.
+func (b *Blockchain) ReloadHead() (bool, error) {
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	hash, ok := rawdb.ReadHeadHash(b.chaindb)
+	if !ok {
+		return false, ErrNoHead
+	}
+
+	current := b.Header()
+	if current != nil && current.Hash == hash {
+		return false, nil
+	}
+
+	header, err := rawdb.ReadHeader(b.chaindb, hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to get header with hash %s err: %w", hash, err)
+	}
+
+	td, ok := b.readTotalDifficulty(hash)
+	if !ok {
+		return false, fmt.Errorf("failed to get total difficulty of header %s", hash)
+	}
+
+	b.setCurHeader(header, td.Uint64())
+
+	if current == nil {
+		return true, nil
+	}
+
+	event := &Event{Type: EventHead}
+
+	oldChain, newChain, err := b.findBranches(current, header)
+	if err != nil {
+		// the branches are unknown, only report the new head
+		b.logger.Warn("failed to find the branches of the new head", "hash", hash, "err", err)
+
+		oldChain, newChain = nil, nil
+	}
+
+	if len(oldChain) > 0 || err != nil {
+		event.Type = EventReorg
+	}
+
+	for _, h := range oldChain {
+		event.AddOldHeader(h)
+	}
+
+	if len(newChain) == 0 {
+		newChain = append(newChain, header)
+	}
+
+	for _, h := range newChain {
+		event.AddNewHeader(h)
+	}
+
+	event.SetDifficulty(td)
+	b.stream.push(event)
+
+	return true, nil
+}
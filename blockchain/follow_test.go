@@ -0,0 +1,89 @@
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func TestBlockchain_ReloadHead(t *testing.T) {
+	b := newTestBlockchain(t)
+
+	_, err := b.ReloadHead()
+	assert.ErrorIs(t, err, ErrNoHead)
+
+	// the writer is simulated by writing the canonical chain directly
+	setHead := func(headers []*types.Header) {
+		for _, h := range headers {
+			assert.NoError(t, rawdb.WriteCanonicalHash(b.chaindb, h.Number, h.Hash))
+		}
+
+		head := headers[len(headers)-1]
+		assert.NoError(t, rawdb.WriteHeadHash(b.chaindb, head.Hash))
+		assert.NoError(t, rawdb.WriteHeadNumber(b.chaindb, head.Number))
+	}
+
+	nextEvent := func(eventCh chan *Event) *Event {
+		select {
+		case ev := <-eventCh:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+		}
+
+		return nil
+	}
+
+	base := appendHeaders(t, b, nil, 3, 0)
+	setHead(base)
+
+	changed, err := b.ReloadHead()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, base[2].Hash, b.Header().Hash)
+	assert.Equal(t, uint64(3), b.CurrentTD().Uint64())
+
+	changed, err = b.ReloadHead()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	sub := b.SubscribeEvents()
+	defer sub.Close()
+
+	eventCh := sub.GetEventCh()
+
+	// the blocks written since the last reload are all reported
+	next := appendHeaders(t, b, base[2], 2, 0)
+	setHead(next)
+
+	changed, err = b.ReloadHead()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	ev := nextEvent(eventCh)
+	assert.Equal(t, EventHead, ev.Type)
+	assert.Empty(t, ev.OldChain)
+
+	if assert.Len(t, ev.NewChain, 2) {
+		assert.Equal(t, next[0].Hash, ev.NewChain[0].Hash)
+		assert.Equal(t, next[1].Hash, ev.NewChain[1].Hash)
+	}
+
+	// a branch switch is a reorg
+	fork := appendHeaders(t, b, base[2], 3, 1)
+	setHead(fork)
+
+	changed, err = b.ReloadHead()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	ev = nextEvent(eventCh)
+	assert.Equal(t, EventReorg, ev.Type)
+	assert.Len(t, ev.OldChain, 2)
+	assert.Len(t, ev.NewChain, 3)
+	assert.Equal(t, fork[2].Hash, b.Header().Hash)
+	assert.Equal(t, uint64(6), b.CurrentTD().Uint64())
+}
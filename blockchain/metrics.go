@@ -0,0 +1,71 @@
+package blockchain
+
+import (
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	prometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics represents the blockchain metrics
+type Metrics struct {
+	// Height of the local chain head
+	Height metrics.Gauge
+
+	// Time spent executing the transactions of a block
+	BlockExecutionSeconds metrics.Histogram
+
+	// Gas executed per second by the last block
+	GasPerSecond metrics.Gauge
+}
+
+// GetPrometheusMetrics return the blockchain metrics instance
+func GetPrometheusMetrics(namespace string, labelsWithValues ...string) *Metrics {
+	labels := []string{}
+
+	for i := 0; i < len(labelsWithValues); i += 2 {
+		labels = append(labels, labelsWithValues[i])
+	}
+
+	return &Metrics{
+		Height: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "blockchain",
+			Name:      "height",
+			Help:      "Height of the local chain head",
+		}, labels).With(labelsWithValues...),
+
+		BlockExecutionSeconds: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "blockchain",
+			Name:      "block_execution_seconds",
+			Help:      "Time spent executing the transactions of a block",
+			Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		}, labels).With(labelsWithValues...),
+
+		GasPerSecond: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "blockchain",
+			Name:      "gas_per_second",
+			Help:      "Gas executed per second by the last block",
+		}, labels).With(labelsWithValues...),
+	}
+}
+
+// NilMetrics will return the non operational blockchain metrics
+func NilMetrics() *Metrics {
+	return &Metrics{
+		Height:                discard.NewGauge(),
+		BlockExecutionSeconds: discard.NewHistogram(),
+		GasPerSecond:          discard.NewGauge(),
+	}
+}
+
+// NewDummyMetrics will return the no nil blockchain metrics
+func NewDummyMetrics(metrics *Metrics) *Metrics {
+	if metrics != nil {
+		return metrics
+	}
+
+	return NilMetrics()
+}
@@ -0,0 +1,275 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/state"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// writeSideBlock stores a block which does not extend the head. It is not
+// executed until its branch becomes canonical, which happens as soon as its
+// total difficulty exceeds the one of the current head.
+func (b *Blockchain) writeSideBlock(block *types.Block, td uint64) error {
+	header := block.Header
+
+	batch := b.chaindb.Batch()
+	defer batch.Discard()
+
+	if err := b.writeBody(batch, block); err != nil {
+		return err
+	}
+
+	if err := b.writeHeader(batch, header, td); err != nil {
+		return err
+	}
+
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("failed to write side block %d %s: %w", header.Number, header.Hash, err)
+	}
+
+	current := b.Header()
+	currentTD := b.CurrentTD()
+
+	if new(big.Int).SetUint64(td).Cmp(currentTD) <= 0 {
+		b.logger.Info("side block", "number", header.Number, "hash", header.Hash, "head", current.Number)
+
+		event := &Event{Type: EventFork}
+		event.AddNewHeader(header)
+		event.SetDifficulty(currentTD)
+		b.stream.push(event)
+
+		return nil
+	}
+
+	return b.reorg(current, header, td)
+}
+
+// reorg replaces the canonical branch ending at oldHead with the one ending
+// at newHead. The new branch is executed first, so a failing block leaves
+// the old branch in place.
+func (b *Blockchain) reorg(oldHead, newHead *types.Header, td uint64) error {
+	oldChain, newChain, err := b.findBranches(oldHead, newHead)
+	if err != nil {
+		return err
+	}
+
+	if err := b.checkReorgDepth(newChain[0]); err != nil {
+		return err
+	}
+
+	b.logger.Info(
+		"chain reorg",
+		"ancestor", newChain[0].Number-1,
+		"old", oldHead.Number,
+		"oldHash", oldHead.Hash,
+		"new", newHead.Number,
+		"newHash", newHead.Hash,
+		"dropped", len(oldChain),
+		"added", len(newChain),
+	)
+
+	// re-execute the new branch on top of the common ancestor
+	blocks := make([]*types.Block, len(newChain))
+	results := make([]*BlockResult, len(newChain))
+
+	for i, header := range newChain {
+		block, ok := rawdb.ReadBlockByHash(b.chaindb, header.Hash)
+		if !ok {
+			return fmt.Errorf("reorg: block %d %s not found", header.Number, header.Hash)
+		}
+
+		result, err := b.processBlock(block)
+		if err != nil {
+			return fmt.Errorf("reorg: execute block %d %s: %w", header.Number, header.Hash, err)
+		}
+
+		blocks[i] = block
+		results[i] = result
+	}
+
+	// the switch is written in a single batch along with the new head
+	batch := b.chaindb.Batch()
+	defer batch.Discard()
+
+	// rewind the old branch, the heights of the new branch are written
+	// again below in the same batch
+	for _, header := range oldChain {
+		if err := rawdb.DeleteCanonicalData(b.chaindb, batch, header.Number, header.Hash); err != nil {
+			return err
+		}
+	}
+
+	// make the new branch canonical
+	for i, block := range blocks {
+		if err := b.writeCanonicalData(batch, block, results[i]); err != nil {
+			return err
+		}
+
+		if err := rawdb.WriteCanonicalHash(batch, block.Number(), block.Hash()); err != nil {
+			return err
+		}
+	}
+
+	if err := b.advanceHead(batch, newHead, td); err != nil {
+		return err
+	}
+
+	for _, block := range blocks {
+		b.updateGasPriceAvgWithBlock(block)
+	}
+
+	event := &Event{Type: EventReorg}
+	for _, header := range oldChain {
+		event.AddOldHeader(header)
+	}
+
+	for _, header := range newChain {
+		event.AddNewHeader(header)
+	}
+
+	event.SetDifficulty(b.CurrentTD())
+	b.stream.push(event)
+
+	return nil
+}
+
+// SetHead rewinds the chain to the canonical block of the number. The
+// canonical data of the blocks above is removed in a single batch and the
+// blocks are forgotten, so they are synced and executed again. Subscribers
+// get a reorg event dropping the removed blocks.
+func (b *Blockchain) SetHead(number uint64) error {
+	if b.isStopped() {
+		return ErrClosed
+	}
+	b.wg.Add(1)
+	defer b.wg.Done()
+
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	current := b.Header()
+	if number > current.Number {
+		return fmt.Errorf("%w: %d > %d", ErrSetHeadAboveHead, number, current.Number)
+	}
+
+	if number == current.Number {
+		return nil
+	}
+
+	dropped := make([]*types.Header, 0, current.Number-number)
+
+	for n := number + 1; n <= current.Number; n++ {
+		if header, ok := b.GetHeaderByNumber(n); ok {
+			dropped = append(dropped, header)
+		}
+	}
+
+	header, err := rawdb.Rewind(b.chaindb, number)
+	if err != nil {
+		return err
+	}
+
+	// the dropped blocks lost their total difficulty
+	b.difficultyCache.Purge()
+
+	td, ok := b.readTotalDifficulty(header.Hash)
+	if !ok {
+		return fmt.Errorf("failed to get total difficulty of header %s", header.Hash)
+	}
+
+	b.setCurHeader(header, td.Uint64())
+
+	b.logger.Info("set head", "number", header.Number, "hash", header.Hash, "dropped", len(dropped))
+
+	event := &Event{Type: EventReorg}
+	for _, h := range dropped {
+		event.AddOldHeader(h)
+	}
+
+	event.AddNewHeader(header)
+	event.SetDifficulty(td)
+	b.stream.push(event)
+
+	return nil
+}
+
+// checkReorgDepth makes sure the new branch starting at the header can be
+// executed on the state of its parent, the common ancestor. The states of
+// a pruned block are gone, and the ones pinned again for its height would
+// never be released.
+func (b *Blockchain) checkReorgDepth(first *types.Header) error {
+	ancestor, err := b.parentHeader(first)
+	if err != nil {
+		return err
+	}
+
+	if b.pruner != nil {
+		if last, ok := b.pruner.LastPruned(); ok && ancestor.Number < last {
+			return fmt.Errorf("%w: ancestor %d is below the last pruned block %d", ErrReorgTooDeep, ancestor.Number, last)
+		}
+	}
+
+	if _, err := b.state.NewSnapshotAt(ancestor.StateRoot); err != nil {
+		if errors.Is(err, state.ErrStateNotAvailable) {
+			return fmt.Errorf("%w: ancestor %d %s", ErrReorgTooDeep, ancestor.Number, ancestor.Hash)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// findBranches walks both heads back to their common ancestor. The returned
+// branches exclude the ancestor and are sorted by ascending number.
+func (b *Blockchain) findBranches(oldHead, newHead *types.Header) ([]*types.Header, []*types.Header, error) {
+	var (
+		oldChain []*types.Header
+		newChain []*types.Header
+		err      error
+	)
+
+	for oldHead.Hash != newHead.Hash {
+		if oldHead.Number >= newHead.Number {
+			oldChain = append(oldChain, oldHead)
+
+			if oldHead, err = b.parentHeader(oldHead); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			newChain = append(newChain, newHead)
+
+			if newHead, err = b.parentHeader(newHead); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	reverseHeaders(oldChain)
+	reverseHeaders(newChain)
+
+	return oldChain, newChain, nil
+}
+
+func (b *Blockchain) parentHeader(header *types.Header) (*types.Header, error) {
+	if header.Number == 0 {
+		return nil, ErrParentNotFound
+	}
+
+	parent, err := rawdb.ReadHeader(b.chaindb, header.ParentHash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrParentNotFound, header.ParentHash)
+	}
+
+	return parent, nil
+}
+
+func reverseHeaders(headers []*types.Header) {
+	for i, j := 0, len(headers)-1; i < j; i, j = i+1, j-1 {
+		headers[i], headers[j] = headers[j], headers[i]
+	}
+}
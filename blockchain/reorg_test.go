@@ -0,0 +1,454 @@
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/chain"
+	"github.com/sunvim/dogesyncer/crypto"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/state"
+	itrie "github.com/sunvim/dogesyncer/state/immutable-trie"
+	"github.com/sunvim/dogesyncer/state/runtime/evm"
+	"github.com/sunvim/dogesyncer/types"
+	"github.com/sunvim/dogesyncer/types/buildroot"
+)
+
+func newTestBlockchain(t *testing.T) *Blockchain {
+	t.Helper()
+
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	t.Cleanup(func() { db.Close() })
+
+	b, err := NewBlockchain(hclog.NewNullLogger(), db, nil, nil, nil, nil)
+	assert.NoError(t, err)
+
+	return b
+}
+
+// appendHeaders writes a branch of n headers on top of parent, the seed
+// keeps the hashes of different branches apart
+func appendHeaders(t *testing.T, b *Blockchain, parent *types.Header, n int, seed byte) []*types.Header {
+	t.Helper()
+
+	td := uint64(0)
+	if parent != nil {
+		parentTD, ok := b.GetTD(parent.Hash)
+		assert.True(t, ok)
+
+		td = parentTD.Uint64()
+	}
+
+	headers := make([]*types.Header, 0, n)
+
+	for i := 0; i < n; i++ {
+		h := &types.Header{Difficulty: 1, StateRoot: types.EmptyRootHash}
+		if parent != nil {
+			h.Number = parent.Number + 1
+			h.ParentHash = parent.Hash
+		}
+
+		h.Hash = types.BytesToHash([]byte{seed, byte(h.Number)})
+		td += h.Difficulty
+
+		assert.NoError(t, rawdb.WriteHeader(b.chaindb, h))
+		assert.NoError(t, rawdb.WriteTD(b.chaindb, h.Hash, td))
+
+		headers = append(headers, h)
+		parent = h
+	}
+
+	return headers
+}
+
+func TestBlockchain_FindBranches(t *testing.T) {
+	b := newTestBlockchain(t)
+
+	base := appendHeaders(t, b, nil, 3, 0)
+	old := appendHeaders(t, b, base[2], 2, 1)
+	fork := appendHeaders(t, b, base[2], 4, 2)
+
+	oldChain, newChain, err := b.findBranches(old[1], fork[3])
+	assert.NoError(t, err)
+	assert.Equal(t, old, oldChain)
+	assert.Equal(t, fork, newChain)
+
+	// a plain extension has no old branch
+	oldChain, newChain, err = b.findBranches(base[1], old[1])
+	assert.NoError(t, err)
+	assert.Empty(t, oldChain)
+	assert.Equal(t, []*types.Header{base[2], old[0], old[1]}, newChain)
+
+	// unrelated chains do not share an ancestor
+	other := appendHeaders(t, b, nil, 2, 3)
+	_, _, err = b.findBranches(old[1], other[1])
+	assert.ErrorIs(t, err, ErrParentNotFound)
+}
+
+func TestBlockchain_WriteSideBlock(t *testing.T) {
+	b := newTestBlockchain(t)
+
+	canonical := appendHeaders(t, b, nil, 4, 0)
+	for _, h := range canonical {
+		assert.NoError(t, rawdb.WriteCanonicalHash(b.chaindb, h.Number, h.Hash))
+	}
+
+	head := canonical[3]
+	b.setCurHeader(head, 4)
+
+	sub := b.SubscribeEvents()
+	defer sub.Close()
+
+	// a side block with less total difficulty is only stored
+	side := &types.Header{
+		Number:     2,
+		ParentHash: canonical[1].Hash,
+		Difficulty: 1,
+		TxRoot:     types.EmptyRootHash,
+		Hash:       types.StringToHash("0xf0"),
+	}
+
+	assert.NoError(t, b.writeSideBlock(&types.Block{Header: side}, 3))
+
+	select {
+	case ev := <-sub.GetEventCh():
+		assert.Equal(t, EventFork, ev.Type)
+		assert.Equal(t, side.Hash, ev.NewChain[0].Hash)
+		assert.Empty(t, ev.OldChain)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no fork event")
+	}
+
+	assert.Equal(t, head.Hash, b.Header().Hash)
+
+	hash, ok := rawdb.ReadCanonicalHash(b.chaindb, 2)
+	assert.True(t, ok)
+	assert.Equal(t, canonical[2].Hash, hash)
+
+	td, ok := b.GetTD(side.Hash)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), td.Uint64())
+
+	// known blocks are rejected by the verification
+	assert.ErrorIs(t, b.VerifyFinalizedBlock(&types.Block{Header: side}), ErrExistBlock)
+}
+
+func TestBlockchain_SetHead(t *testing.T) {
+	b := newTestBlockchain(t)
+
+	canonical := appendHeaders(t, b, nil, 5, 0)
+	for _, h := range canonical {
+		assert.NoError(t, rawdb.WriteCanonicalHash(b.chaindb, h.Number, h.Hash))
+		assert.NoError(t, rawdb.WriteBloomIndex(b.chaindb, h.Number, h.Hash, types.Bloom{}))
+	}
+
+	head := canonical[4]
+	assert.NoError(t, rawdb.WriteHeadHash(b.chaindb, head.Hash))
+	assert.NoError(t, rawdb.WriteHeadNumber(b.chaindb, head.Number))
+	b.setCurHeader(head, 5)
+
+	// cache the total difficulty of a block to drop
+	_, ok := b.GetTD(canonical[3].Hash)
+	assert.True(t, ok)
+
+	assert.ErrorIs(t, b.SetHead(5), ErrSetHeadAboveHead)
+
+	sub := b.SubscribeEvents()
+	defer sub.Close()
+
+	// the subscriber is busy, its queue is full
+	for i := 0; i < maxSubscriptionEvents; i++ {
+		b.stream.push(&Event{Type: EventHead, NewChain: []*types.Header{head}})
+	}
+
+	assert.NoError(t, b.SetHead(2))
+
+	for i := 0; i < maxSubscriptionEvents; i++ {
+		assert.Equal(t, EventHead, sub.GetEvent().Type)
+	}
+
+	// the reorg event is not dropped
+	assert.NoError(t, sub.Err())
+
+	select {
+	case ev := <-sub.GetEventCh():
+		assert.Equal(t, EventReorg, ev.Type)
+		if assert.Len(t, ev.OldChain, 2) {
+			assert.Equal(t, canonical[3].Hash, ev.OldChain[0].Hash)
+			assert.Equal(t, canonical[4].Hash, ev.OldChain[1].Hash)
+		}
+
+		assert.Equal(t, canonical[2].Hash, ev.Header().Hash)
+		assert.Equal(t, uint64(3), ev.Difficulty.Uint64())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reorg event")
+	}
+
+	assert.Equal(t, canonical[2].Hash, b.Header().Hash)
+	assert.Equal(t, uint64(3), b.CurrentTD().Uint64())
+
+	hash, ok := rawdb.ReadHeadHash(b.chaindb)
+	assert.True(t, ok)
+	assert.Equal(t, canonical[2].Hash, hash)
+
+	for _, h := range canonical[3:] {
+		_, ok := b.GetHeaderByNumber(h.Number)
+		assert.False(t, ok)
+
+		_, ok = b.GetTD(h.Hash)
+		assert.False(t, ok)
+
+		_, _, ok = rawdb.ReadBloomIndex(b.chaindb, h.Number)
+		assert.False(t, ok)
+	}
+
+	// setting the current head is a noop
+	assert.NoError(t, b.SetHead(2))
+}
+
+// testChain builds executed and sealed blocks on top of a genesis with a
+// funded sender
+type testChain struct {
+	t        *testing.T
+	b        *Blockchain
+	executor *state.Executor
+	params   *chain.Params
+	sender   *ecdsa.PrivateKey
+	miner    *ecdsa.PrivateKey
+	genesis  *types.Header
+}
+
+func newTestChain(t *testing.T) *testChain {
+	t.Helper()
+
+	sender, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+
+	miner, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+
+	params := &chain.Params{Forks: chain.AllForksEnabled, ChainID: 100}
+	st := itrie.NewState(itrie.NewMemoryStorage(), nil)
+
+	executor := state.NewExecutor(params, st, hclog.NewNullLogger())
+	executor.SetRuntime(evm.NewEVM())
+
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	t.Cleanup(func() { db.Close() })
+
+	b, err := NewBlockchain(hclog.NewNullLogger(), db, &chain.Chain{Params: params}, executor, st, nil)
+	assert.NoError(t, err)
+
+	executor.GetHash = b.GetHashHelper
+
+	root := executor.WriteGenesis(map[types.Address]*chain.GenesisAccount{
+		crypto.PubKeyToAddress(&sender.PublicKey): {Balance: big.NewInt(1e18)},
+	})
+
+	genesis := &types.Header{
+		Difficulty: 1,
+		GasLimit:   10000000,
+		StateRoot:  root,
+		TxRoot:     types.EmptyRootHash,
+		Hash:       types.StringToHash("0x01"),
+	}
+	assert.NoError(t, b.WriteHeader(genesis))
+
+	return &testChain{
+		t:        t,
+		b:        b,
+		executor: executor,
+		params:   params,
+		sender:   sender,
+		miner:    miner,
+		genesis:  genesis,
+	}
+}
+
+// block returns a sealed block on top of parent, which transfers one wei
+// to the receiver
+func (c *testChain) block(parent *types.Header, difficulty uint64, to types.Address, nonce uint64) *types.Block {
+	c.t.Helper()
+
+	signer := crypto.NewSigner(c.params.Forks.At(parent.Number+1), uint64(c.params.ChainID))
+
+	tx, err := signer.SignTx(&types.Transaction{
+		Nonce:    nonce,
+		GasPrice: big.NewInt(1),
+		Gas:      21000,
+		To:       &to,
+		Value:    big.NewInt(1),
+	}, c.sender)
+	assert.NoError(c.t, err)
+
+	header := &types.Header{
+		ParentHash: parent.Hash,
+		Number:     parent.Number + 1,
+		Difficulty: difficulty,
+		GasLimit:   parent.GasLimit,
+		Timestamp:  parent.Timestamp + 1,
+		Sha3Uncles: types.EmptyUncleHash,
+	}
+
+	// execute the block to fill in the roots
+	transition, err := c.executor.BeginTxn(parent.StateRoot, header, crypto.PubKeyToAddress(&c.miner.PublicKey))
+	assert.NoError(c.t, err)
+	assert.NoError(c.t, transition.Write(tx))
+
+	_, root := transition.Commit()
+
+	header.StateRoot = root
+	header.GasUsed = transition.TotalGas()
+	header.ReceiptsRoot = buildroot.CalculateReceiptsRoot(transition.Receipts())
+	header.TxRoot = buildroot.CalculateTransactionsRoot([]*types.Transaction{tx})
+
+	c.seal(header)
+
+	return &types.Block{Header: header, Transactions: []*types.Transaction{tx}}
+}
+
+// seal signs the header with the miner key
+func (c *testChain) seal(header *types.Header) {
+	c.t.Helper()
+
+	extra := &types.IstanbulExtra{Validators: []types.Address{}}
+	header.ExtraData = append(make([]byte, types.IstanbulExtraVanity), extra.MarshalRLPTo(nil)...)
+
+	msg, err := types.CalculateHeaderHash(header)
+	assert.NoError(c.t, err)
+
+	extra.Seal, err = crypto.Sign(c.miner, crypto.Keccak256(msg))
+	assert.NoError(c.t, err)
+
+	header.ExtraData = append(make([]byte, types.IstanbulExtraVanity), extra.MarshalRLPTo(nil)...)
+	header.ComputeHash()
+}
+
+func TestBlockchain_Reorg(t *testing.T) {
+	c := newTestChain(t)
+	b := c.b
+
+	var (
+		alice = types.StringToAddress("0xa1")
+		bob   = types.StringToAddress("0xb1")
+	)
+
+	// the canonical chain G - A1 - A2 with a total difficulty of 3
+	a1 := c.block(c.genesis, 1, alice, 0)
+	assert.NoError(t, b.WriteBlock(a1))
+
+	a2 := c.block(a1.Header, 1, alice, 1)
+	assert.NoError(t, b.WriteBlock(a2))
+	assert.Equal(t, a2.Hash(), b.Header().Hash)
+
+	sub := b.SubscribeEvents()
+	defer sub.Close()
+
+	// the side chain G - B1 is heavier, it replaces both blocks
+	b1 := c.block(c.genesis, 5, bob, 0)
+	assert.NoError(t, b.WriteBlock(b1))
+
+	select {
+	case ev := <-sub.GetEventCh():
+		assert.Equal(t, EventReorg, ev.Type)
+		if assert.Len(t, ev.OldChain, 2) {
+			assert.Equal(t, a1.Hash(), ev.OldChain[0].Hash)
+			assert.Equal(t, a2.Hash(), ev.OldChain[1].Hash)
+		}
+
+		if assert.Len(t, ev.NewChain, 1) {
+			assert.Equal(t, b1.Hash(), ev.NewChain[0].Hash)
+		}
+
+		assert.Equal(t, uint64(6), ev.Difficulty.Uint64())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reorg event")
+	}
+
+	assert.Equal(t, b1.Hash(), b.Header().Hash)
+	assert.Equal(t, uint64(6), b.CurrentTD().Uint64())
+
+	hash, ok := rawdb.ReadCanonicalHash(b.chaindb, 1)
+	assert.True(t, ok)
+	assert.Equal(t, b1.Hash(), hash)
+
+	_, ok = rawdb.ReadCanonicalHash(b.chaindb, 2)
+	assert.False(t, ok)
+
+	// the transactions of the old branch are no longer indexed and their
+	// receipts are gone
+	for _, tx := range append(a1.Transactions, a2.Transactions...) {
+		_, ok := rawdb.ReadTxLookUp(b.chaindb, tx.Hash())
+		assert.False(t, ok)
+
+		_, err := rawdb.ReadReceipt(b.chaindb, tx.Hash())
+		assert.Error(t, err)
+	}
+
+	number, ok := rawdb.ReadTxLookUp(b.chaindb, b1.Transactions[0].Hash())
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), number)
+
+	// the new branch was executed, its receipts are written
+	receipts, err := b.GetReceiptsByHash(b1.Hash())
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+
+	// the bloom index only covers the new branch
+	hash, _, ok = rawdb.ReadBloomIndex(b.chaindb, 1)
+	assert.True(t, ok)
+	assert.Equal(t, b1.Hash(), hash)
+
+	_, _, ok = rawdb.ReadBloomIndex(b.chaindb, 2)
+	assert.False(t, ok)
+
+	// the old branch is kept as a side chain
+	td, ok := b.GetTD(a2.Hash())
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), td.Uint64())
+}
+
+func TestBlockchain_ReorgTooDeep(t *testing.T) {
+	c := newTestChain(t)
+	b := c.b
+
+	// the state of the common ancestor A1 is gone
+	a1 := &types.Header{
+		Number:     1,
+		ParentHash: c.genesis.Hash,
+		Difficulty: 1,
+		StateRoot:  types.StringToHash("0xdead"),
+		TxRoot:     types.EmptyRootHash,
+		Hash:       types.StringToHash("0xa1"),
+	}
+	assert.NoError(t, rawdb.WriteHeader(b.chaindb, a1))
+	assert.NoError(t, rawdb.WriteTD(b.chaindb, a1.Hash, 2))
+
+	a2 := appendHeaders(t, b, a1, 1, 0xa)[0]
+	b.setCurHeader(a2, 3)
+
+	// the side chain A1 - B2 is heavier
+	b2 := &types.Header{
+		Number:     2,
+		ParentHash: a1.Hash,
+		Difficulty: 5,
+		StateRoot:  types.EmptyRootHash,
+		TxRoot:     types.EmptyRootHash,
+		Hash:       types.StringToHash("0xb2"),
+	}
+
+	err := b.writeSideBlock(&types.Block{Header: b2}, 7)
+	assert.ErrorIs(t, err, ErrReorgTooDeep)
+	assert.ErrorIs(t, err, state.ErrStateNotAvailable)
+
+	// the chain is left as it was, the side block is kept
+	assert.Equal(t, a2.Hash, b.Header().Hash)
+
+	_, ok := b.GetTD(b2.Hash)
+	assert.True(t, ok)
+}
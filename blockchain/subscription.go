@@ -1,6 +1,7 @@
 package blockchain
 
 import (
+	"errors"
 	"math/big"
 	"sync"
 
@@ -9,11 +10,22 @@ import (
 
 type void struct{}
 
+// maxSubscriptionEvents is how many head and fork events a subscription
+// queues for its consumer before it is closed with ErrSubscriptionOverflow
+const maxSubscriptionEvents = 4096
+
+// ErrSubscriptionOverflow closes a subscription whose consumer fell too far
+// behind, the events since the last one it got are lost
+var ErrSubscriptionOverflow = errors.New("subscription event queue overflow")
+
 // Subscription is the blockchain subscription interface
 type Subscription interface {
 	GetEventCh() chan *Event
 	GetEvent() *Event
 	Close()
+	// Err returns why the subscription was closed by the blockchain, it is
+	// nil while the events are delivered or after a Close
+	Err() error
 }
 
 // FOR TESTING PURPOSES //
@@ -43,12 +55,78 @@ func (m *MockSubscription) GetEvent() *Event {
 func (m *MockSubscription) Close() {
 }
 
+func (m *MockSubscription) Err() error {
+	return nil
+}
+
 /////////////////////////
 
 // subscription is the Blockchain event subscription object
 type subscription struct {
-	updateCh chan *Event // Channel for update information
-	closeCh  chan void   // Channel for close signals
+	lock   sync.Mutex
+	events []*Event // Events not yet taken by the consumer
+	err    error    // Why the subscription was closed by the blockchain
+
+	updateCh  chan void // Channel for update information
+	closeCh   chan void // Channel for close signals
+	closeOnce sync.Once
+}
+
+func newSubscription() *subscription {
+	return &subscription{
+		updateCh: make(chan void, 1),
+		closeCh:  make(chan void),
+	}
+}
+
+// enqueue queues the event for the consumer. Reorg events are always
+// queued, so a busy consumer still gets the removed headers. Any other
+// event overflowing the queue closes the subscription with
+// ErrSubscriptionOverflow.
+func (s *subscription) enqueue(event *Event) {
+	s.lock.Lock()
+
+	if s.isClosed() {
+		s.lock.Unlock()
+
+		return
+	}
+
+	if len(s.events) >= maxSubscriptionEvents && event.Type != EventReorg {
+		s.events = nil
+		s.err = ErrSubscriptionOverflow
+		s.lock.Unlock()
+
+		s.Close()
+
+		return
+	}
+
+	s.events = append(s.events, event)
+	s.lock.Unlock()
+
+	select {
+	case s.updateCh <- void{}:
+	default:
+	}
+}
+
+func (s *subscription) isClosed() bool {
+	select {
+	case <-s.closeCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// Err returns ErrSubscriptionOverflow if the subscription was closed since
+// its consumer fell behind
+func (s *subscription) Err() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.err
 }
 
 // GetEventCh creates a new event channel, and returns it
@@ -68,13 +146,28 @@ func (s *subscription) GetEventCh() chan *Event {
 	return eventCh
 }
 
-// GetEvent returns the event from the subscription (BLOCKING)
+// GetEvent returns the event from the subscription (BLOCKING), it returns
+// nil once the subscription is closed
 func (s *subscription) GetEvent() *Event {
 	for {
+		if s.isClosed() {
+			return nil
+		}
+
+		s.lock.Lock()
+		if len(s.events) > 0 {
+			ev := s.events[0]
+			s.events[0] = nil
+			s.events = s.events[1:]
+			s.lock.Unlock()
+
+			return ev
+		}
+		s.lock.Unlock()
+
 		// Wait for an update
 		select {
-		case ev := <-s.updateCh:
-			return ev
+		case <-s.updateCh:
 		case <-s.closeCh:
 			return nil
 		}
@@ -83,7 +176,9 @@ func (s *subscription) GetEvent() *Event {
 
 // Close closes the subscription
 func (s *subscription) Close() {
-	close(s.closeCh)
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+	})
 }
 
 type EventType int
@@ -148,43 +243,45 @@ func (e *Event) AddOldHeader(oldHeader *types.Header) {
 }
 
 // eventStream is the structure that contains the event list,
-// as well as the update channel which it uses to notify of updates
+// as well as the subscriptions it notifies of updates
 type eventStream struct {
 	lock sync.Mutex
 
-	// channel to notify updates
-	updateCh []chan *Event
+	// subscriptions to notify
+	subs []*subscription
 }
 
 // subscribe Creates a new blockchain event subscription
 func (e *eventStream) subscribe() *subscription {
-	return &subscription{
-		updateCh: e.newUpdateCh(),
-		closeCh:  make(chan void),
-	}
-}
-
-// newUpdateCh returns the event update channel
-func (e *eventStream) newUpdateCh() chan *Event {
 	e.lock.Lock()
 	defer e.lock.Unlock()
 
-	ch := make(chan *Event, 1)
-	e.updateCh = append(e.updateCh, ch)
+	sub := newSubscription()
+	e.subs = append(e.subs, sub)
 
-	return ch
+	return sub
 }
 
-// push adds a new Event, and notifies listeners
+// push adds a new Event, and notifies listeners. It never blocks, the
+// events are queued per subscription.
 func (e *eventStream) push(event *Event) {
 	e.lock.Lock()
 	defer e.lock.Unlock()
 
-	// Notify the listeners
-	for _, update := range e.updateCh {
-		select {
-		case update <- event:
-		default:
+	// Notify the listeners, forgetting the closed ones
+	subs := e.subs[:0]
+
+	for _, sub := range e.subs {
+		sub.enqueue(event)
+
+		if !sub.isClosed() {
+			subs = append(subs, sub)
 		}
 	}
+
+	for i := len(subs); i < len(e.subs); i++ {
+		e.subs[i] = nil
+	}
+
+	e.subs = subs
 }
@@ -51,3 +51,107 @@ func TestSubscription(t *testing.T) {
 
 	assert.Equal(t, event.NewChain[0].Number, caughtEventNum)
 }
+
+func TestSubscription_Burst(t *testing.T) {
+	t.Parallel()
+
+	var (
+		e     = &eventStream{}
+		sub   = e.subscribe()
+		count = 1000
+	)
+
+	defer sub.Close()
+
+	// the consumer starts after the whole burst, like one busy writing
+	// the previous events out
+	for i := 0; i < count; i++ {
+		e.push(&Event{
+			Type:     EventHead,
+			NewChain: []*types.Header{{Number: uint64(i)}},
+		})
+	}
+
+	for i := 0; i < count; i++ {
+		ev := sub.GetEvent()
+		if !assert.NotNil(t, ev) {
+			return
+		}
+
+		assert.Equal(t, uint64(i), ev.Header().Number)
+	}
+
+	assert.NoError(t, sub.Err())
+}
+
+func TestSubscription_Overflow(t *testing.T) {
+	t.Parallel()
+
+	var (
+		e    = &eventStream{}
+		sub  = e.subscribe()
+		fast = e.subscribe()
+	)
+
+	defer fast.Close()
+
+	for i := 0; i <= maxSubscriptionEvents; i++ {
+		e.push(&Event{
+			Type:     EventHead,
+			NewChain: []*types.Header{{Number: uint64(i)}},
+		})
+
+		// the other subscriber keeps up
+		assert.Equal(t, uint64(i), fast.GetEvent().Header().Number)
+	}
+
+	// the consumer learns about the gap instead of missing blocks
+	assert.Nil(t, sub.GetEvent())
+	assert.ErrorIs(t, sub.Err(), ErrSubscriptionOverflow)
+	assert.NoError(t, fast.Err())
+
+	// the closed subscription is forgotten by the stream
+	assert.Len(t, e.subs, 1)
+
+	// a subscription closed by its consumer has no error
+	fast.Close()
+	assert.Nil(t, fast.GetEvent())
+	assert.NoError(t, fast.Err())
+}
+
+func TestSubscription_ReorgNotDropped(t *testing.T) {
+	t.Parallel()
+
+	var (
+		e   = &eventStream{}
+		sub = e.subscribe()
+	)
+
+	defer sub.Close()
+
+	for i := 0; i < maxSubscriptionEvents; i++ {
+		e.push(&Event{
+			Type:     EventHead,
+			NewChain: []*types.Header{{Number: uint64(i)}},
+		})
+	}
+
+	// the queue is full, a reorg is still queued
+	e.push(&Event{
+		Type:     EventReorg,
+		OldChain: []*types.Header{{Number: 1}},
+		NewChain: []*types.Header{{Number: 1}},
+	})
+
+	for i := 0; i < maxSubscriptionEvents; i++ {
+		assert.Equal(t, EventHead, sub.GetEvent().Type)
+	}
+
+	ev := sub.GetEvent()
+	if assert.NotNil(t, ev) {
+		assert.Equal(t, EventReorg, ev.Type)
+		assert.Len(t, ev.OldChain, 1)
+	}
+
+	assert.NoError(t, sub.Err())
+}
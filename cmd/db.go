@@ -0,0 +1,294 @@
+/*
+Copyright © 2022 mobus <sunsc0220@gmail.com>
+
+This program is free software; you can redistribute it and/or
+modify it under the terms of the GNU General Public License
+as published by the Free Software Foundation; either version 2
+of the License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/sunvim/dogesyncer/dbtool"
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/engine"
+	"github.com/sunvim/dogesyncer/rawdb"
+)
+
+var dbParams struct {
+	dataDir   string
+	dbEngine  string
+	from      uint64
+	to        uint64
+	toDataDir string
+	toEngine  string
+}
+
+// dbCmd represents the db command
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "inspect and repair the database",
+	Long: `db works on the database of the data directory. The inspection commands
+open it read only, rewind needs the node to be stopped.`,
+}
+
+var dbStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "show the entries and the size of every dbi",
+	Args:  cobra.NoArgs,
+	RunE:  runDBStats,
+}
+
+var dbGetCmd = &cobra.Command{
+	Use:   "get <dbi> <key>",
+	Short: "read and decode a value",
+	Long: `get reads the key of the dbi and decodes its value. The dbis keyed by block
+number (nuha, blom, snap) take a decimal number, assi takes the key name and
+the other dbis take a hex key.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDBGet,
+}
+
+var dbVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "check the consistency of the canonical blocks",
+	Long: `verify checks the canonical hash, the header, the body, the transaction
+lookups and the receipts of the canonical blocks in the range.`,
+	Args: cobra.NoArgs,
+	RunE: runDBVerify,
+}
+
+var dbRewindCmd = &cobra.Command{
+	Use:   "rewind <number>",
+	Short: "set the head back to a block",
+	Long: `rewind makes the canonical block the head and removes the canonical data of
+the blocks above it, which are synced and executed again on the next start.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDBRewind,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "copy the database into another engine",
+	Long: `migrate copies all the dbis of the database into the database of another
+engine in the target data directory. The node has to be stopped, afterwards it
+can be started on the target data directory with --db.engine set to the target
+engine.`,
+	Args: cobra.NoArgs,
+	RunE: runDBMigrate,
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbStatsCmd, dbGetCmd, dbVerifyCmd, dbRewindCmd, dbMigrateCmd)
+
+	dbCmd.PersistentFlags().StringVar(&dbParams.dataDir, "data-dir", "", "the data directory of the node")
+	dbCmd.PersistentFlags().StringVar(&dbParams.dbEngine, "db.engine", engine.MDBX, "the database engine of the data directory")
+	_ = dbCmd.MarkPersistentFlagRequired("data-dir")
+
+	dbVerifyCmd.Flags().Uint64Var(&dbParams.from, "from", 0, "the first block to verify")
+	dbVerifyCmd.Flags().Uint64Var(&dbParams.to, "to", 0, "the last block to verify, 0 means the head")
+
+	dbMigrateCmd.Flags().StringVar(&dbParams.toDataDir, "to-data-dir", "", "the data directory to copy the database into")
+	dbMigrateCmd.Flags().StringVar(&dbParams.toEngine, "to-engine", "", "the database engine to copy the database into")
+	_ = dbMigrateCmd.MarkFlagRequired("to-data-dir")
+	_ = dbMigrateCmd.MarkFlagRequired("to-engine")
+}
+
+func dbPath() string {
+	return filepath.Join(dbParams.dataDir, "blockchain")
+}
+
+func openReadOnlyDB() (ethdb.Database, error) {
+	db, err := engine.OpenReadOnly(dbParams.dbEngine, dbPath(), hclog.NewNullLogger())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %w", err)
+	}
+
+	return db, nil
+}
+
+func runDBStats(cmd *cobra.Command, _ []string) error {
+	db, err := openReadOnlyDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stats, err := dbtool.Stats(db)
+	if err != nil {
+		return err
+	}
+
+	var entries, size uint64
+
+	fmt.Printf("%-6s %14s %16s\n", "dbi", "entries", "size")
+
+	for _, stat := range stats {
+		fmt.Printf("%-6s %14d %16d\n", stat.DBI, stat.Entries, stat.Size)
+
+		entries += stat.Entries
+		size += stat.Size
+	}
+
+	fmt.Printf("%-6s %14d %16d\n", "total", entries, size)
+
+	return nil
+}
+
+func runDBGet(cmd *cobra.Command, args []string) error {
+	key, err := dbtool.ParseKey(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	db, err := openReadOnlyDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	value, err := dbtool.Get(db, args[0], key)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+
+	return nil
+}
+
+func runDBVerify(cmd *cobra.Command, _ []string) error {
+	db, err := openReadOnlyDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	to := dbParams.to
+	if to == 0 {
+		head, ok := rawdb.ReadHeadNumber(db)
+		if !ok {
+			return fmt.Errorf("head not found")
+		}
+
+		to = head
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	issues := 0
+
+	checked, err := dbtool.Verify(ctx, db, dbParams.from, to, func(issue *dbtool.Issue) {
+		issues++
+
+		fmt.Println(issue)
+	})
+
+	fmt.Printf("checked %d blocks from %d, %d issues\n", checked, dbParams.from, issues)
+
+	if err != nil {
+		return err
+	}
+
+	if issues > 0 {
+		return fmt.Errorf("found %d issues", issues)
+	}
+
+	return nil
+}
+
+func runDBRewind(cmd *cobra.Command, args []string) error {
+	number, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block number %q: %w", args[0], err)
+	}
+
+	if _, err := os.Stat(dbPath()); err != nil {
+		return fmt.Errorf("failed to open the database: %w", err)
+	}
+
+	db, err := engine.Open(dbParams.dbEngine, dbPath(), hclog.NewNullLogger())
+	if err != nil {
+		return fmt.Errorf("failed to open the database: %w", err)
+	}
+	defer db.Close()
+
+	header, err := rawdb.Rewind(db, number)
+	if err != nil {
+		return fmt.Errorf("rewind failed: %w", err)
+	}
+
+	fmt.Printf("rewound the head to block %d %s\n", header.Number, header.Hash)
+
+	return nil
+}
+
+func runDBMigrate(cmd *cobra.Command, _ []string) error {
+	if err := engine.Validate(dbParams.toEngine); err != nil {
+		return err
+	}
+
+	if dbParams.toEngine == engine.Memory {
+		return fmt.Errorf("the %s engine keeps no data", engine.Memory)
+	}
+
+	toPath := filepath.Join(dbParams.toDataDir, "blockchain")
+	if toPath == dbPath() {
+		return fmt.Errorf("the target data directory is the source one")
+	}
+
+	src, err := openReadOnlyDB()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(toPath, 0755); err != nil {
+		return err
+	}
+
+	dst, err := engine.Open(dbParams.toEngine, toPath, hclog.NewNullLogger())
+	if err != nil {
+		return fmt.Errorf("failed to open the target database: %w", err)
+	}
+	defer dst.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err = dbtool.Migrate(ctx, src, dst, func(dbi string, keys uint64) {
+		fmt.Printf("copied %d keys of %s\n", keys, dbi)
+	})
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	fmt.Printf("migrated the database to %s in %s\n", dbParams.toEngine, toPath)
+
+	return nil
+}
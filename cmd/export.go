@@ -0,0 +1,152 @@
+/*
+Copyright © 2022 mobus <sunsc0220@gmail.com>
+
+This program is free software; you can redistribute it and/or
+modify it under the terms of the GNU General Public License
+as published by the Free Software Foundation; either version 2
+of the License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/sunvim/dogesyncer/archive"
+	"github.com/sunvim/dogesyncer/ethdb/engine"
+	"github.com/sunvim/dogesyncer/helper/common"
+	"github.com/sunvim/dogesyncer/pkg/server/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var exportParams struct {
+	from        uint64
+	to          uint64
+	out         string
+	compression string
+	grpcAddress string
+	dataDir     string
+	dbEngine    string
+}
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export blocks to an archive file",
+	Long: `export writes the canonical blocks as a RLP stream, which can be restored with
+"server --restore". The blocks are read from the GRPC endpoint of a running node,
+or straight from the data directory of a stopped one with --data-dir.`,
+	RunE: runExport,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().Uint64Var(&exportParams.from, "from", 0, "the first block to export")
+	exportCmd.Flags().Uint64Var(&exportParams.to, "to", 0, "the last block to export, 0 means the latest block")
+	exportCmd.Flags().StringVar(&exportParams.out, "out", "", "the archive file to write")
+	exportCmd.Flags().StringVar(
+		&exportParams.compression,
+		"compression",
+		string(archive.CompressionNone),
+		"the compression of the archive: none, gzip or zstd",
+	)
+	exportCmd.Flags().StringVar(
+		&exportParams.grpcAddress,
+		"grpc-address",
+		"127.0.0.1:9632",
+		"the GRPC interface of the running node",
+	)
+	exportCmd.Flags().StringVar(
+		&exportParams.dataDir,
+		"data-dir",
+		"",
+		"the data directory to read the blocks from, instead of the GRPC interface",
+	)
+	exportCmd.Flags().StringVar(&exportParams.dbEngine, "db.engine", engine.MDBX, "the database engine of the data directory")
+
+	_ = exportCmd.MarkFlagRequired("out")
+}
+
+func runExport(cmd *cobra.Command, _ []string) error {
+	compression, err := archive.ParseCompression(exportParams.compression)
+	if err != nil {
+		return err
+	}
+
+	if exportParams.to != 0 && exportParams.to < exportParams.from {
+		return errors.New("to must be greater than or equal to from")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	w, err := archive.NewWriter(exportParams.out, compression)
+	if err != nil {
+		return err
+	}
+
+	if exportParams.dataDir != "" {
+		err = exportFromDataDir(ctx, w)
+	} else {
+		err = exportFromGRPC(ctx, w)
+	}
+
+	manifest, closeErr := w.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+
+	fmt.Printf("exported blocks %d - %d to %s\n", manifest.From, manifest.To, exportParams.out)
+	fmt.Printf("size: %d bytes, sha256: %s\n", manifest.Size, manifest.SHA256)
+
+	return nil
+}
+
+func exportFromDataDir(ctx context.Context, w *archive.Writer) error {
+	db, err := engine.OpenReadOnly(
+		exportParams.dbEngine,
+		filepath.Join(exportParams.dataDir, "blockchain"),
+		hclog.NewNullLogger(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to open the database: %w", err)
+	}
+	defer db.Close()
+
+	return archive.ExportChain(ctx, archive.NewDBChain(db), exportParams.from, exportParams.to, w)
+}
+
+func exportFromGRPC(ctx context.Context, w *archive.Writer) error {
+	conn, err := grpc.DialContext(
+		ctx,
+		exportParams.grpcAddress,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(common.MaxGrpcMsgSize)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", exportParams.grpcAddress, err)
+	}
+	defer conn.Close()
+
+	return archive.ExportFromGRPC(ctx, proto.NewSystemClient(conn), exportParams.from, exportParams.to, w)
+}
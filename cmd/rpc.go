@@ -0,0 +1,150 @@
+/*
+Copyright © 2022 mobus <sunsc0220@gmail.com>
+
+This program is free software; you can redistribute it and/or
+modify it under the terms of the GNU General Public License
+as published by the Free Software Foundation; either version 2
+of the License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/sunvim/dogesyncer/chain"
+	"github.com/sunvim/dogesyncer/pkg/rpcnode"
+	"github.com/sunvim/dogesyncer/pkg/server"
+	"github.com/sunvim/dogesyncer/rpc"
+	"github.com/sunvim/utils/grace"
+)
+
+var rpcParams struct {
+	dataDir          string
+	dbEngine         string
+	genesisPath      string
+	addr             string
+	port             string
+	enableWS         bool
+	wsPort           string
+	batchLimit       uint64
+	blockRangeLimit  uint64
+	healthMaxHeadAge uint64
+	pollInterval     time.Duration
+	logLevel         string
+}
+
+// rpcCmd represents the rpc command
+var rpcCmd = &cobra.Command{
+	Use:   "rpc",
+	Short: "serve the JSON-RPC API from the database of a running node",
+	Long: `rpc opens the database of the data directory read only and serves the JSON-RPC
+API from it, while the node started with "server" keeps writing to it. New
+heads are picked up by polling the database. Several rpc processes can share a
+data directory. Only the mdbx engine can be read while another process writes
+to it.`,
+	Args: cobra.NoArgs,
+	RunE: runRPC,
+}
+
+func init() {
+	rootCmd.AddCommand(rpcCmd)
+
+	defaultConfig := server.DefaultConfig()
+
+	rpcCmd.Flags().StringVar(&rpcParams.dataDir, "data-dir", "", "the data directory of the node")
+	rpcCmd.Flags().StringVar(&rpcParams.dbEngine, "db.engine", defaultConfig.DBEngine, "the database engine of the data directory")
+	rpcCmd.Flags().StringVar(&rpcParams.genesisPath, "chain", defaultConfig.GenesisPath, "the genesis file of the chain")
+	rpcCmd.Flags().StringVar(&rpcParams.addr, server.JsonrpcAddress, defaultConfig.HttpAddr, "rpc address")
+	rpcCmd.Flags().StringVar(&rpcParams.port, server.JsonrpcPort, defaultConfig.HttpPort, "rpc port")
+	rpcCmd.Flags().BoolVar(&rpcParams.enableWS, "enable-ws", defaultConfig.EnableWS, "enable the websocket json-rpc endpoint")
+	rpcCmd.Flags().StringVar(&rpcParams.wsPort, server.JsonrpcWSPort, defaultConfig.WSPort, "websocket rpc port")
+	rpcCmd.Flags().Uint64Var(
+		&rpcParams.batchLimit,
+		"json-rpc-batch-request-limit",
+		defaultConfig.JSONRPCBatchRequestLimit,
+		"max length to be considered when handling json-rpc batch requests, 0 means no limit",
+	)
+	rpcCmd.Flags().Uint64Var(
+		&rpcParams.blockRangeLimit,
+		"json-rpc-block-range-limit",
+		defaultConfig.JSONRPCBlockRangeLimit,
+		"max block range to be considered when executing json-rpc requests "+
+			"that consider fromBlock/toBlock values (e.g. eth_getLogs), 0 means no limit",
+	)
+	rpcCmd.Flags().Uint64Var(
+		&rpcParams.healthMaxHeadAge,
+		"health-max-head-age",
+		defaultConfig.HealthMaxHeadAge,
+		"max age in seconds of the head block while /ready reports ready, 0 means no limit",
+	)
+	rpcCmd.Flags().DurationVar(
+		&rpcParams.pollInterval,
+		"poll-interval",
+		rpcnode.DefaultPollInterval,
+		"how often the head written by the node is read",
+	)
+	rpcCmd.Flags().StringVar(&rpcParams.logLevel, server.LogLevelFlag, defaultConfig.LogLevel, "the log level for console output")
+
+	_ = rpcCmd.MarkFlagRequired("data-dir")
+}
+
+func runRPC(cmd *cobra.Command, _ []string) error {
+	if rpcParams.pollInterval <= 0 {
+		return fmt.Errorf("the poll interval must be positive")
+	}
+
+	genesis, err := chain.Import(rpcParams.genesisPath)
+	if err != nil {
+		return err
+	}
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "dogesyncer",
+		Level: hclog.LevelFromString(rpcParams.logLevel),
+	})
+
+	node, err := rpcnode.NewNode(logger, &rpcnode.Config{
+		Chain:        genesis,
+		DataDir:      rpcParams.dataDir,
+		DBEngine:     rpcParams.dbEngine,
+		PollInterval: rpcParams.pollInterval,
+		RPC: &rpc.Config{
+			Addr:             rpcParams.addr,
+			Port:             rpcParams.port,
+			BatchLengthLimit: rpcParams.batchLimit,
+			BlockRangeLimit:  rpcParams.blockRangeLimit,
+			EnableWS:         rpcParams.enableWS,
+			WSPort:           rpcParams.wsPort,
+
+			HealthMaxHeadAge: time.Duration(rpcParams.healthMaxHeadAge) * time.Second,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, svc := grace.New(context.Background())
+
+	if err := node.Start(ctx); err != nil {
+		node.Close()
+
+		return err
+	}
+
+	svc.Register(node.Close)
+	svc.Wait()
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package dbtool
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/helper"
+	"github.com/sunvim/dogesyncer/helper/hex"
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// the dbis keyed by block number, the others are keyed by hash
+var numberKeyed = map[string]bool{
+	ethdb.NumHashDBI: true,
+	ethdb.BloomDBI:   true,
+	ethdb.SnapDBI:    true,
+}
+
+// bloomIndex is the decoded value of ethdb.BloomDBI
+type bloomIndex struct {
+	Hash  types.Hash
+	Bloom types.Bloom
+}
+
+// ParseKey turns the key given on the command line into the stored key. The
+// dbis keyed by block number take a decimal number, ethdb.AssistDBI takes
+// the key name and the other ones a hex key.
+func ParseKey(dbi, key string) ([]byte, error) {
+	if !isDBI(dbi) {
+		return nil, fmt.Errorf("unknown dbi %q", dbi)
+	}
+
+	switch {
+	case numberKeyed[dbi]:
+		number, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block number %q: %w", key, err)
+		}
+
+		return helper.EncodeVarint(number), nil
+
+	case dbi == ethdb.AssistDBI:
+		return []byte(key), nil
+
+	default:
+		k, err := hex.DecodeHex(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex key %q: %w", key, err)
+		}
+
+		return k, nil
+	}
+}
+
+// Get reads the value of the key and decodes it with the codec of the dbi,
+// the values without a codec are returned as hex
+func Get(db ethdb.Database, dbi string, key []byte) (interface{}, error) {
+	value, ok, err := db.Get(dbi, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ethdb.ErrNotFound
+	}
+
+	hash := types.BytesToHash(key)
+
+	switch dbi {
+	case ethdb.HeadDBI:
+		return rawdb.ReadHeader(db, hash)
+
+	case ethdb.BodyDBI:
+		return rawdb.ReadBody(db, hash)
+
+	case ethdb.TxesDBI:
+		return rawdb.ReadTransaction(db, hash)
+
+	case ethdb.ReceiptsDBI:
+		return rawdb.ReadReceipt(db, hash)
+
+	case ethdb.TxLookUpDBI, ethdb.TODBI:
+		number, _ := helper.DecodeVarint(value)
+
+		return number, nil
+
+	case ethdb.NumHashDBI:
+		return types.BytesToHash(value), nil
+
+	case ethdb.BloomDBI:
+		number, _ := helper.DecodeVarint(key)
+
+		hash, bloom, ok := rawdb.ReadBloomIndex(db, number)
+		if !ok {
+			return nil, fmt.Errorf("invalid bloom index of block %d", number)
+		}
+
+		return &bloomIndex{Hash: hash, Bloom: bloom}, nil
+
+	case ethdb.SnapDBI:
+		snap := &types.Snapshot{}
+		if err := snap.Unmarshal(value); err != nil {
+			return nil, err
+		}
+
+		return snap, nil
+
+	case ethdb.AssistDBI:
+		return decodeAssist(string(key), value), nil
+	}
+
+	return hex.EncodeToHex(value), nil
+}
+
+// decodeAssist decodes the records of ethdb.AssistDBI, see rawdb/schema.go
+func decodeAssist(key string, value []byte) interface{} {
+	switch key {
+	case "latest_hash":
+		return types.BytesToHash(value)
+
+	case "latest_number":
+		number, _ := helper.DecodeVarint(value)
+
+		return number
+
+	case "gc_mode":
+		return string(value)
+	}
+
+	return hex.EncodeToHex(value)
+}
+
+func isDBI(dbi string) bool {
+	for _, d := range ethdb.DBIs {
+		if d == dbi {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,65 @@
+package dbtool
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func TestGet(t *testing.T) {
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer db.Close()
+
+	headers := writeChain(t, db, 2)
+
+	get := func(dbi, key string) interface{} {
+		k, err := ParseKey(dbi, key)
+		assert.NoError(t, err)
+
+		value, err := Get(db, dbi, k)
+		assert.NoError(t, err)
+
+		return value
+	}
+
+	header, ok := get(ethdb.HeadDBI, headers[1].Hash.String()).(*types.Header)
+	assert.True(t, ok)
+	assert.Equal(t, headers[1].ParentHash, header.ParentHash)
+
+	assert.Equal(t, headers[2].Hash, get(ethdb.NumHashDBI, "2"))
+	assert.Equal(t, uint64(3), get(ethdb.TODBI, headers[2].Hash.String()))
+	assert.Equal(t, headers[2].Hash, get(ethdb.AssistDBI, "latest_hash"))
+	assert.Equal(t, uint64(2), get(ethdb.AssistDBI, "latest_number"))
+
+	body, ok := get(ethdb.BodyDBI, headers[1].Hash.String()).([]types.Hash)
+	assert.True(t, ok)
+	assert.Len(t, body, 1)
+
+	receipt, ok := get(ethdb.ReceiptsDBI, body[0].String()).(*types.Receipt)
+	assert.True(t, ok)
+	assert.Equal(t, body[0], receipt.TxHash)
+
+	bloom, ok := get(ethdb.BloomDBI, "1").(*bloomIndex)
+	assert.True(t, ok)
+	assert.Equal(t, headers[1].Hash, bloom.Hash)
+
+	// missing keys and bad input
+	_, err := Get(db, ethdb.HeadDBI, types.StringToHash("0xff").Bytes())
+	assert.ErrorIs(t, err, ethdb.ErrNotFound)
+
+	_, err = ParseKey("none", "0x00")
+	assert.Error(t, err)
+
+	_, err = ParseKey(ethdb.NumHashDBI, "0x01")
+	assert.Error(t, err)
+
+	// every dbi is reported
+	stats, err := Stats(db)
+	assert.NoError(t, err)
+	assert.Len(t, stats, len(ethdb.DBIs))
+}
@@ -0,0 +1,64 @@
+package dbtool
+
+import (
+	"context"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+)
+
+const (
+	// migrateBatchSize is the number of keys copied per batch
+	migrateBatchSize = 10000
+)
+
+// Migrate copies all the dbis of src into dst, the keys of dst which are not
+// in src are kept. The number of keys copied per dbi is reported once the
+// dbi is done.
+func Migrate(ctx context.Context, src, dst ethdb.Database, report func(dbi string, keys uint64)) error {
+	for _, dbi := range ethdb.DBIs {
+		keys, err := migrateDBI(ctx, src, dst, dbi)
+		if err != nil {
+			return err
+		}
+
+		report(dbi, keys)
+	}
+
+	return dst.Sync()
+}
+
+func migrateDBI(ctx context.Context, src, dst ethdb.Database, dbi string) (uint64, error) {
+	it := src.NewIterator(dbi, nil, nil)
+	defer it.Release()
+
+	var (
+		batch = dst.Batch()
+		keys  uint64
+	)
+
+	defer batch.Discard()
+
+	for it.Next() {
+		if err := batch.Set(dbi, it.Key(), it.Value()); err != nil {
+			return keys, err
+		}
+
+		keys++
+
+		if keys%migrateBatchSize == 0 {
+			if err := ctx.Err(); err != nil {
+				return keys, err
+			}
+
+			if err := batch.Write(); err != nil {
+				return keys, err
+			}
+		}
+	}
+
+	if err := it.Error(); err != nil {
+		return keys, err
+	}
+
+	return keys, batch.Write()
+}
@@ -0,0 +1,53 @@
+package dbtool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/leveldb"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+)
+
+func TestMigrate(t *testing.T) {
+	src := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer src.Close()
+
+	for i := 0; i < migrateBatchSize+1; i++ {
+		assert.NoError(t, src.Set(ethdb.TrieDBI, []byte{byte(i >> 8), byte(i)}, []byte{byte(i)}))
+	}
+
+	assert.NoError(t, src.Set(ethdb.AssistDBI, []byte("key"), []byte("value")))
+
+	dst, err := leveldb.NewLevelDB(t.TempDir(), hclog.NewNullLogger())
+	assert.NoError(t, err)
+
+	defer dst.Close()
+
+	copied := map[string]uint64{}
+
+	assert.NoError(t, Migrate(context.Background(), src, dst, func(dbi string, keys uint64) {
+		copied[dbi] = keys
+	}))
+
+	assert.Len(t, copied, len(ethdb.DBIs))
+	assert.Equal(t, uint64(migrateBatchSize+1), copied[ethdb.TrieDBI])
+	assert.Equal(t, uint64(1), copied[ethdb.AssistDBI])
+	assert.Equal(t, uint64(0), copied[ethdb.BodyDBI])
+
+	for _, dbi := range []string{ethdb.TrieDBI, ethdb.AssistDBI} {
+		it := src.NewIterator(dbi, nil, nil)
+
+		for it.Next() {
+			got, ok, err := dst.Get(dbi, it.Key())
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, it.Value(), got)
+		}
+
+		assert.NoError(t, it.Error())
+		it.Release()
+	}
+}
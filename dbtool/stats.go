@@ -0,0 +1,36 @@
+package dbtool
+
+import (
+	"errors"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+)
+
+var errNoStats = errors.New("the database does not report dbi stats")
+
+// DBIStat is the size of a dbi
+type DBIStat struct {
+	DBI string
+	ethdb.Stat
+}
+
+// Stats returns the number of entries and the size of every dbi
+func Stats(db ethdb.Database) ([]*DBIStat, error) {
+	stater, ok := db.(ethdb.Stater)
+	if !ok {
+		return nil, errNoStats
+	}
+
+	stats := make([]*DBIStat, 0, len(ethdb.DBIs))
+
+	for _, dbi := range ethdb.DBIs {
+		stat, err := stater.Stat(dbi)
+		if err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, &DBIStat{DBI: dbi, Stat: *stat})
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,154 @@
+package dbtool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/types"
+	"github.com/sunvim/dogesyncer/types/buildroot"
+)
+
+// Issue is an inconsistency of a canonical block
+type Issue struct {
+	Number  uint64
+	Problem string
+}
+
+func (i *Issue) String() string {
+	return fmt.Sprintf("block %d: %s", i.Number, i.Problem)
+}
+
+// Verify checks the canonical blocks from to to, both included. Every
+// block needs a header matching its canonical hash and linked to its
+// parent, a total difficulty, its transactions with their lookups and
+// receipts matching the roots of the header, and a bloom index. The
+// issues are reported as they are found, it returns the number of blocks
+// checked.
+func Verify(ctx context.Context, db ethdb.Database, from, to uint64, report func(*Issue)) (uint64, error) {
+	if to < from {
+		return 0, fmt.Errorf("to %d is below from %d", to, from)
+	}
+
+	var checked uint64
+
+	for number := from; number <= to; number++ {
+		if err := ctx.Err(); err != nil {
+			return checked, err
+		}
+
+		v := &blockVerifier{db: db, number: number, report: report}
+		v.verify()
+
+		checked++
+	}
+
+	return checked, nil
+}
+
+type blockVerifier struct {
+	db     ethdb.Database
+	number uint64
+	report func(*Issue)
+}
+
+func (v *blockVerifier) fail(format string, args ...interface{}) {
+	v.report(&Issue{Number: v.number, Problem: fmt.Sprintf(format, args...)})
+}
+
+func (v *blockVerifier) verify() {
+	hash, ok := rawdb.ReadCanonicalHash(v.db, v.number)
+	if !ok {
+		v.fail("missing canonical hash")
+
+		return
+	}
+
+	header, err := rawdb.ReadHeader(v.db, hash)
+	if err != nil {
+		v.fail("header %s: %v", hash, err)
+
+		return
+	}
+
+	if header.Number != v.number {
+		v.fail("header %s has number %d", hash, header.Number)
+	}
+
+	if computed := types.HeaderHash(header); computed != hash {
+		v.fail("header hash mismatch: stored %s, computed %s", hash, computed)
+	}
+
+	if v.number > 0 {
+		if parent, ok := rawdb.ReadCanonicalHash(v.db, v.number-1); ok && parent != header.ParentHash {
+			v.fail("parent hash %s is not the canonical hash %s", header.ParentHash, parent)
+		}
+	}
+
+	if _, ok := rawdb.ReadTD(v.db, hash); !ok {
+		v.fail("missing total difficulty")
+	}
+
+	// the genesis is written without a bloom index
+	if v.number > 0 {
+		if bloomHash, _, ok := rawdb.ReadBloomIndex(v.db, v.number); !ok {
+			v.fail("missing bloom index")
+		} else if bloomHash != hash {
+			v.fail("bloom index of block %s", bloomHash)
+		}
+	}
+
+	v.verifyBody(header)
+}
+
+// verifyBody checks the transactions of the block along with their lookups
+// and receipts
+func (v *blockVerifier) verifyBody(header *types.Header) {
+	txhashes, err := rawdb.ReadBody(v.db, header.Hash)
+	if err != nil {
+		// empty blocks have no body stored
+		if header.TxRoot != types.EmptyRootHash {
+			v.fail("missing body")
+		}
+
+		return
+	}
+
+	var (
+		txs      = make([]*types.Transaction, 0, len(txhashes))
+		receipts = make([]*types.Receipt, 0, len(txhashes))
+	)
+
+	for _, txhash := range txhashes {
+		if tx, err := rawdb.ReadTransaction(v.db, txhash); err != nil {
+			v.fail("transaction %s: %v", txhash, err)
+		} else {
+			txs = append(txs, tx)
+		}
+
+		if number, ok := rawdb.ReadTxLookUp(v.db, txhash); !ok {
+			v.fail("missing lookup of transaction %s", txhash)
+		} else if number != v.number {
+			v.fail("lookup of transaction %s points to block %d", txhash, number)
+		}
+
+		if receipt, err := rawdb.ReadReceipt(v.db, txhash); err != nil {
+			v.fail("receipt of transaction %s: %v", txhash, err)
+		} else {
+			receipts = append(receipts, receipt)
+		}
+	}
+
+	if len(txs) == len(txhashes) {
+		if root := buildroot.CalculateTransactionsRoot(txs); root != header.TxRoot {
+			v.fail("transactions root mismatch: header %s, computed %s", header.TxRoot, root)
+		}
+	}
+
+	if len(receipts) == len(txhashes) {
+		if root := buildroot.CalculateReceiptsRoot(receipts); root != header.ReceiptsRoot {
+			v.fail("receipts root mismatch: header %s, computed %s", header.ReceiptsRoot, root)
+		}
+	}
+}
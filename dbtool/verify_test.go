@@ -0,0 +1,129 @@
+package dbtool
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/helper"
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/types"
+	"github.com/sunvim/dogesyncer/types/buildroot"
+)
+
+// writeChain writes a consistent chain of the genesis and n blocks with a
+// transaction each, it returns the headers
+func writeChain(t *testing.T, db ethdb.Database, n int) []*types.Header {
+	t.Helper()
+
+	to := types.StringToAddress("0x1")
+	headers := make([]*types.Header, 0, n+1)
+
+	for i := 0; i <= n; i++ {
+		header := &types.Header{
+			Number:       uint64(i),
+			Difficulty:   1,
+			TxRoot:       types.EmptyRootHash,
+			ReceiptsRoot: types.EmptyRootHash,
+			StateRoot:    types.EmptyRootHash,
+		}
+
+		var (
+			txs      []*types.Transaction
+			receipts types.Receipts
+		)
+
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash
+
+			txs = []*types.Transaction{{
+				Nonce: uint64(i), GasPrice: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(1),
+				V: big.NewInt(27), R: big.NewInt(1), S: big.NewInt(1),
+			}}
+
+			receipt := &types.Receipt{TxHash: txs[0].Hash(), CumulativeGasUsed: 21000, GasUsed: 21000}
+			receipt.SetStatus(types.ReceiptSuccess)
+			receipts = types.Receipts{receipt}
+
+			header.TxRoot = buildroot.CalculateTransactionsRoot(txs)
+			header.ReceiptsRoot = buildroot.CalculateReceiptsRoot(receipts)
+		}
+
+		types.PutIbftExtraValidators(header, nil)
+		header.ComputeHash()
+
+		assert.NoError(t, rawdb.WriteHeader(db, header))
+		assert.NoError(t, rawdb.WriteTD(db, header.Hash, uint64(i+1)))
+		assert.NoError(t, rawdb.WriteCanonicalHash(db, header.Number, header.Hash))
+		assert.NoError(t, rawdb.WriteHeadHash(db, header.Hash))
+		assert.NoError(t, rawdb.WriteHeadNumber(db, header.Number))
+
+		if i > 0 {
+			assert.NoError(t, rawdb.WriteTransactions(db, txs))
+			assert.NoError(t, rawdb.WriteBody(db, header.Hash, txs))
+			assert.NoError(t, rawdb.WriteTxLookUp(db, header.Number, txs))
+			assert.NoError(t, rawdb.WrteReceipts(db, receipts))
+			assert.NoError(t, rawdb.WriteBloomIndex(db, header.Number, header.Hash, header.LogsBloom))
+		}
+
+		headers = append(headers, header)
+	}
+
+	return headers
+}
+
+func verifyIssues(t *testing.T, db ethdb.Database, from, to uint64) []string {
+	t.Helper()
+
+	var issues []string
+
+	checked, err := Verify(context.Background(), db, from, to, func(issue *Issue) {
+		issues = append(issues, issue.String())
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, to-from+1, checked)
+
+	return issues
+}
+
+func TestVerify(t *testing.T) {
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer db.Close()
+
+	headers := writeChain(t, db, 5)
+
+	assert.Empty(t, verifyIssues(t, db, 0, 5))
+
+	// break a block in every way the verification looks at
+	body, err := rawdb.ReadBody(db, headers[2].Hash)
+	assert.NoError(t, err)
+
+	body3, err := rawdb.ReadBody(db, headers[3].Hash)
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.Remove(ethdb.TxLookUpDBI, body[0].Bytes()))
+	assert.NoError(t, db.Remove(ethdb.ReceiptsDBI, body[0].Bytes()))
+	assert.NoError(t, db.Remove(ethdb.TODBI, headers[2].Hash.Bytes()))
+	assert.NoError(t, db.Remove(ethdb.BloomDBI, helper.EncodeVarint(2)))
+	assert.NoError(t, db.Remove(ethdb.NumHashDBI, helper.EncodeVarint(4)))
+	assert.NoError(t, db.Set(ethdb.NumHashDBI, helper.EncodeVarint(5), headers[3].Hash.Bytes()))
+
+	assert.Equal(t, []string{
+		"block 2: missing total difficulty",
+		"block 2: missing bloom index",
+		"block 2: missing lookup of transaction " + body[0].String(),
+		"block 2: receipt of transaction " + body[0].String() + ": Not Found",
+		"block 4: missing canonical hash",
+		"block 5: header " + headers[3].Hash.String() + " has number 3",
+		"block 5: bloom index of block " + headers[5].Hash.String(),
+		"block 5: lookup of transaction " + body3[0].String() + " points to block 3",
+	}, verifyIssues(t, db, 0, 5))
+
+	_, err = Verify(context.Background(), db, 3, 2, func(*Issue) {})
+	assert.Error(t, err)
+}
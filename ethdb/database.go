@@ -14,8 +14,31 @@ var (
 	TODBI       = "todi" // total difficulty
 	SnapDBI     = "snap" // consensus snapshot
 	CodeDBI     = "code" // save contract code
+	BloomDBI    = "blom" // block hash and logs bloom by number
+	PruneDBI    = "prun" // trie node reference counts and pinned state roots
+	SnapAccDBI  = "sacc" // flat state accounts by address hash
+	SnapStoDBI  = "ssto" // flat state storage by address hash and slot hash
 )
 
+// DBIs lists all the dbis of the database
+var DBIs = []string{
+	BodyDBI,
+	AssistDBI,
+	TrieDBI,
+	NumHashDBI,
+	TxesDBI,
+	HeadDBI,
+	TODBI,
+	ReceiptsDBI,
+	SnapDBI,
+	CodeDBI,
+	TxLookUpDBI,
+	BloomDBI,
+	PruneDBI,
+	SnapAccDBI,
+	SnapStoDBI,
+}
+
 var (
 	ErrNotFound = fmt.Errorf("Not Found")
 )
@@ -30,7 +53,12 @@ type Getter interface {
 
 type Batch interface {
 	Setter
+	Remover
 	Write() error
+
+	// Discard drops the pending writes and releases their buffers, it is
+	// a noop on a written batch
+	Discard()
 }
 
 type Closer interface {
@@ -41,6 +69,49 @@ type Remover interface {
 	Remove(dbi string, k []byte) error
 }
 
+// Writer is the write side shared by the database and its batches
+type Writer interface {
+	Setter
+	Remover
+}
+
+type Clearer interface {
+	Clear(dbi string) error
+}
+
+// Stat is the number of entries of a dbi and the bytes they take
+type Stat struct {
+	Entries uint64
+	Size    uint64
+}
+
+// Stater reports the size of the dbis, not all databases support it
+type Stater interface {
+	Stat(dbi string) (*Stat, error)
+}
+
+// Iterator walks the keys of a dbi in ascending order over a snapshot of
+// the database taken when it is created, the writes done afterwards are not
+// visible. The key and the value are only valid until the next call to
+// Next. It has to be released after use.
+type Iterator interface {
+	// Next moves to the next key, it returns false once the iterator is
+	// exhausted or failed
+	Next() bool
+	// Error returns the error which stopped the iteration, if any
+	Error() error
+	Key() []byte
+	Value() []byte
+	// Release frees the snapshot, it can be called multiple times
+	Release()
+}
+
+type Iteratee interface {
+	// NewIterator iterates the keys of the dbi with the prefix, starting at
+	// the key prefix+start
+	NewIterator(dbi string, prefix, start []byte) Iterator
+}
+
 type Syncer interface {
 	Sync() error
 }
@@ -50,6 +121,8 @@ type Database interface {
 	Getter
 	Closer
 	Remover
+	Clearer
 	Syncer
+	Iteratee
 	Batch() Batch
 }
@@ -15,6 +15,7 @@ package dbtest
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/sunvim/dogesyncer/ethdb"
@@ -99,16 +100,58 @@ func TestDatabaseSuite(t *testing.T, New func() ethdb.Database) {
 				}
 			}
 			// Iterate over the database with the given configs and verify the results
-			for key, val := range tt.content {
-				got, _, _ := db.Get(ethdb.BodyDBI, []byte(key))
-				if !bytes.Equal(got, []byte(val)) {
-					t.Errorf("test %d:  value mismatch: have %s, want %s", i, string(got), val)
+			it, idx := db.NewIterator(ethdb.BodyDBI, []byte(tt.prefix), []byte(tt.start)), 0
+			for it.Next() {
+				if len(tt.order) <= idx {
+					t.Errorf("test %d: prefix=%q more items than expected: checking idx=%d (key %q), expecting len=%d", i, tt.prefix, idx, it.Key(), len(tt.order))
+					break
+				}
+				if !bytes.Equal(it.Key(), []byte(tt.order[idx])) {
+					t.Errorf("test %d: item %d: key mismatch: have %s, want %s", i, idx, string(it.Key()), tt.order[idx])
+				}
+				if !bytes.Equal(it.Value(), []byte(tt.content[tt.order[idx]])) {
+					t.Errorf("test %d: item %d: value mismatch: have %s, want %s", i, idx, string(it.Value()), tt.content[tt.order[idx]])
 				}
+				idx++
+			}
+			if err := it.Error(); err != nil {
+				t.Errorf("test %d: iteration failed: %v", i, err)
+			}
+			if idx != len(tt.order) {
+				t.Errorf("test %d: iteration terminated prematurely: have %d, want %d", i, idx, len(tt.order))
 			}
+			it.Release()
 			db.Close()
 		}
 	})
 
+	t.Run("Clear", func(t *testing.T) {
+		db := New()
+		defer db.Close()
+
+		for _, dbi := range []string{ethdb.BodyDBI, ethdb.HeadDBI} {
+			if err := db.Set(dbi, []byte("key"), []byte("val")); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := db.Clear(ethdb.BodyDBI); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, has, err := db.Get(ethdb.BodyDBI, []byte("key")); err != nil {
+			t.Fatal(err)
+		} else if has {
+			t.Fatal("cleared dbi still contains the key")
+		}
+
+		if _, has, err := db.Get(ethdb.HeadDBI, []byte("key")); err != nil {
+			t.Fatal(err)
+		} else if !has {
+			t.Fatal("clear removed the key of another dbi")
+		}
+	})
+
 	t.Run("Batch", func(t *testing.T) {
 		db := New()
 		defer db.Close()
@@ -132,4 +175,164 @@ func TestDatabaseSuite(t *testing.T, New func() ethdb.Database) {
 
 	})
 
+	t.Run("BatchDiscard", func(t *testing.T) {
+		db := New()
+		defer db.Close()
+
+		b := db.Batch()
+		if err := b.Set(ethdb.BodyDBI, []byte("1"), []byte("v1")); err != nil {
+			t.Fatal(err)
+		}
+
+		b.Discard()
+
+		// a discarded batch writes nothing and may be reused
+		if err := b.Write(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, has, err := db.Get(ethdb.BodyDBI, []byte("1")); err != nil {
+			t.Fatal(err)
+		} else if has {
+			t.Fatal("discarded write was applied")
+		}
+
+		if err := b.Set(ethdb.BodyDBI, []byte("2"), []byte("v2")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := b.Write(); err != nil {
+			t.Fatal(err)
+		}
+
+		// discarding a written batch is a noop
+		b.Discard()
+
+		if _, has, err := db.Get(ethdb.BodyDBI, []byte("2")); err != nil {
+			t.Fatal(err)
+		} else if !has {
+			t.Fatal("batch write is missing")
+		}
+	})
+
+	t.Run("BatchAtomic", func(t *testing.T) {
+		db := New()
+		defer db.Close()
+
+		if err := db.Set(ethdb.BodyDBI, []byte("1"), []byte("v1")); err != nil {
+			t.Fatal(err)
+		}
+
+		b := db.Batch()
+		if err := b.Remove(ethdb.BodyDBI, []byte("1")); err != nil {
+			t.Fatal(err)
+		}
+
+		// removing a missing key does not fail the batch
+		if err := b.Remove(ethdb.BodyDBI, []byte("missing")); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, dbi := range []string{ethdb.BodyDBI, ethdb.HeadDBI} {
+			if err := b.Set(dbi, []byte("2"), []byte("v2")); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, has, err := db.Get(ethdb.HeadDBI, []byte("2")); err != nil {
+			t.Fatal(err)
+		} else if has {
+			t.Fatal("db contains element before batch write")
+		}
+
+		if err := b.Write(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, has, err := db.Get(ethdb.BodyDBI, []byte("1")); err != nil {
+			t.Fatal(err)
+		} else if has {
+			t.Fatal("batch did not remove the key")
+		}
+
+		for _, dbi := range []string{ethdb.BodyDBI, ethdb.HeadDBI} {
+			if got, _, err := db.Get(dbi, []byte("2")); err != nil {
+				t.Fatal(err)
+			} else if !bytes.Equal(got, []byte("v2")) {
+				t.Fatalf("value mismatch in %s: have %s, want v2", dbi, got)
+			}
+		}
+	})
+
+	t.Run("IteratorDBI", func(t *testing.T) {
+		db := New()
+		defer db.Close()
+
+		for _, dbi := range []string{ethdb.BodyDBI, ethdb.HeadDBI} {
+			if err := db.Set(dbi, []byte("key"), []byte(dbi)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		it := db.NewIterator(ethdb.HeadDBI, nil, nil)
+		defer it.Release()
+
+		if !it.Next() || string(it.Key()) != "key" || string(it.Value()) != ethdb.HeadDBI {
+			t.Fatalf("unexpected item %q %q", it.Key(), it.Value())
+		}
+
+		if it.Next() {
+			t.Fatalf("iterated the key %q of another dbi", it.Key())
+		}
+
+		// an exhausted iterator stays exhausted
+		if it.Next() || it.Error() != nil {
+			t.Fatalf("exhausted iterator moved or failed: %v", it.Error())
+		}
+	})
+
+	t.Run("IteratorSnapshot", func(t *testing.T) {
+		db := New()
+		defer db.Close()
+
+		for _, k := range []string{"1", "2", "3"} {
+			if err := db.Set(ethdb.BodyDBI, []byte(k), []byte("v"+k)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		it := db.NewIterator(ethdb.BodyDBI, nil, nil)
+		defer it.Release()
+
+		// the writes after the creation are not visible to the iterator
+		if err := db.Remove(ethdb.BodyDBI, []byte("2")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := db.Set(ethdb.BodyDBI, []byte("3"), []byte("other")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := db.Set(ethdb.BodyDBI, []byte("4"), []byte("v4")); err != nil {
+			t.Fatal(err)
+		}
+
+		var keys []string
+
+		for it.Next() {
+			if string(it.Value()) != "v"+string(it.Key()) {
+				t.Fatalf("value mismatch for %s: have %s", it.Key(), it.Value())
+			}
+
+			keys = append(keys, string(it.Key()))
+		}
+
+		if err := it.Error(); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Join(keys, ",") != "1,2,3" {
+			t.Fatalf("unexpected keys %v", keys)
+		}
+	})
 }
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/leveldb"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/ethdb/memorydb"
+)
+
+const (
+	MDBX    = "mdbx"
+	LevelDB = "leveldb"
+	Memory  = "memory"
+)
+
+// Engines lists the supported database engines
+var Engines = []string{MDBX, LevelDB, Memory}
+
+var (
+	ErrUnknownEngine  = fmt.Errorf("unknown database engine, expected one of %s", strings.Join(Engines, ", "))
+	ErrEngineMismatch = fmt.Errorf("database written by another engine")
+)
+
+// markers are the files identifying the engine of an existing database
+var markers = map[string]string{
+	MDBX:    "mdbx.dat",
+	LevelDB: "CURRENT",
+}
+
+// Validate checks the engine is supported
+func Validate(engine string) error {
+	for _, e := range Engines {
+		if e == engine {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q", ErrUnknownEngine, engine)
+}
+
+// checkPath refuses to open a database left by another engine in the path
+func checkPath(engine, path string) error {
+	for other, marker := range markers {
+		if other == engine {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(path, marker)); err == nil {
+			return fmt.Errorf("%w: %s holds a %s database", ErrEngineMismatch, path, other)
+		}
+	}
+
+	return nil
+}
+
+// Open opens the database of the engine at the path, creating it when
+// missing. The memory engine ignores the path.
+func Open(engine, path string, logger hclog.Logger) (ethdb.Database, error) {
+	if err := Validate(engine); err != nil {
+		return nil, err
+	}
+
+	if err := checkPath(engine, path); err != nil {
+		return nil, err
+	}
+
+	switch engine {
+	case LevelDB:
+		return leveldb.NewLevelDB(path, logger)
+	case Memory:
+		return memorydb.New(), nil
+	default:
+		return mdbx.NewMDBX(path, logger), nil
+	}
+}
+
+// OpenReadOnly opens an existing database of the engine without write
+// access
+func OpenReadOnly(engine, path string, logger hclog.Logger) (ethdb.Database, error) {
+	if err := Validate(engine); err != nil {
+		return nil, err
+	}
+
+	if err := checkPath(engine, path); err != nil {
+		return nil, err
+	}
+
+	switch engine {
+	case LevelDB:
+		return leveldb.NewLevelDBReadOnly(path, logger)
+	case Memory:
+		return nil, fmt.Errorf("the %s engine keeps no data to read", Memory)
+	default:
+		return mdbx.NewMDBXReadOnly(path, logger)
+	}
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/ethdb"
+)
+
+func TestOpen(t *testing.T) {
+	_, err := Open("pebble", t.TempDir(), hclog.NewNullLogger())
+	assert.ErrorIs(t, err, ErrUnknownEngine)
+
+	for _, engine := range []string{MDBX, LevelDB} {
+		dir := t.TempDir()
+
+		db, err := Open(engine, dir, hclog.NewNullLogger())
+		assert.NoError(t, err)
+		assert.NoError(t, db.Set(ethdb.AssistDBI, []byte("key"), []byte("value")))
+		assert.NoError(t, db.Close())
+
+		ro, err := OpenReadOnly(engine, dir, hclog.NewNullLogger())
+		assert.NoError(t, err)
+
+		v, ok, err := ro.Get(ethdb.AssistDBI, []byte("key"))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("value"), v)
+		assert.NoError(t, ro.Close())
+
+		// the other engine does not touch the database
+		for _, other := range []string{MDBX, LevelDB} {
+			if other != engine {
+				_, err := Open(other, dir, hclog.NewNullLogger())
+				assert.ErrorIs(t, err, ErrEngineMismatch)
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package leveldb
+
+import (
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/errors"
+	leveldbiter "github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// clearBatchSize is the number of keys deleted per batch when a dbi
+	// is cleared
+	clearBatchSize = 10000
+)
+
+// LevelDB is a pure go database, the dbis share a single keyspace where
+// every key is prefixed with the name of its dbi
+type LevelDB struct {
+	logger hclog.Logger
+	path   string
+	db     *leveldb.DB
+}
+
+// NewLevelDB opens the database at the path, creating it when missing
+func NewLevelDB(path string, logger hclog.Logger) (*LevelDB, error) {
+	db, err := leveldb.OpenFile(path, &opt.Options{
+		OpenFilesCacheCapacity: 1024,
+		BlockCacheCapacity:     256 * opt.MiB,
+		WriteBuffer:            64 * opt.MiB,
+	})
+	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
+		logger.Warn("recovering corrupted database", "path", path)
+
+		db, err = leveldb.RecoverFile(path, nil)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &LevelDB{logger: logger, path: path, db: db}, nil
+}
+
+// NewLevelDBReadOnly opens an existing database without write access
+func NewLevelDBReadOnly(path string, logger hclog.Logger) (*LevelDB, error) {
+	db, err := leveldb.OpenFile(path, &opt.Options{
+		ReadOnly:       true,
+		ErrorIfMissing: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &LevelDB{logger: logger, path: path, db: db}, nil
+}
+
+// dbiKey returns the key prefixed with the dbi name, all the dbi names have
+// the same length so no prefix is the prefix of another one
+func dbiKey(dbi string, k []byte) []byte {
+	key := make([]byte, len(dbi)+len(k))
+	copy(key, dbi)
+	copy(key[len(dbi):], k)
+
+	return key
+}
+
+func (d *LevelDB) Set(dbi string, k, v []byte) error {
+	return d.db.Put(dbiKey(dbi, k), v, nil)
+}
+
+func (d *LevelDB) Get(dbi string, k []byte) ([]byte, bool, error) {
+	v, err := d.db.Get(dbiKey(dbi, k), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return v, true, nil
+}
+
+// Remove deletes the key, removing a missing key is not an error
+func (d *LevelDB) Remove(dbi string, k []byte) error {
+	return d.db.Delete(dbiKey(dbi, k), nil)
+}
+
+// Clear deletes all the keys of the dbi
+func (d *LevelDB) Clear(dbi string) error {
+	it := d.db.NewIterator(util.BytesPrefix([]byte(dbi)), nil)
+	defer it.Release()
+
+	batch := new(leveldb.Batch)
+
+	for it.Next() {
+		batch.Delete(it.Key())
+
+		if batch.Len() >= clearBatchSize {
+			if err := d.db.Write(batch, nil); err != nil {
+				return err
+			}
+
+			batch.Reset()
+		}
+	}
+
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	return d.db.Write(batch, nil)
+}
+
+// Sync is a noop, leveldb has no explicit flush and syncs its journal on
+// close
+func (d *LevelDB) Sync() error {
+	return nil
+}
+
+// Size returns the size of the tables on disk in bytes
+func (d *LevelDB) Size() (uint64, error) {
+	sizes, err := d.db.SizeOf([]util.Range{{}})
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(sizes.Sum()), nil
+}
+
+// Stat counts the entries of the dbi and returns the size of its tables,
+// recent writes still in memory are not part of the size
+func (d *LevelDB) Stat(dbi string) (*ethdb.Stat, error) {
+	prefix := util.BytesPrefix([]byte(dbi))
+
+	it := d.db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	stat := &ethdb.Stat{}
+	for it.Next() {
+		stat.Entries++
+	}
+
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	sizes, err := d.db.SizeOf([]util.Range{*prefix})
+	if err != nil {
+		return nil, err
+	}
+
+	stat.Size = uint64(sizes.Sum())
+
+	return stat, nil
+}
+
+// NewIterator iterates the keys of the dbi with the prefix from
+// prefix+start over the snapshot of the database at the call
+func (d *LevelDB) NewIterator(dbi string, prefix, start []byte) ethdb.Iterator {
+	r := util.BytesPrefix(dbiKey(dbi, prefix))
+	r.Start = dbiKey(dbi, append(append([]byte{}, prefix...), start...))
+
+	return &iterator{Iterator: d.db.NewIterator(r, nil), dbi: dbi}
+}
+
+// iterator strips the dbi name from the keys
+type iterator struct {
+	leveldbiter.Iterator
+	dbi string
+}
+
+func (it *iterator) Key() []byte {
+	key := it.Iterator.Key()
+	if key == nil {
+		return nil
+	}
+
+	return key[len(it.dbi):]
+}
+
+func (d *LevelDB) Close() error {
+	return d.db.Close()
+}
+
+func (d *LevelDB) Batch() ethdb.Batch {
+	return &Batch{db: d.db, batch: new(leveldb.Batch)}
+}
+
+// Batch collects the writes and applies them atomically
+type Batch struct {
+	db    *leveldb.DB
+	batch *leveldb.Batch
+}
+
+func (b *Batch) Set(dbi string, k, v []byte) error {
+	b.batch.Put(dbiKey(dbi, k), v)
+
+	return nil
+}
+
+// Remove deletes the key when the batch is written, removing a missing key
+// is not an error
+func (b *Batch) Remove(dbi string, k []byte) error {
+	b.batch.Delete(dbiKey(dbi, k))
+
+	return nil
+}
+
+// Write applies the batch, either all of its writes are stored or none of
+// them. The batch is empty afterwards.
+func (b *Batch) Write() error {
+	defer b.batch.Reset()
+
+	return b.db.Write(b.batch, nil)
+}
+
+// Discard drops the pending writes without applying them
+func (b *Batch) Discard() {
+	b.batch.Reset()
+}
@@ -0,0 +1,22 @@
+package leveldb
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/dbtest"
+)
+
+func TestLevelDB(t *testing.T) {
+	t.Run("DatabaseSuite", func(t *testing.T) {
+		dbtest.TestDatabaseSuite(t, func() ethdb.Database {
+			db, err := NewLevelDB(t.TempDir(), hclog.NewNullLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return db
+		})
+	})
+}
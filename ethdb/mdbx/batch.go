@@ -4,12 +4,14 @@ import (
 	"runtime"
 
 	"github.com/sunvim/utils/cachem"
+	"github.com/torquem-ch/mdbx-go/mdbx"
 )
 
 type keyvalue struct {
-	dbi   string
-	key   []byte
-	value []byte
+	dbi    string
+	key    []byte
+	value  []byte
+	remove bool
 }
 
 // KVBatch is a batch write for leveldb
@@ -28,32 +30,71 @@ func copyBytes(b []byte) (copiedBytes []byte) {
 }
 
 func (b *KVBatch) Set(dbi string, k, v []byte) error {
-	b.writes = append(b.writes, keyvalue{dbi, copyBytes(k), copyBytes(v)})
+	b.writes = append(b.writes, keyvalue{dbi: dbi, key: copyBytes(k), value: copyBytes(v)})
 	return nil
 }
 
-// why no error handle
-func (b *KVBatch) Write() error {
+// Remove deletes the key when the batch is written, removing a missing key
+// is not an error
+func (b *KVBatch) Remove(dbi string, k []byte) error {
+	b.writes = append(b.writes, keyvalue{dbi: dbi, key: copyBytes(k), remove: true})
+	return nil
+}
 
+// Write applies the batch in a single transaction, either all of its writes
+// are stored or none of them
+func (b *KVBatch) Write() error {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 
-	var err error
+	defer b.free()
 
 	txn, err := b.db.env.BeginTxn(nil, 0)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer txn.Commit()
 
 	for _, keyvalue := range b.writes {
-		err = txn.Put(b.db.dbi[keyvalue.dbi], keyvalue.key, keyvalue.value, 0)
+		if keyvalue.remove {
+			err = txn.Del(b.db.dbi[keyvalue.dbi], keyvalue.key, nil)
+			if mdbx.IsNotFound(err) {
+				err = nil
+			}
+		} else {
+			err = txn.Put(b.db.dbi[keyvalue.dbi], keyvalue.key, keyvalue.value, 0)
+		}
+
 		if err != nil {
-			panic(err)
+			txn.Abort()
+
+			return err
 		}
-		cachem.Free(keyvalue.key)
-		cachem.Free(keyvalue.value)
 	}
 
-	return nil
+	_, err = txn.Commit()
+
+	return err
+}
+
+// Discard drops the pending writes without applying them
+func (b *KVBatch) Discard() {
+	b.free()
+}
+
+// free returns the copied keys and values to the pool, the batch is empty
+// afterwards
+func (b *KVBatch) free() {
+	for _, keyvalue := range b.writes {
+		freeBytes(keyvalue.key)
+		freeBytes(keyvalue.value)
+	}
+
+	b.writes = nil
+}
+
+func freeBytes(b []byte) {
+	// the pool has nothing to take back from empty slices
+	if cap(b) > 0 {
+		cachem.Free(b)
+	}
 }
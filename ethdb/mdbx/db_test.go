@@ -1,7 +1,11 @@
 package mdbx
 
 import (
+	"io"
+	"os"
+	"os/exec"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/sunvim/dogesyncer/ethdb"
@@ -16,3 +20,114 @@ func TestMdbxDB(t *testing.T) {
 		})
 	})
 }
+
+func TestMdbxDB_ReadOnly(t *testing.T) {
+	dir := t.TempDir()
+
+	db := NewMDBX(dir, hclog.NewNullLogger())
+	if err := db.Set(ethdb.AssistDBI, []byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	ro, err := NewMDBXReadOnly(dir, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+
+	v, ok, err := ro.Get(ethdb.AssistDBI, []byte("key"))
+	if err != nil || !ok || string(v) != "value" {
+		t.Fatalf("unexpected read %q %v %v", v, ok, err)
+	}
+
+	if err := ro.Set(ethdb.AssistDBI, []byte("key"), []byte("other")); err == nil {
+		t.Fatal("expected write to fail on a read-only database")
+	}
+}
+
+func TestMdbxDB_Size(t *testing.T) {
+	db := NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer db.Close()
+
+	size, err := db.Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if size == 0 {
+		t.Fatal("expected a non-empty data file")
+	}
+}
+
+// writerDirEnv makes the test binary act as the writer process of
+// TestMdbxDB_ReadOnlyFollowsWriter
+const writerDirEnv = "MDBX_TEST_WRITER_DIR"
+
+func TestMdbxDB_ReadOnlyFollowsWriter(t *testing.T) {
+	if dir := os.Getenv(writerDirEnv); dir != "" {
+		// keep the database open until the reader is done
+		db := NewMDBX(dir, hclog.NewNullLogger())
+		defer db.Close()
+
+		if err := db.Set(ethdb.AssistDBI, []byte("key"), []byte("written")); err != nil {
+			t.Fatal(err)
+		}
+
+		_, _ = io.Copy(io.Discard, os.Stdin)
+
+		return
+	}
+
+	dir := t.TempDir()
+
+	db := NewMDBX(dir, hclog.NewNullLogger())
+	db.Close()
+
+	ro, err := NewMDBXReadOnly(dir, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+
+	// a single process can not open the database twice, the writer runs in
+	// another one
+	writer := exec.Command(os.Args[0], "-test.run", "^TestMdbxDB_ReadOnlyFollowsWriter$")
+	writer.Env = append(os.Environ(), writerDirEnv+"="+dir)
+
+	stdin, err := writer.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		stdin.Close()
+
+		if err := writer.Wait(); err != nil {
+			t.Fatalf("writer failed: %v", err)
+		}
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+
+	for {
+		v, ok, err := ro.Get(ethdb.AssistDBI, []byte("key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ok && string(v) == "written" {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the write of the other process is not visible")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,134 @@
+package mdbx
+
+import (
+	"bytes"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/torquem-ch/mdbx-go/mdbx"
+)
+
+// cursorIter iterates a dbi with a cursor of its own read transaction, the
+// transaction keeps the snapshot until the iterator is released. A long
+// living iterator holds back the reuse of the pages freed after it started.
+type cursorIter struct {
+	txn    *mdbx.Txn
+	cursor *mdbx.Cursor
+	prefix []byte
+	seek   []byte
+
+	started  bool
+	done     bool
+	released bool
+	key      []byte
+	value    []byte
+	err      error
+}
+
+// NewIterator iterates the keys of the dbi with the prefix from prefix+start
+// in a read transaction. The environment is opened without thread local
+// storage, so the iterator may move between goroutines but must not be used
+// by two of them at once.
+func (d *MdbxDB) NewIterator(dbi string, prefix, start []byte) ethdb.Iterator {
+	it := &cursorIter{
+		prefix: append([]byte{}, prefix...),
+		seek:   append(append([]byte{}, prefix...), start...),
+	}
+
+	txn, err := d.env.BeginTxn(nil, mdbx.Readonly)
+	if err != nil {
+		it.err = err
+
+		return it
+	}
+
+	cursor, err := txn.OpenCursor(d.dbi[dbi])
+	if err != nil {
+		txn.Abort()
+
+		it.err = err
+
+		return it
+	}
+
+	it.txn = txn
+	it.cursor = cursor
+
+	return it
+}
+
+func (it *cursorIter) Next() bool {
+	if it.err != nil || it.done || it.released {
+		return false
+	}
+
+	var (
+		k, v []byte
+		err  error
+	)
+
+	switch {
+	case it.started:
+		k, v, err = it.cursor.Get(nil, nil, mdbx.Next)
+	case len(it.seek) == 0:
+		k, v, err = it.cursor.Get(nil, nil, mdbx.First)
+	default:
+		k, v, err = it.cursor.Get(it.seek, nil, mdbx.SetRange)
+	}
+
+	it.started = true
+
+	if mdbx.IsNotFound(err) {
+		it.finish()
+
+		return false
+	}
+
+	if err != nil {
+		it.err = err
+		it.finish()
+
+		return false
+	}
+
+	if !bytes.HasPrefix(k, it.prefix) {
+		it.finish()
+
+		return false
+	}
+
+	it.key, it.value = k, v
+
+	return true
+}
+
+// finish ends the iteration, the snapshot is kept until the release
+func (it *cursorIter) finish() {
+	it.done = true
+	it.key, it.value = nil, nil
+}
+
+func (it *cursorIter) Error() error {
+	return it.err
+}
+
+func (it *cursorIter) Key() []byte {
+	return it.key
+}
+
+func (it *cursorIter) Value() []byte {
+	return it.value
+}
+
+func (it *cursorIter) Release() {
+	if it.released {
+		return
+	}
+
+	it.released = true
+	it.key, it.value = nil, nil
+
+	if it.cursor != nil {
+		it.cursor.Close()
+		it.txn.Abort()
+	}
+}
@@ -28,7 +28,7 @@ func (d *MdbxDB) Get(dbi string, k []byte) ([]byte, bool, error) {
 	})
 
 	if e != nil {
-		if e == mdbx.NotFound {
+		if mdbx.IsNotFound(e) {
 			e = nil
 			r = false
 		}
@@ -44,6 +44,35 @@ func (d *MdbxDB) Sync() error {
 	return nil
 }
 
+// Size returns the size of the data file in bytes
+func (d *MdbxDB) Size() (uint64, error) {
+	info, err := d.env.Info(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Geo.Current, nil
+}
+
+// Stat returns the number of entries of the dbi and the size of its pages
+func (d *MdbxDB) Stat(dbi string) (*ethdb.Stat, error) {
+	var stat *mdbx.Stat
+
+	err := d.env.View(func(txn *mdbx.Txn) (err error) {
+		stat, err = txn.StatDBI(d.dbi[dbi])
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ethdb.Stat{
+		Entries: stat.Entries,
+		Size:    uint64(stat.PSize) * (stat.BranchPages + stat.LeafPages + stat.OverflowPages),
+	}, nil
+}
+
 func (d *MdbxDB) Close() error {
 	d.env.Sync(true, false)
 	for _, dbi := range d.dbi {
@@ -57,8 +86,20 @@ func (d *MdbxDB) Batch() ethdb.Batch {
 	return &KVBatch{db: d}
 }
 
+// Clear deletes all the keys of the dbi
+func (d *MdbxDB) Clear(dbi string) error {
+	return d.env.Update(func(txn *mdbx.Txn) error {
+		return txn.Drop(d.dbi[dbi], false)
+	})
+}
+
+// Remove deletes the key, removing a missing key is not an error
 func (d *MdbxDB) Remove(dbi string, k []byte) error {
 	return d.env.Update(func(txn *mdbx.Txn) error {
-		return txn.Del(d.dbi[dbi], k, nil)
+		if err := txn.Del(d.dbi[dbi], k, nil); err != nil && !mdbx.IsNotFound(err) {
+			return err
+		}
+
+		return nil
 	})
 }
@@ -2,6 +2,7 @@ package mdbx
 
 import (
 	"bytes"
+	"fmt"
 	"sync"
 
 	"github.com/hashicorp/go-hclog"
@@ -39,19 +40,7 @@ var (
 
 	defaultFlags = mdbx.Durable | mdbx.NoReadahead | mdbx.Coalesce | mdbx.NoMetaSync
 
-	dbis = []string{
-		ethdb.BodyDBI,
-		ethdb.AssistDBI,
-		ethdb.TrieDBI,
-		ethdb.NumHashDBI,
-		ethdb.TxesDBI,
-		ethdb.HeadDBI,
-		ethdb.TODBI,
-		ethdb.ReceiptsDBI,
-		ethdb.SnapDBI,
-		ethdb.CodeDBI,
-		ethdb.TxLookUpDBI,
-	}
+	dbis = ethdb.DBIs
 )
 
 func NewMDBX(path string, logger hclog.Logger) *MdbxDB {
@@ -99,3 +88,50 @@ func NewMDBX(path string, logger hclog.Logger) *MdbxDB {
 
 	return d
 }
+
+// NewMDBXReadOnly opens an existing database without write access, so it
+// can be read while another process writes to it
+func NewMDBXReadOnly(path string, logger hclog.Logger) (*MdbxDB, error) {
+	env, err := mdbx.NewEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := env.SetOption(mdbx.OptMaxDB, 32); err != nil {
+		env.Close()
+
+		return nil, err
+	}
+
+	if err = env.Open(path, uint(mdbx.Readonly), 0664); err != nil {
+		env.Close()
+
+		return nil, err
+	}
+
+	d := &MdbxDB{
+		logger: logger,
+		path:   path,
+		env:    env,
+		dbi:    make(map[string]mdbx.DBI),
+	}
+
+	err = env.View(func(txn *mdbx.Txn) error {
+		for _, dbiName := range dbis {
+			dbi, err := txn.OpenDBI(dbiName, 0, nil, nil)
+			if err != nil {
+				return fmt.Errorf("open dbi %s: %w", dbiName, err)
+			}
+			d.dbi[dbiName] = dbi
+		}
+
+		return nil
+	})
+	if err != nil {
+		env.Close()
+
+		return nil, err
+	}
+
+	return d, nil
+}
@@ -0,0 +1,39 @@
+package mdbx
+
+import (
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	prometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics represents the mdbx metrics
+type Metrics struct {
+	// Size of the data file in bytes
+	Size metrics.Gauge
+}
+
+// GetPrometheusMetrics return the mdbx metrics instance
+func GetPrometheusMetrics(namespace string, labelsWithValues ...string) *Metrics {
+	labels := []string{}
+
+	for i := 0; i < len(labelsWithValues); i += 2 {
+		labels = append(labels, labelsWithValues[i])
+	}
+
+	return &Metrics{
+		Size: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "mdbx",
+			Name:      "size_bytes",
+			Help:      "Size of the data file in bytes",
+		}, labels).With(labelsWithValues...),
+	}
+}
+
+// NilMetrics will return the non operational mdbx metrics
+func NilMetrics() *Metrics {
+	return &Metrics{
+		Size: discard.NewGauge(),
+	}
+}
@@ -0,0 +1,244 @@
+package memorydb
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+)
+
+// MemoryDB keeps the dbis in maps, nothing is persisted. It is meant for
+// tests and throwaway nodes.
+type MemoryDB struct {
+	lock sync.RWMutex
+	dbis map[string]map[string][]byte
+}
+
+func New() *MemoryDB {
+	return &MemoryDB{dbis: make(map[string]map[string][]byte)}
+}
+
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+
+	return c
+}
+
+// set stores the value as is, it expects the lock to be held
+func (d *MemoryDB) set(dbi string, k, v []byte) {
+	keys, ok := d.dbis[dbi]
+	if !ok {
+		keys = make(map[string][]byte)
+		d.dbis[dbi] = keys
+	}
+
+	keys[string(k)] = v
+}
+
+func (d *MemoryDB) Set(dbi string, k, v []byte) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.set(dbi, k, copyBytes(v))
+
+	return nil
+}
+
+func (d *MemoryDB) Get(dbi string, k []byte) ([]byte, bool, error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	v, ok := d.dbis[dbi][string(k)]
+	if !ok {
+		return nil, false, nil
+	}
+
+	return copyBytes(v), true, nil
+}
+
+// Remove deletes the key, removing a missing key is not an error
+func (d *MemoryDB) Remove(dbi string, k []byte) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.dbis[dbi], string(k))
+
+	return nil
+}
+
+// Clear deletes all the keys of the dbi
+func (d *MemoryDB) Clear(dbi string) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.dbis, dbi)
+
+	return nil
+}
+
+func (d *MemoryDB) Sync() error {
+	return nil
+}
+
+// Size returns the bytes taken by all the keys and values
+func (d *MemoryDB) Size() (uint64, error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	size := uint64(0)
+	for _, keys := range d.dbis {
+		for k, v := range keys {
+			size += uint64(len(k) + len(v))
+		}
+	}
+
+	return size, nil
+}
+
+// Stat returns the number of entries of the dbi and the bytes taken by
+// their keys and values
+func (d *MemoryDB) Stat(dbi string) (*ethdb.Stat, error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	stat := &ethdb.Stat{Entries: uint64(len(d.dbis[dbi]))}
+	for k, v := range d.dbis[dbi] {
+		stat.Size += uint64(len(k) + len(v))
+	}
+
+	return stat, nil
+}
+
+// NewIterator iterates the keys of the dbi with the prefix from
+// prefix+start, the matching keys and values are copied when it is created
+func (d *MemoryDB) NewIterator(dbi string, prefix, start []byte) ethdb.Iterator {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	var (
+		seek = string(prefix) + string(start)
+		keys = []string{}
+	)
+
+	for k := range d.dbis[dbi] {
+		if strings.HasPrefix(k, string(prefix)) && k >= seek {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	it := &iterator{
+		keys:   make([][]byte, len(keys)),
+		values: make([][]byte, len(keys)),
+		index:  -1,
+	}
+
+	for i, k := range keys {
+		it.keys[i] = []byte(k)
+		it.values[i] = copyBytes(d.dbis[dbi][k])
+	}
+
+	return it
+}
+
+func (d *MemoryDB) Close() error {
+	return nil
+}
+
+func (d *MemoryDB) Batch() ethdb.Batch {
+	return &Batch{db: d}
+}
+
+type keyvalue struct {
+	dbi    string
+	key    []byte
+	value  []byte
+	remove bool
+}
+
+// Batch collects the writes and applies them under a single lock
+type Batch struct {
+	db     *MemoryDB
+	writes []keyvalue
+}
+
+func (b *Batch) Set(dbi string, k, v []byte) error {
+	b.writes = append(b.writes, keyvalue{dbi: dbi, key: copyBytes(k), value: copyBytes(v)})
+
+	return nil
+}
+
+// Remove deletes the key when the batch is written, removing a missing key
+// is not an error
+func (b *Batch) Remove(dbi string, k []byte) error {
+	b.writes = append(b.writes, keyvalue{dbi: dbi, key: copyBytes(k), remove: true})
+
+	return nil
+}
+
+// Write applies the batch atomically, the batch is empty afterwards
+func (b *Batch) Write() error {
+	b.db.lock.Lock()
+	defer b.db.lock.Unlock()
+
+	for _, kv := range b.writes {
+		if kv.remove {
+			delete(b.db.dbis[kv.dbi], string(kv.key))
+		} else {
+			b.db.set(kv.dbi, kv.key, kv.value)
+		}
+	}
+
+	b.writes = nil
+
+	return nil
+}
+
+// Discard drops the pending writes without applying them
+func (b *Batch) Discard() {
+	b.writes = nil
+}
+
+// iterator walks the copied keys and values
+type iterator struct {
+	keys   [][]byte
+	values [][]byte
+	index  int
+}
+
+func (it *iterator) Next() bool {
+	if it.index >= len(it.keys) {
+		return false
+	}
+
+	it.index++
+
+	return it.index < len(it.keys)
+}
+
+func (it *iterator) Error() error {
+	return nil
+}
+
+func (it *iterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+
+	return it.keys[it.index]
+}
+
+func (it *iterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+
+	return it.values[it.index]
+}
+
+func (it *iterator) Release() {
+	it.keys, it.values = nil, nil
+}
@@ -0,0 +1,16 @@
+package memorydb
+
+import (
+	"testing"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/dbtest"
+)
+
+func TestMemoryDB(t *testing.T) {
+	t.Run("DatabaseSuite", func(t *testing.T) {
+		dbtest.TestDatabaseSuite(t, func() ethdb.Database {
+			return New()
+		})
+	})
+}
@@ -2,6 +2,7 @@ package progress
 
 import (
 	"sync"
+	"time"
 
 	"github.com/sunvim/dogesyncer/blockchain"
 )
@@ -17,17 +18,42 @@ const (
 // progression of the node
 type Progression struct {
 	// SyncType is indicating the sync method
-	SyncType ChainSyncType
+	SyncType ChainSyncType `json:"syncType"`
 
 	// StartingBlock is the initial block that the node is starting
 	// the sync from. It is reset after every sync batch
-	StartingBlock uint64
+	StartingBlock uint64 `json:"startingBlock"`
 
 	// CurrentBlock is the last written block from the sync batch
-	CurrentBlock uint64
+	CurrentBlock uint64 `json:"currentBlock"`
 
 	// HighestBlock is the target block in the sync batch
-	HighestBlock uint64
+	HighestBlock uint64 `json:"highestBlock"`
+
+	// StartTime is when the sync batch started
+	StartTime time.Time `json:"startTime"`
+}
+
+// Rate returns the number of blocks written per second since the start
+// of the sync batch
+func (p *Progression) Rate(now time.Time) float64 {
+	elapsed := now.Sub(p.StartTime).Seconds()
+	if elapsed <= 0 || p.CurrentBlock <= p.StartingBlock {
+		return 0
+	}
+
+	return float64(p.CurrentBlock-p.StartingBlock) / elapsed
+}
+
+// ETA returns the estimated time until the highest block is written at
+// the current rate, zero if it is unknown
+func (p *Progression) ETA(now time.Time) time.Duration {
+	rate := p.Rate(now)
+	if rate == 0 || p.HighestBlock <= p.CurrentBlock {
+		return 0
+	}
+
+	return time.Duration(float64(p.HighestBlock-p.CurrentBlock) / rate * float64(time.Second))
 }
 
 type ProgressionWrapper struct {
@@ -63,6 +89,8 @@ func (pw *ProgressionWrapper) StartProgression(
 	pw.progression = &Progression{
 		SyncType:      pw.syncType,
 		StartingBlock: startingBlock,
+		CurrentBlock:  startingBlock,
+		StartTime:     time.Now(),
 	}
 
 	go pw.RunUpdateLoop(subscription)
@@ -109,6 +137,10 @@ func (pw *ProgressionWrapper) UpdateCurrentProgression(currentBlock uint64) {
 	pw.lock.Lock()
 	defer pw.lock.Unlock()
 
+	if pw.progression == nil {
+		return
+	}
+
 	pw.progression.CurrentBlock = currentBlock
 }
 
@@ -117,13 +149,24 @@ func (pw *ProgressionWrapper) UpdateHighestProgression(highestBlock uint64) {
 	pw.lock.Lock()
 	defer pw.lock.Unlock()
 
+	if pw.progression == nil {
+		return
+	}
+
 	pw.progression.HighestBlock = highestBlock
 }
 
-// GetProgression returns the latest sync progression
+// GetProgression returns a copy of the latest sync progression, nil if no
+// sync is in progress
 func (pw *ProgressionWrapper) GetProgression() *Progression {
 	pw.lock.RLock()
 	defer pw.lock.RUnlock()
 
-	return pw.progression
+	if pw.progression == nil {
+		return nil
+	}
+
+	progression := *pw.progression
+
+	return &progression
 }
@@ -0,0 +1,45 @@
+package progress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgression_RateAndETA(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	p := &Progression{
+		StartingBlock: 100,
+		CurrentBlock:  300,
+		HighestBlock:  1300,
+		StartTime:     now.Add(-10 * time.Second),
+	}
+
+	assert.Equal(t, float64(20), p.Rate(now))
+	assert.Equal(t, 50*time.Second, p.ETA(now))
+
+	// nothing written yet, the rate is unknown
+	p.CurrentBlock = p.StartingBlock
+	assert.Equal(t, float64(0), p.Rate(now))
+	assert.Equal(t, time.Duration(0), p.ETA(now))
+}
+
+func TestProgressionWrapper_GetProgression(t *testing.T) {
+	pw := NewProgressionWrapper(ChainSyncBulk)
+	assert.Nil(t, pw.GetProgression())
+
+	// updates without a running progression are dropped
+	pw.UpdateHighestProgression(10)
+	assert.Nil(t, pw.GetProgression())
+
+	pw.progression = &Progression{SyncType: ChainSyncBulk}
+	pw.UpdateHighestProgression(10)
+
+	// the caller gets a copy
+	p := pw.GetProgression()
+	p.HighestBlock = 20
+
+	assert.Equal(t, uint64(10), pw.GetProgression().HighestBlock)
+}
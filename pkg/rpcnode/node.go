@@ -0,0 +1,176 @@
+package rpcnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/blockchain"
+	"github.com/sunvim/dogesyncer/chain"
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/engine"
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/rpc"
+	"github.com/sunvim/dogesyncer/state"
+	itrie "github.com/sunvim/dogesyncer/state/immutable-trie"
+	"github.com/sunvim/dogesyncer/state/runtime/evm"
+	"github.com/sunvim/dogesyncer/state/runtime/precompiled"
+)
+
+const (
+	// DefaultPollInterval is the default interval between two reads of the
+	// head written by the syncer
+	DefaultPollInterval = time.Second
+)
+
+var (
+	errGenesisMismatch = errors.New("genesis file does not match the genesis of the database")
+)
+
+// Config is the configuration of the rpc node
+type Config struct {
+	Chain    *chain.Chain
+	DataDir  string
+	DBEngine string
+
+	// PollInterval is how often the head of the database is read
+	PollInterval time.Duration
+
+	RPC *rpc.Config
+}
+
+// Node serves the JSON-RPC API from the database of a syncer running in
+// another process. The database is opened read only and the head is
+// followed by polling it.
+type Node struct {
+	logger     hclog.Logger
+	config     *Config
+	blockchain *blockchain.Blockchain
+	rpc        *rpc.RpcServer
+
+	wg      sync.WaitGroup
+	closeCh chan struct{}
+}
+
+// NewNode opens the database and loads its head, the database needs a head
+// so the syncer has to write the genesis first
+func NewNode(logger hclog.Logger, config *Config) (*Node, error) {
+	db, err := engine.OpenReadOnly(config.DBEngine, filepath.Join(config.DataDir, "blockchain"), logger.Named(config.DBEngine))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %w", err)
+	}
+
+	if err := checkGenesis(db, config.Chain.Genesis); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	st := itrie.NewState(itrie.NewKVStorage(db), nil)
+
+	executor := state.NewExecutor(config.Chain.Params, st, logger)
+	executor.SetRuntime(precompiled.NewPrecompiled())
+	executor.SetRuntime(evm.NewEVM())
+
+	bc, err := blockchain.NewBlockchain(logger, db, config.Chain, executor, st, nil)
+	if err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	executor.GetHash = bc.GetHashHelper
+
+	if _, err := bc.ReloadHead(); err != nil {
+		bc.Close()
+
+		return nil, err
+	}
+
+	logger.Info("current header", "hash", bc.Header().Hash, "number", bc.Header().Number)
+
+	return &Node{
+		logger:     logger,
+		config:     config,
+		blockchain: bc,
+		rpc:        rpc.NewRpcServer(logger, bc, executor, nil, config.RPC),
+		closeCh:    make(chan struct{}),
+	}, nil
+}
+
+// checkGenesis compares the genesis of the chain with the one of the
+// database. The genesis state is not written by the reader, so its root is
+// taken from the database.
+func checkGenesis(db ethdb.Database, genesis *chain.Genesis) error {
+	hash, ok := rawdb.ReadCanonicalHash(db, 0)
+	if !ok {
+		return blockchain.ErrNoHead
+	}
+
+	header, err := rawdb.ReadHeader(db, hash)
+	if err != nil {
+		return fmt.Errorf("failed to get genesis header %s: %w", hash, err)
+	}
+
+	genesis.StateRoot = header.StateRoot
+
+	if genesis.Hash() != hash {
+		return fmt.Errorf("%w: %s != %s", errGenesisMismatch, genesis.Hash(), hash)
+	}
+
+	return nil
+}
+
+// Start serves the API and follows the head until the node closes
+func (n *Node) Start(ctx context.Context) error {
+	if err := n.rpc.Start(ctx); err != nil {
+		return err
+	}
+
+	n.wg.Add(1)
+
+	go n.followHead()
+
+	return nil
+}
+
+// followHead reloads the head written by the syncer
+func (n *Node) followHead() {
+	defer n.wg.Done()
+
+	ticker := time.NewTicker(n.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.closeCh:
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := n.blockchain.ReloadHead()
+		if err != nil {
+			n.logger.Error("failed to reload the head", "err", err)
+
+			continue
+		}
+
+		if changed {
+			header := n.blockchain.Header()
+			n.logger.Debug("new head", "number", header.Number, "hash", header.Hash)
+		}
+	}
+}
+
+func (n *Node) Close() error {
+	close(n.closeCh)
+	n.wg.Wait()
+
+	n.logger.Info("closing blockchain...")
+
+	return n.blockchain.Close()
+}
@@ -2,11 +2,14 @@ package server
 
 import (
 	"net"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/sunvim/dogesyncer/chain"
+	"github.com/sunvim/dogesyncer/ethdb/engine"
 	"github.com/sunvim/dogesyncer/network"
 	"github.com/sunvim/dogesyncer/secrets"
+	itrie "github.com/sunvim/dogesyncer/state/immutable-trie"
 )
 
 // Config defines the server configuration params
@@ -14,8 +17,10 @@ type Config struct {
 	GenesisPath       string   `json:"chain_config"`
 	SecretsConfigPath string   `json:"secrets_config"`
 	DataDir           string   `json:"data_dir"`
+	DBEngine          string   `json:"db_engine"`
 	BlockGasTarget    string   `json:"block_gas_target"`
 	GRPCAddr          string   `json:"grpc_addr"`
+	PrometheusAddr    string   `json:"prometheus_addr"`
 	HttpAddr          string   `json:"rpc_addr"`
 	HttpPort          string   `json:"rpc_port"`
 	Network           *Network `json:"network"`
@@ -23,17 +28,58 @@ type Config struct {
 	BlockTime         uint64   `json:"block_time_s"`
 	Headers           *Headers `json:"headers"`
 	LogFilePath       string   `json:"log_to"`
+
+	JSONRPCBatchRequestLimit uint64 `json:"json_rpc_batch_request_limit"`
+	JSONRPCBlockRangeLimit   uint64 `json:"json_rpc_block_range_limit"`
+	JSONRPCEnableUnsafe      bool   `json:"json_rpc_enable_unsafe"`
+	EnableWS                 bool   `json:"enable_ws"`
+	WSPort                   string `json:"ws_port"`
+	RestoreFile              string `json:"restore_file"`
+
+	HealthMaxBlockLag uint64 `json:"health_max_block_lag"`
+	HealthMaxHeadAge  uint64 `json:"health_max_head_age_s"`
+
+	GCMode                  string `json:"gc_mode"`
+	PruneTickSeconds        uint64 `json:"prune_tick_seconds"`
+	PruneRetainBlocks       uint64 `json:"prune_retain_blocks"`
+	PruneCheckpoints        []uint `json:"prune_checkpoints"`
+	PruneCheckpointInterval uint64 `json:"prune_checkpoint_interval"`
 }
 
+const (
+	// DefaultJSONRPCBatchRequestLimit is the default max length of a json-rpc batch request
+	DefaultJSONRPCBatchRequestLimit uint64 = 20
+	// DefaultJSONRPCBlockRangeLimit is the default max block range of a log query
+	DefaultJSONRPCBlockRangeLimit uint64 = 1000
+	// DefaultHealthMaxBlockLag is the default max number of blocks a ready
+	// node lags the best peer
+	DefaultHealthMaxBlockLag uint64 = 16
+	// DefaultHealthMaxHeadAge is the default max age in seconds of the head
+	// block of a ready node
+	DefaultHealthMaxHeadAge uint64 = 60
+	// DefaultPruneTickSeconds is the default interval in seconds between two
+	// state prunings of a full node
+	DefaultPruneTickSeconds uint64 = 60
+)
+
+const (
+	// GCModeArchive keeps the states of all blocks
+	GCModeArchive = "archive"
+	// GCModeFull prunes the states which left the retention window
+	GCModeFull = "full"
+)
+
 func DefaultConfig() *Config {
 	defaultNetworkConfig := network.DefaultConfig()
 	return &Config{
 		GenesisPath:    "genesis.json",
 		DataDir:        "dogechain",
+		DBEngine:       engine.MDBX,
 		BlockGasTarget: "0x00",
 		LogLevel:       "INFO",
 		HttpAddr:       "127.0.0.1",
 		HttpPort:       "8545",
+		WSPort:         "8546",
 		BlockTime:      2,
 		Network: &Network{
 			NoDiscover:       defaultNetworkConfig.NoDiscover,
@@ -44,7 +90,14 @@ func DefaultConfig() *Config {
 		Headers: &Headers{
 			AccessControlAllowOrigins: []string{"*"},
 		},
-		LogFilePath: "",
+		LogFilePath:              "",
+		JSONRPCBatchRequestLimit: DefaultJSONRPCBatchRequestLimit,
+		JSONRPCBlockRangeLimit:   DefaultJSONRPCBlockRangeLimit,
+		HealthMaxBlockLag:        DefaultHealthMaxBlockLag,
+		HealthMaxHeadAge:         DefaultHealthMaxHeadAge,
+		GCMode:                   GCModeArchive,
+		PruneTickSeconds:         DefaultPruneTickSeconds,
+		PruneRetainBlocks:        itrie.DefaultPruneRetain,
 	}
 }
 
@@ -73,6 +126,25 @@ type ServerConfig struct {
 	RpcAddr       string
 	RpcPort       string
 
+	// PrometheusAddr serves the metrics on /metrics, nil disables it
+	PrometheusAddr *net.TCPAddr
+
+	JSONRPCBatchRequestLimit uint64
+	JSONRPCBlockRangeLimit   uint64
+	JSONRPCEnableUnsafe      bool
+	EnableWS                 bool
+	WSPort                   string
+
+	HealthMaxBlockLag uint64
+	HealthMaxHeadAge  time.Duration
+
+	// the full gc mode keeps the states of the last PruneRetainBlocks blocks
+	// and of the checkpoints, it prunes every PruneTickSeconds
+	GCMode                  string
+	PruneRetainBlocks       uint64
+	PruneCheckpoints        []uint64
+	PruneCheckpointInterval uint64
+
 	PriceLimit            uint64
 	MaxSlots              uint64
 	BlockTime             uint64
@@ -82,6 +154,7 @@ type ServerConfig struct {
 	Network *network.Config
 
 	DataDir     string
+	DBEngine    string
 	RestoreFile *string
 
 	Seal           bool
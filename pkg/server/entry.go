@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"github.com/spf13/cobra"
-	"github.com/sunvim/dogesyncer/protocol"
 	"github.com/sunvim/dogesyncer/rpc"
 	"github.com/sunvim/utils/grace"
 )
@@ -23,14 +22,26 @@ func Run(cmd *cobra.Command, args []string) {
 	}
 
 	m.logger.Info("start to syncer")
-	syncer := protocol.NewSyncer(m.logger, m.network, m.blockchain, serverConfig.DataDir)
-	syncer.Start(ctx)
-
-	rpcServer := rpc.NewRpcServer(m.logger, m.blockchain, serverConfig.RpcAddr, serverConfig.RpcPort)
+	m.syncer.Start(ctx)
+
+	rpcServer := rpc.NewRpcServer(m.logger, m.blockchain, m.executor, m.syncer, &rpc.Config{
+		Addr:             serverConfig.RpcAddr,
+		Port:             serverConfig.RpcPort,
+		BatchLengthLimit: serverConfig.JSONRPCBatchRequestLimit,
+		BlockRangeLimit:  serverConfig.JSONRPCBlockRangeLimit,
+		EnableUnsafe:     serverConfig.JSONRPCEnableUnsafe,
+		EnableWS:         serverConfig.EnableWS,
+		WSPort:           serverConfig.WSPort,
+
+		HealthMaxBlockLag: serverConfig.HealthMaxBlockLag,
+		HealthMaxHeadAge:  serverConfig.HealthMaxHeadAge,
+
+		Metrics: m.serverMetrics.rpc,
+	})
 	rpcServer.Start(ctx)
 
 	// register close function
-	svc.Register(syncer.Close)
+	svc.Register(m.syncer.Close)
 	svc.Register(m.Close)
 
 	m.logger.Info("server boot over...")
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"github.com/sunvim/dogesyncer/blockchain"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/network"
+	"github.com/sunvim/dogesyncer/protocol"
+	"github.com/sunvim/dogesyncer/rpc"
+	itrie "github.com/sunvim/dogesyncer/state/immutable-trie"
+)
+
+// serverMetrics holds the metric instances of all sub systems
+type serverMetrics struct {
+	blockchain *blockchain.Metrics
+	network    *network.Metrics
+	trie       *itrie.Metrics
+	syncer     *protocol.Metrics
+	rpc        *rpc.Metrics
+	mdbx       *mdbx.Metrics
+}
+
+// metricProvider serverMetric instance for the given ChainID and nameSpace
+func metricProvider(nameSpace string, chainID string, metricsRequired bool) *serverMetrics {
+	if metricsRequired {
+		return &serverMetrics{
+			blockchain: blockchain.GetPrometheusMetrics(nameSpace, "chain_id", chainID),
+			network:    network.GetPrometheusMetrics(nameSpace, "chain_id", chainID),
+			trie:       itrie.GetPrometheusMetrics(nameSpace, "chain_id", chainID),
+			syncer:     protocol.GetPrometheusMetrics(nameSpace, "chain_id", chainID),
+			rpc:        rpc.GetPrometheusMetrics(nameSpace, "chain_id", chainID),
+			mdbx:       mdbx.GetPrometheusMetrics(nameSpace, "chain_id", chainID),
+		}
+	}
+
+	return &serverMetrics{
+		blockchain: blockchain.NilMetrics(),
+		network:    network.NilMetrics(),
+		trie:       itrie.NilMetrics(),
+		syncer:     protocol.NilMetrics(),
+		rpc:        rpc.NilMetrics(),
+		mdbx:       mdbx.NilMetrics(),
+	}
+}
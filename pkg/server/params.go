@@ -8,15 +8,18 @@ import (
 	"math"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/hcl"
 
 	"github.com/multiformats/go-multiaddr"
 	"github.com/sunvim/dogesyncer/chain"
+	"github.com/sunvim/dogesyncer/ethdb/engine"
 	"github.com/sunvim/dogesyncer/network"
 	"github.com/sunvim/dogesyncer/network/common"
 	"github.com/sunvim/dogesyncer/secrets"
+	itrie "github.com/sunvim/dogesyncer/state/immutable-trie"
 	"github.com/sunvim/dogesyncer/types"
 )
 
@@ -47,10 +50,20 @@ const (
 	enableGraphQLFlag            = "enable-graphql"
 	jsonRPCBatchRequestLimitFlag = "json-rpc-batch-request-limit"
 	jsonRPCBlockRangeLimitFlag   = "json-rpc-block-range-limit"
+	jsonRPCEnableUnsafeFlag      = "json-rpc-enable-unsafe"
 	jsonrpcNamespaceFlag         = "json-rpc-namespace"
 	JsonrpcAddress               = "http.addr"
 	JsonrpcPort                  = "http.port"
+	JsonrpcWSPort                = "ws.port"
 	enableWSFlag                 = "enable-ws"
+	prometheusAddressFlag        = "prometheus"
+	healthMaxBlockLagFlag        = "health-max-block-lag"
+	healthMaxHeadAgeFlag         = "health-max-head-age"
+	dbEngineFlag                 = "db.engine"
+	gcModeFlag                   = "gcmode"
+	pruneRetainBlocksFlag        = "prune-retain-blocks"
+	pruneCheckpointsFlag         = "prune-checkpoints"
+	pruneCheckpointIntervalFlag  = "prune-checkpoint-interval"
 )
 
 const (
@@ -71,8 +84,11 @@ var (
 )
 
 var (
-	errInvalidPeerParams = errors.New("both max-peers and max-inbound/outbound flags are set")
-	errInvalidNATAddress = errors.New("could not parse NAT address (ip:port)")
+	errInvalidPeerParams  = errors.New("both max-peers and max-inbound/outbound flags are set")
+	errInvalidNATAddress  = errors.New("could not parse NAT address (ip:port)")
+	errInvalidGCMode      = fmt.Errorf("gc mode must be %s or %s", GCModeArchive, GCModeFull)
+	errInvalidPruneTick   = errors.New("the full gc mode needs a non zero prune tick")
+	errInvalidPruneRetain = fmt.Errorf("the full gc mode needs to retain at least %d blocks", itrie.MinPruneRetain)
 )
 
 type serverParams struct {
@@ -149,6 +165,26 @@ func (p *serverParams) validateFlags() error {
 		return errInvalidPeerParams
 	}
 
+	if err := engine.Validate(p.rawConfig.DBEngine); err != nil {
+		return err
+	}
+
+	// Validate the gc mode configuration
+	switch p.rawConfig.GCMode {
+	case GCModeArchive:
+	case GCModeFull:
+		if p.rawConfig.PruneTickSeconds == 0 {
+			return errInvalidPruneTick
+		}
+
+		// the flat state is generated from a state behind the head
+		if p.rawConfig.PruneRetainBlocks < itrie.MinPruneRetain {
+			return errInvalidPruneRetain
+		}
+	default:
+		return errInvalidGCMode
+	}
+
 	return nil
 }
 
@@ -173,10 +209,32 @@ func (p *serverParams) isNATAddressSet() bool {
 	return p.rawConfig.Network.NatAddr != ""
 }
 
+func (p *serverParams) isPrometheusAddressSet() bool {
+	return p.rawConfig.PrometheusAddr != ""
+}
+
 func (p *serverParams) isDNSAddressSet() bool {
 	return p.rawConfig.Network.DNSAddr != ""
 }
 
+func (p *serverParams) getRestoreFilePath() *string {
+	if p.rawConfig.RestoreFile != "" {
+		return &p.rawConfig.RestoreFile
+	}
+
+	return nil
+}
+
+func (p *serverParams) getPruneCheckpoints() []uint64 {
+	checkpoints := make([]uint64, 0, len(p.rawConfig.PruneCheckpoints))
+
+	for _, number := range p.rawConfig.PruneCheckpoints {
+		checkpoints = append(checkpoints, uint64(number))
+	}
+
+	return checkpoints
+}
+
 func (p *serverParams) setRawGRPCAddress(grpcAddress string) {
 	p.rawConfig.GRPCAddr = grpcAddress
 }
@@ -200,11 +258,28 @@ func (p *serverParams) generateConfig() *ServerConfig {
 	// namespace
 
 	return &ServerConfig{
-		Chain:      chainCfg,
-		GRPCAddr:   p.grpcAddress,
-		LibP2PAddr: p.libp2pAddress,
-		RpcAddr:    p.rawConfig.HttpAddr,
-		RpcPort:    p.rawConfig.HttpPort,
+		Chain:          chainCfg,
+		GRPCAddr:       p.grpcAddress,
+		LibP2PAddr:     p.libp2pAddress,
+		PrometheusAddr: p.prometheusAddress,
+		RpcAddr:        p.rawConfig.HttpAddr,
+		RpcPort:        p.rawConfig.HttpPort,
+
+		JSONRPCBatchRequestLimit: p.rawConfig.JSONRPCBatchRequestLimit,
+		JSONRPCBlockRangeLimit:   p.rawConfig.JSONRPCBlockRangeLimit,
+		JSONRPCEnableUnsafe:      p.rawConfig.JSONRPCEnableUnsafe,
+		EnableWS:                 p.rawConfig.EnableWS,
+		WSPort:                   p.rawConfig.WSPort,
+
+		HealthMaxBlockLag: p.rawConfig.HealthMaxBlockLag,
+		HealthMaxHeadAge:  time.Duration(p.rawConfig.HealthMaxHeadAge) * time.Second,
+
+		GCMode:                  p.rawConfig.GCMode,
+		PruneTickSeconds:        p.rawConfig.PruneTickSeconds,
+		PruneRetainBlocks:       p.rawConfig.PruneRetainBlocks,
+		PruneCheckpoints:        p.getPruneCheckpoints(),
+		PruneCheckpointInterval: p.rawConfig.PruneCheckpointInterval,
+
 		Network: &network.Config{
 			NoDiscover:       p.rawConfig.Network.NoDiscover,
 			Addr:             p.libp2pAddress,
@@ -217,6 +292,8 @@ func (p *serverParams) generateConfig() *ServerConfig {
 			Chain:            p.genesisConfig,
 		},
 		DataDir:        p.rawConfig.DataDir,
+		DBEngine:       p.rawConfig.DBEngine,
+		RestoreFile:    p.getRestoreFilePath(),
 		SecretsManager: p.secretsConfig,
 		BlockTime:      p.rawConfig.BlockTime,
 		LogLevel:       hclog.LevelFromString(p.rawConfig.LogLevel),
@@ -362,6 +439,10 @@ func (p *serverParams) initAddresses() error {
 		return err
 	}
 
+	if err := p.initPrometheusAddress(); err != nil {
+		return err
+	}
+
 	return p.initGRPCAddress()
 }
 
@@ -422,6 +503,23 @@ func (p *serverParams) initDNSAddress() error {
 	return nil
 }
 
+func (p *serverParams) initPrometheusAddress() error {
+	if !p.isPrometheusAddressSet() {
+		return nil
+	}
+
+	var parseErr error
+
+	if p.prometheusAddress, parseErr = ResolveAddr(
+		p.rawConfig.PrometheusAddr,
+		"",
+	); parseErr != nil {
+		return parseErr
+	}
+
+	return nil
+}
+
 func (p *serverParams) initGRPCAddress() error {
 	var parseErr error
 
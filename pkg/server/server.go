@@ -2,17 +2,29 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sunvim/dogesyncer/archive"
 	"github.com/sunvim/dogesyncer/blockchain"
 	"github.com/sunvim/dogesyncer/chain"
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/engine"
 	"github.com/sunvim/dogesyncer/ethdb/mdbx"
 	"github.com/sunvim/dogesyncer/helper/common"
+	"github.com/sunvim/dogesyncer/helper/progress"
 	"github.com/sunvim/dogesyncer/network"
 	"github.com/sunvim/dogesyncer/pkg/server/proto"
+	"github.com/sunvim/dogesyncer/protocol"
+	"github.com/sunvim/dogesyncer/rawdb"
 	"github.com/sunvim/dogesyncer/secrets"
 	"github.com/sunvim/dogesyncer/state"
 	itrie "github.com/sunvim/dogesyncer/state/immutable-trie"
@@ -21,6 +33,15 @@ import (
 	"google.golang.org/grpc"
 )
 
+var (
+	errPrunedDatabase = errors.New("the database was pruned in the full gc mode, it can not switch to the archive mode")
+)
+
+const (
+	// dbSizeReportInterval is how often the size of the database is reported
+	dbSizeReportInterval = 30 * time.Second
+)
+
 type Server struct {
 	logger       hclog.Logger
 	config       *ServerConfig
@@ -40,8 +61,27 @@ type Server struct {
 	// libp2p network
 	network *network.Server
 
+	// block syncer
+	syncer *protocol.Syncer
+
 	// secrets manager
 	secretsManager secrets.SecretsManager
+
+	// state pruner, nil keeps the states of all blocks
+	pruner *itrie.Pruner
+
+	// flat snapshot of the recent states
+	flat *itrie.FlatState
+
+	// restore
+	restoreProgression *progress.ProgressionWrapper
+
+	// metrics
+	serverMetrics    *serverMetrics
+	prometheusServer *http.Server
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
 }
 
 // NewServer creates a new Minimal server, using the passed in configuration
@@ -58,10 +98,22 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 			grpc.MaxRecvMsgSize(common.MaxGrpcMsgSize),
 			grpc.MaxSendMsgSize(common.MaxGrpcMsgSize),
 		),
+		restoreProgression: progress.NewProgressionWrapper(progress.ChainSyncRestore),
+		closeCh:            make(chan struct{}),
 	}
 
 	m.logger.Info("Data dir", "path", config.DataDir)
 
+	m.serverMetrics = metricProvider(
+		"dogechain",
+		strconv.Itoa(config.Chain.Params.ChainID),
+		config.PrometheusAddr != nil,
+	)
+
+	if config.PrometheusAddr != nil {
+		m.prometheusServer = m.startPrometheusServer(config.PrometheusAddr)
+	}
+
 	// Set up the secrets manager
 	if err := m.setupSecretsManager(); err != nil {
 		return nil, fmt.Errorf("failed to set up the secrets manager: %w", err)
@@ -72,7 +124,7 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 		netConfig := config.Network
 		netConfig.DataDir = filepath.Join(m.config.DataDir, "libp2p")
 		netConfig.SecretsManager = m.secretsManager
-		netConfig.Metrics = network.NilMetrics()
+		netConfig.Metrics = m.serverMetrics.network
 
 		network, err := network.NewServer(logger, netConfig)
 		if err != nil {
@@ -83,7 +135,21 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 
 	// create database
 
-	db := mdbx.NewMDBX(filepath.Join(config.DataDir, "blockchain"), logger.Named("mdbx"))
+	db, err := engine.Open(config.DBEngine, filepath.Join(config.DataDir, "blockchain"), logger.Named(config.DBEngine))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %w", err)
+	}
+
+	if err := m.checkGCMode(db); err != nil {
+		return nil, err
+	}
+
+	// only the mdbx data file size is reported
+	if mdbxDB, ok := db.(*mdbx.MdbxDB); ok {
+		m.wg.Add(1)
+
+		go m.reportDBSize(mdbxDB)
+	}
 
 	// start blockchain object
 	stateStorage, err := func() (itrie.Storage, error) {
@@ -96,7 +162,7 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 
 	m.stateStorage = stateStorage
 
-	st := itrie.NewState(stateStorage, nil)
+	st := itrie.NewState(stateStorage, m.serverMetrics.trie)
 	m.state = st
 
 	m.executor = state.NewExecutor(config.Chain.Params, st, logger)
@@ -107,12 +173,26 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 	genesisRoot := m.executor.WriteGenesis(config.Chain.Genesis.Alloc)
 	config.Chain.Genesis.StateRoot = genesisRoot
 
+	// the genesis state is written before the pruner and never pruned
+	if config.GCMode == GCModeFull {
+		m.pruner, err = itrie.NewPruner(logger, st, db, &itrie.PrunerConfig{
+			Retain:             config.PruneRetainBlocks,
+			Checkpoints:        config.PruneCheckpoints,
+			CheckpointInterval: config.PruneCheckpointInterval,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// blockchain
-	m.blockchain, err = blockchain.NewBlockchain(logger, db, config.Chain, m.executor, st)
+	m.blockchain, err = blockchain.NewBlockchain(logger, db, config.Chain, m.executor, st, m.serverMetrics.blockchain)
 	if err != nil {
 		return nil, err
 	}
 
+	m.blockchain.SetPruner(m.pruner)
+
 	m.executor.GetHash = m.blockchain.GetHashHelper
 
 	err = m.blockchain.HandleGenesis()
@@ -120,6 +200,24 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 		return nil, err
 	}
 
+	m.flat, err = itrie.NewFlatState(logger, st, db, m.blockchain.Header().StateRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	// restore archive data before starting
+	if err := m.restoreChain(); err != nil {
+		return nil, err
+	}
+
+	if m.pruner != nil {
+		m.wg.Add(1)
+
+		go m.pruneStates(time.Duration(config.PruneTickSeconds) * time.Second)
+	}
+
+	m.syncer = protocol.NewSyncer(logger, m.network, m.blockchain, config.DataDir, m.serverMetrics.syncer)
+
 	// setup and start grpc server
 	if err := m.setupGRPC(); err != nil {
 		return nil, err
@@ -132,6 +230,41 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 	return m, nil
 }
 
+// checkGCMode records the gc mode in the database, a pruned database can
+// not switch back to the archive mode as its old states are gone
+func (s *Server) checkGCMode(db ethdb.Database) error {
+	stored, ok := rawdb.ReadGCMode(db)
+	if ok && stored == s.config.GCMode {
+		return nil
+	}
+
+	if ok && stored == GCModeFull && s.config.GCMode == GCModeArchive {
+		return errPrunedDatabase
+	}
+
+	if ok {
+		s.logger.Info("switch gc mode", "from", stored, "to", s.config.GCMode)
+	}
+
+	return rawdb.WriteGCMode(db, s.config.GCMode)
+}
+
+// restoreChain writes the blocks of the restore file, if any, to the chain
+func (s *Server) restoreChain() error {
+	if s.config.RestoreFile == nil {
+		return nil
+	}
+
+	s.logger.Info("restore chain", "file", *s.config.RestoreFile)
+
+	return archive.RestoreChain(
+		s.logger.Named("restore"),
+		s.blockchain,
+		*s.config.RestoreFile,
+		s.restoreProgression,
+	)
+}
+
 // setupGRPC sets up the grpc server and listens on tcp
 func (s *Server) setupGRPC() error {
 	proto.RegisterSystemServer(s.grpcServer, &systemService{server: s})
@@ -152,6 +285,48 @@ func (s *Server) setupGRPC() error {
 	return nil
 }
 
+// startPrometheusServer serves the metrics of all sub systems on /metrics
+func (s *Server) startPrometheusServer(listenAddr *net.TCPAddr) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	srv := &http.Server{
+		Addr:              listenAddr.String(),
+		Handler:           mux,
+		ReadHeaderTimeout: 60 * time.Second,
+	}
+
+	go func() {
+		s.logger.Info("Prometheus server started", "addr", listenAddr.String())
+
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Prometheus HTTP server ListenAndServe", "err", err)
+		}
+	}()
+
+	return srv
+}
+
+// reportDBSize updates the database size metric until the server closes
+func (s *Server) reportDBSize(db *mdbx.MdbxDB) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(dbSizeReportInterval)
+	defer ticker.Stop()
+
+	for {
+		if size, err := db.Size(); err == nil {
+			s.serverMetrics.mdbx.Size.Set(float64(size))
+		}
+
+		select {
+		case <-s.closeCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // setupSecretsManager sets up the secrets manager
 func (s *Server) setupSecretsManager() error {
 	secretsManagerConfig := s.config.SecretsManager
@@ -207,8 +382,34 @@ func (s *Server) JoinPeer(rawPeerMultiaddr string) error {
 	return s.network.JoinPeer(rawPeerMultiaddr)
 }
 
+// pruneStates prunes the states which left the retention window until the
+// server closes
+func (s *Server) pruneStates(interval time.Duration) {
+	defer s.wg.Done()
+
+	s.logger.Info("state pruning enabled", "retain", s.config.PruneRetainBlocks, "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.pruner.Prune(s.blockchain.Header().Number); err != nil {
+			s.logger.Error("failed to prune states", "err", err)
+		}
+	}
+}
+
 // Close closes the Minimal server (blockchain, networking, consensus)
 func (s *Server) Close() error {
+	// Stop reading the database before it closes
+	close(s.closeCh)
+	s.wg.Wait()
 
 	s.logger.Info("closing network...")
 	// Close the networking layer
@@ -217,6 +418,11 @@ func (s *Server) Close() error {
 	}
 	s.logger.Info("network close over")
 
+	// Flatten the recent states so the snapshot is reused on restart
+	if err := s.flat.Close(s.blockchain.Header().StateRoot); err != nil {
+		s.logger.Error("failed to close flat state", "err", err)
+	}
+
 	s.logger.Info("closing blockchain...")
 	// Close the state storage
 	if err := s.blockchain.Close(); err != nil {
@@ -224,6 +430,13 @@ func (s *Server) Close() error {
 	}
 	s.logger.Info("close blockchain over")
 
+	// Close the prometheus server
+	if s.prometheusServer != nil {
+		if err := s.prometheusServer.Shutdown(context.Background()); err != nil {
+			s.logger.Error("Prometheus server shutdown error", "err", err)
+		}
+	}
+
 	return nil
 
 }
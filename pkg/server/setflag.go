@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/sunvim/dogesyncer/network"
+	itrie "github.com/sunvim/dogesyncer/state/immutable-trie"
 )
 
 func SetFlags(cmd *cobra.Command) {
@@ -19,6 +20,17 @@ func SetFlags(cmd *cobra.Command) {
 		)
 	}
 
+	// prometheus
+	{
+		cmd.Flags().StringVar(
+			&params.rawConfig.PrometheusAddr,
+			prometheusAddressFlag,
+			"",
+			"the address and port for the prometheus instrumentation service (address:port). "+
+				"If only port is defined (:port) it will bind to 0.0.0.0:port",
+		)
+	}
+
 	// rpc & ws
 
 	{
@@ -32,6 +44,95 @@ func SetFlags(cmd *cobra.Command) {
 			"8545",
 			"rpc port",
 		)
+		cmd.Flags().Uint64Var(
+			&params.rawConfig.JSONRPCBatchRequestLimit,
+			jsonRPCBatchRequestLimitFlag,
+			defaultConfig.JSONRPCBatchRequestLimit,
+			"max length to be considered when handling json-rpc batch requests, 0 means no limit",
+		)
+		cmd.Flags().Uint64Var(
+			&params.rawConfig.JSONRPCBlockRangeLimit,
+			jsonRPCBlockRangeLimitFlag,
+			defaultConfig.JSONRPCBlockRangeLimit,
+			"max block range to be considered when executing json-rpc requests "+
+				"that consider fromBlock/toBlock values (e.g. eth_getLogs), 0 means no limit",
+		)
+		cmd.Flags().BoolVar(
+			&params.rawConfig.JSONRPCEnableUnsafe,
+			jsonRPCEnableUnsafeFlag,
+			false,
+			"serve the json-rpc methods which modify the node (e.g. debug_setHead), the endpoints are not authenticated",
+		)
+		cmd.Flags().BoolVar(
+			&params.rawConfig.EnableWS,
+			enableWSFlag,
+			defaultConfig.EnableWS,
+			"enable the websocket json-rpc endpoint",
+		)
+		cmd.Flags().StringVar(
+			&params.rawConfig.WSPort,
+			JsonrpcWSPort,
+			defaultConfig.WSPort,
+			"websocket rpc port",
+		)
+		cmd.Flags().Uint64Var(
+			&params.rawConfig.HealthMaxBlockLag,
+			healthMaxBlockLagFlag,
+			defaultConfig.HealthMaxBlockLag,
+			"max number of blocks the head lags the best peer while /ready reports ready, 0 means no limit",
+		)
+		cmd.Flags().Uint64Var(
+			&params.rawConfig.HealthMaxHeadAge,
+			healthMaxHeadAgeFlag,
+			defaultConfig.HealthMaxHeadAge,
+			"max age in seconds of the head block while /ready reports ready, 0 means no limit",
+		)
+	}
+
+	// database flags
+	{
+		cmd.Flags().StringVar(
+			&params.rawConfig.DBEngine,
+			dbEngineFlag,
+			defaultConfig.DBEngine,
+			"the database engine: \"mdbx\", the pure go \"leveldb\" or \"memory\" which keeps nothing on disk. "+
+				"An existing database can be copied into another engine with \"db migrate\"",
+		)
+	}
+
+	// state pruning flags
+	{
+		cmd.Flags().StringVar(
+			&params.rawConfig.GCMode,
+			gcModeFlag,
+			defaultConfig.GCMode,
+			"how the states of the blocks are kept, \"archive\" keeps all of them and \"full\" "+
+				"prunes the old ones. A pruned database can not go back to the archive mode",
+		)
+		cmd.Flags().Uint64Var(
+			&params.rawConfig.PruneTickSeconds,
+			pruneTickSecondsFlag,
+			defaultConfig.PruneTickSeconds,
+			"interval in seconds between two state prunings in the full gc mode",
+		)
+		cmd.Flags().Uint64Var(
+			&params.rawConfig.PruneRetainBlocks,
+			pruneRetainBlocksFlag,
+			defaultConfig.PruneRetainBlocks,
+			fmt.Sprintf("number of recent blocks whose states are kept by the state pruning, at least %d", itrie.MinPruneRetain),
+		)
+		cmd.Flags().UintSliceVar(
+			&params.rawConfig.PruneCheckpoints,
+			pruneCheckpointsFlag,
+			defaultConfig.PruneCheckpoints,
+			"block numbers whose states are never pruned",
+		)
+		cmd.Flags().Uint64Var(
+			&params.rawConfig.PruneCheckpointInterval,
+			pruneCheckpointIntervalFlag,
+			defaultConfig.PruneCheckpointInterval,
+			"keep the states of every n-th block when pruning, 0 disables it",
+		)
 	}
 
 	// basic flags
@@ -57,6 +158,13 @@ func SetFlags(cmd *cobra.Command) {
 			"the genesis file used for starting the chain",
 		)
 
+		cmd.Flags().StringVar(
+			&params.rawConfig.RestoreFile,
+			restoreFlag,
+			"",
+			"the path to the archive blockchain data to restore on initialization",
+		)
+
 	}
 
 	// block flags
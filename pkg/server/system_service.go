@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/sunvim/dogesyncer/blockchain"
@@ -42,6 +43,35 @@ func (s *systemService) GetStatus(ctx context.Context, req *empty.Empty) (*proto
 	return status, nil
 }
 
+// SyncStatus returns the progress of the catch-up with the network
+func (s *systemService) SyncStatus(ctx context.Context, req *empty.Empty) (*proto.SyncStatusResponse, error) {
+	p := s.server.syncer.GetSyncProgression()
+	if p == nil {
+		return &proto.SyncStatusResponse{}, nil
+	}
+
+	now := time.Now()
+
+	return &proto.SyncStatusResponse{
+		Syncing:         p.CurrentBlock < p.HighestBlock,
+		SyncType:        string(p.SyncType),
+		StartingBlock:   p.StartingBlock,
+		CurrentBlock:    p.CurrentBlock,
+		HighestBlock:    p.HighestBlock,
+		BlocksPerSecond: p.Rate(now),
+		EtaSeconds:      uint64(p.ETA(now) / time.Second),
+	}, nil
+}
+
+// SetHead rewinds the chain to the canonical block of the number
+func (s *systemService) SetHead(ctx context.Context, req *proto.SetHeadRequest) (*empty.Empty, error) {
+	if err := s.server.blockchain.SetHead(req.Number); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
 // Subscribe implements the blockchain event subscription service
 func (s *systemService) Subscribe(req *empty.Empty, stream proto.System_SubscribeServer) error {
 	sub := s.server.blockchain.SubscribeEvents()
@@ -52,6 +82,10 @@ func (s *systemService) Subscribe(req *empty.Empty, stream proto.System_Subscrib
 			break
 		}
 
+		if evnt.Type == blockchain.EventFork {
+			continue
+		}
+
 		pEvent := &proto.BlockchainEvent{
 			Added:   []*proto.BlockchainEvent_Header{},
 			Removed: []*proto.BlockchainEvent_Header{},
@@ -0,0 +1,48 @@
+package protocol
+
+import (
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	prometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics represents the syncer metrics
+type Metrics struct {
+	// Height of the highest connected peer
+	BestPeerHeight metrics.Gauge
+}
+
+// GetPrometheusMetrics return the syncer metrics instance
+func GetPrometheusMetrics(namespace string, labelsWithValues ...string) *Metrics {
+	labels := []string{}
+
+	for i := 0; i < len(labelsWithValues); i += 2 {
+		labels = append(labels, labelsWithValues[i])
+	}
+
+	return &Metrics{
+		BestPeerHeight: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "syncer",
+			Name:      "best_peer_height",
+			Help:      "Height of the highest connected peer",
+		}, labels).With(labelsWithValues...),
+	}
+}
+
+// NilMetrics will return the non operational syncer metrics
+func NilMetrics() *Metrics {
+	return &Metrics{
+		BestPeerHeight: discard.NewGauge(),
+	}
+}
+
+// NewDummyMetrics will return the no nil syncer metrics
+func NewDummyMetrics(metrics *Metrics) *Metrics {
+	if metrics != nil {
+		return metrics
+	}
+
+	return NilMetrics()
+}
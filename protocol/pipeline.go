@@ -0,0 +1,465 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/sunvim/dogesyncer/blockchain"
+	"github.com/sunvim/dogesyncer/types"
+	grpccodes "google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+const (
+	// maxSyncPeers is the number of peers downloading windows concurrently
+	maxSyncPeers = 8
+	// maxSyncAheadBlocks bounds how far downloads may run ahead of the writer
+	maxSyncAheadBlocks = 32 * maxSkeletonHeadersAmount
+	// syncPeerBackoff is how long a failing peer is skipped for new windows
+	syncPeerBackoff = 5 * time.Second
+	// syncBadPeerBackoff is how long a peer which served a block failing
+	// to write is skipped for new windows
+	syncBadPeerBackoff = time.Minute
+	// maxBlockWriteFailures is how many times writing the same height may
+	// fail, with blocks from different peers, before the sync gives up
+	maxBlockWriteFailures = 3
+	// syncScheduleInterval is how often idle peers are looked up again
+	syncScheduleInterval = time.Second
+)
+
+var (
+	errNoSyncPeers         = errors.New("no peers to sync from")
+	errEmptyWindowResponse = errors.New("peer returned no blocks")
+	errInvalidBlockNumber  = errors.New("peer returned unexpected block number")
+	errInvalidParentHash   = errors.New("peer returned unlinked blocks")
+	errHeadMoved           = errors.New("local head moved below the sync")
+)
+
+// syncWindow is a range of block heights [from, to] fetched from one peer
+type syncWindow struct {
+	from uint64
+	to   uint64
+}
+
+func (w syncWindow) size() uint64 {
+	return w.to - w.from + 1
+}
+
+// windowResult is the outcome of fetching one window
+type windowResult struct {
+	window syncWindow
+	peer   peer.ID
+	blocks []*types.Block
+	err    error
+}
+
+// blockWriteError is returned by the writer if a block fails to write, it
+// records the peer which delivered the block
+type blockWriteError struct {
+	number uint64
+	peer   peer.ID
+	err    error
+}
+
+func (e *blockWriteError) Error() string {
+	return fmt.Sprintf("write block %d: %v", e.number, e.err)
+}
+
+func (e *blockWriteError) Unwrap() error {
+	return e.err
+}
+
+// blockOrigins records which peer delivered each queued block
+type blockOrigins struct {
+	lock  sync.Mutex
+	peers map[uint64]peer.ID
+}
+
+func newBlockOrigins() *blockOrigins {
+	return &blockOrigins{
+		peers: make(map[uint64]peer.ID),
+	}
+}
+
+// add records the peer of the blocks, the first delivery of a height wins
+// just like in the queue
+func (o *blockOrigins) add(p peer.ID, blocks []*types.Block) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for _, block := range blocks {
+		if _, ok := o.peers[block.Number()]; !ok {
+			o.peers[block.Number()] = p
+		}
+	}
+}
+
+// take returns and forgets the peer of the height
+func (o *blockOrigins) take(number uint64) peer.ID {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	p := o.peers[number]
+	delete(o.peers, number)
+
+	return p
+}
+
+// drop forgets all the heights delivered by the peer and returns them
+func (o *blockOrigins) drop(p peer.ID) map[uint64]struct{} {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	numbers := make(map[uint64]struct{})
+
+	for number, origin := range o.peers {
+		if origin == p {
+			numbers[number] = struct{}{}
+			delete(o.peers, number)
+		}
+	}
+
+	return numbers
+}
+
+// windowScheduler hands out the windows of a sync range in ascending order,
+// with re-queued windows taking precedence over new ones
+type windowScheduler struct {
+	next   uint64
+	target uint64
+	retry  []syncWindow
+}
+
+func newWindowScheduler(from, target uint64) *windowScheduler {
+	return &windowScheduler{
+		next:   from,
+		target: target,
+	}
+}
+
+// pop returns the lowest pending window, new windows are only created
+// up to limit
+func (ws *windowScheduler) pop(limit uint64) (syncWindow, bool) {
+	if len(ws.retry) > 0 {
+		w := ws.retry[0]
+		ws.retry = ws.retry[1:]
+
+		return w, true
+	}
+
+	if ws.next > ws.target || ws.next > limit {
+		return syncWindow{}, false
+	}
+
+	w := syncWindow{
+		from: ws.next,
+		to:   ws.next + maxSkeletonHeadersAmount - 1,
+	}
+	if w.to > ws.target {
+		w.to = ws.target
+	}
+
+	ws.next = w.to + 1
+
+	return w, true
+}
+
+// push re-queues a window which has not been (fully) fetched
+func (ws *windowScheduler) push(w syncWindow) {
+	ws.retry = append(ws.retry, w)
+	sort.Slice(ws.retry, func(i, j int) bool {
+		return ws.retry[i].from < ws.retry[j].from
+	})
+}
+
+// pushHeights re-queues the given heights as windows of consecutive
+// heights
+func (ws *windowScheduler) pushHeights(numbers []uint64) {
+	sort.Slice(numbers, func(i, j int) bool {
+		return numbers[i] < numbers[j]
+	})
+
+	for i := 0; i < len(numbers); {
+		w := syncWindow{from: numbers[i], to: numbers[i]}
+
+		for i++; i < len(numbers) && numbers[i] == w.to+1 && w.size() < maxSkeletonHeadersAmount; i++ {
+			w.to = numbers[i]
+		}
+
+		ws.push(w)
+	}
+}
+
+// syncRange downloads the blocks [from, target] from several peers
+// concurrently and writes them in order. Download and execution overlap,
+// a failing peer only re-queues its own window. A block failing to write
+// drops all the queued blocks of the peer which delivered it, those heights
+// are fetched again from other peers.
+func (s *Syncer) syncRange(ctx context.Context, from, target uint64) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		queue     = NewPriorityQueue(maxSyncAheadBlocks, false)
+		scheduler = newWindowScheduler(from, target)
+		readyCh   = make(chan struct{}, 1)
+		writerCh  = make(chan error, 1)
+		resultCh  = make(chan *windowResult, maxSyncPeers)
+		origins   = newBlockOrigins()
+		busy      = make(map[peer.ID]struct{})
+		backoff   = make(map[peer.ID]time.Time)
+		failures  = make(map[uint64]int)
+		written   atomic.Uint64
+	)
+
+	defer queue.Dispose()
+
+	written.Store(from - 1)
+
+	startWriter := func(next uint64) {
+		go func() {
+			writerCh <- s.writeBlocks(ctx, queue, origins, next, target, readyCh, &written)
+		}()
+	}
+
+	startWriter(from)
+
+	ticker := time.NewTicker(syncScheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		// hand out windows to idle peers
+		height := written.Load()
+		limit := height + maxSyncAheadBlocks
+
+		for _, p := range s.TakePeerByHeight(height, maxSyncPeers) {
+			if _, ok := busy[p.ID()]; ok || p.IsClosed() {
+				continue
+			}
+
+			if until, ok := backoff[p.ID()]; ok && time.Now().Before(until) {
+				continue
+			}
+
+			w, ok := scheduler.pop(limit)
+			if !ok {
+				break
+			}
+
+			if p.Number() < w.from {
+				scheduler.push(w)
+
+				continue
+			}
+
+			busy[p.ID()] = struct{}{}
+
+			go s.fetchWindow(ctx, p.ID(), w, resultCh)
+		}
+
+		if len(busy) == 0 && len(s.TakePeerByHeight(height, 1)) == 0 {
+			return errNoSyncPeers
+		}
+
+		select {
+		case <-s.stopSync:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-writerCh:
+			var writeErr *blockWriteError
+			if !errors.As(err, &writeErr) {
+				return err
+			}
+
+			failures[writeErr.number]++
+			if failures[writeErr.number] >= maxBlockWriteFailures {
+				return err
+			}
+
+			s.logger.Warn("drop blocks of peer", "peer", writeErr.peer, "err", err)
+
+			// fetch the failed height and all the queued blocks of the peer
+			// from other peers
+			heights := []uint64{writeErr.number}
+
+			if writeErr.peer != "" {
+				backoff[writeErr.peer] = time.Now().Add(syncBadPeerBackoff)
+
+				dropped := origins.drop(writeErr.peer)
+				for _, block := range queue.Remove(func(b *types.Block) bool {
+					_, ok := dropped[b.Number()]
+
+					return ok
+				}) {
+					heights = append(heights, block.Number())
+				}
+			}
+
+			scheduler.pushHeights(heights)
+			startWriter(writeErr.number)
+		case <-ticker.C:
+		case res := <-resultCh:
+			delete(busy, res.peer)
+
+			if res.err != nil {
+				s.logger.Debug("fetch window", "peer", res.peer, "from", res.window.from, "to", res.window.to, "err", res.err)
+
+				if isResourceExhausted(res.err) && res.window.size() > 1 {
+					// the window does not fit into one message, split it up
+					mid := res.window.from + res.window.size()/2
+					scheduler.push(syncWindow{from: res.window.from, to: mid - 1})
+					scheduler.push(syncWindow{from: mid, to: res.window.to})
+				} else {
+					backoff[res.peer] = time.Now().Add(syncPeerBackoff)
+					scheduler.push(res.window)
+				}
+
+				continue
+			}
+
+			if until, ok := backoff[res.peer]; ok && time.Now().Before(until) {
+				// fetched before the peer served a bad block
+				scheduler.push(res.window)
+
+				continue
+			}
+
+			delete(backoff, res.peer)
+
+			if fetched := uint64(len(res.blocks)); fetched < res.window.size() {
+				// the peer stopped early, fetch the rest elsewhere
+				scheduler.push(syncWindow{from: res.window.from + fetched, to: res.window.to})
+			}
+
+			origins.add(res.peer, res.blocks)
+
+			if err := queue.Put(res.blocks...); err != nil {
+				return err
+			}
+
+			select {
+			case readyCh <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// fetchWindow requests the blocks of a window from the given peer and
+// checks that they form the expected sequence
+func (s *Syncer) fetchWindow(ctx context.Context, peerID peer.ID, w syncWindow, resultCh chan<- *windowResult) {
+	sk := &skeleton{
+		server: s.server,
+		amount: int64(w.size()),
+	}
+
+	blocks, err := sk.GetBlocks(ctx, peerID, w.from)
+	if err == nil {
+		err = validateWindow(w, blocks)
+	}
+
+	if err != nil {
+		blocks = nil
+	}
+
+	select {
+	case resultCh <- &windowResult{window: w, peer: peerID, blocks: blocks, err: err}:
+	case <-ctx.Done():
+	}
+}
+
+// validateWindow makes sure the blocks are a non-empty, linked sequence
+// starting at the beginning of the window
+func validateWindow(w syncWindow, blocks []*types.Block) error {
+	if len(blocks) == 0 {
+		return errEmptyWindowResponse
+	}
+
+	if uint64(len(blocks)) > w.size() {
+		return fmt.Errorf("%w: got %d blocks for %d heights", errInvalidBlockNumber, len(blocks), w.size())
+	}
+
+	for i, block := range blocks {
+		if expected := w.from + uint64(i); block.Number() != expected {
+			return fmt.Errorf("%w: expected %d, got %d", errInvalidBlockNumber, expected, block.Number())
+		}
+
+		if i > 0 && block.ParentHash() != blocks[i-1].Hash() {
+			return fmt.Errorf("%w: block %d", errInvalidParentHash, block.Number())
+		}
+	}
+
+	return nil
+}
+
+// writeBlocks is the single writer of the sync pipeline, it takes the blocks
+// from the queue in order and executes them until target is written. It
+// stops at the first block failing to write with a blockWriteError, or with
+// errHeadMoved if the local head was rewound below the written blocks, e.g.
+// by debug_setHead, and the queued blocks no longer link to it.
+func (s *Syncer) writeBlocks(
+	ctx context.Context,
+	queue *PriorityQueue,
+	origins *blockOrigins,
+	next, target uint64,
+	readyCh <-chan struct{},
+	written *atomic.Uint64,
+) error {
+	for next <= target {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-readyCh:
+		}
+
+		for next <= target {
+			block := queue.Peek()
+			if block == nil || block.Number() > next {
+				break
+			}
+
+			items, err := queue.Get(1)
+			if err != nil {
+				return err
+			}
+
+			if items[0].Number() < next {
+				// already written from an overlapping window
+				origins.take(items[0].Number())
+
+				continue
+			}
+
+			origin := origins.take(next)
+
+			if err := s.blockchain.WriteBlock(items[0]); err != nil {
+				if errors.Is(err, blockchain.ErrParentNotFound) && s.blockchain.Header().Number+1 < next {
+					return fmt.Errorf("%w: head %d, next %d", errHeadMoved, s.blockchain.Header().Number, next)
+				}
+
+				return &blockWriteError{number: next, peer: origin, err: err}
+			}
+
+			written.Store(next)
+			next++
+		}
+	}
+
+	return nil
+}
+
+func isResourceExhausted(err error) bool {
+	if rpcErr, ok := grpcstatus.FromError(err); ok {
+		// the data size exceeds grpc server/client message size
+		return rpcErr.Code() == grpccodes.ResourceExhausted
+	}
+
+	return false
+}
@@ -0,0 +1,345 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cornelk/hashmap"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/blockchain"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// writerStore records the blocks written by the sync pipeline, blocks
+// with an extra data are rejected
+type writerStore struct {
+	blockchainShim
+
+	written []uint64
+}
+
+func (w *writerStore) WriteBlock(block *types.Block) error {
+	if len(block.Header.ExtraData) > 0 {
+		return errors.New("bad block")
+	}
+
+	w.written = append(w.written, block.Number())
+
+	return nil
+}
+
+// chainStore only writes the blocks extending its head, the head can be
+// rewound with SetHead while the pipeline writes
+type chainStore struct {
+	blockchainShim
+
+	lock    sync.Mutex
+	headers []*types.Header
+}
+
+func newChainStore() *chainStore {
+	return &chainStore{
+		headers: []*types.Header{{Number: 0}},
+	}
+}
+
+func (c *chainStore) Header() *types.Header {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.headers[len(c.headers)-1]
+}
+
+func (c *chainStore) WriteBlock(block *types.Block) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if head := c.headers[len(c.headers)-1]; block.ParentHash() != head.Hash {
+		return fmt.Errorf("%w: %s", blockchain.ErrParentNotFound, block.ParentHash())
+	}
+
+	c.headers = append(c.headers, block.Header)
+
+	return nil
+}
+
+func (c *chainStore) SetHead(number uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.headers = c.headers[:number+1]
+}
+
+func newTestBlock(number uint64) *types.Block {
+	return &types.Block{
+		Header: &types.Header{Number: number},
+	}
+}
+
+// newTestChain returns linked blocks of the heights [from, to]
+func newTestChain(from, to uint64) []*types.Block {
+	blocks := make([]*types.Block, 0, to-from+1)
+	parent := types.Hash{}
+
+	for number := from; number <= to; number++ {
+		header := &types.Header{
+			Number:     number,
+			ParentHash: parent,
+			Hash:       types.BytesToHash([]byte{byte(from), byte(number)}),
+		}
+		blocks = append(blocks, &types.Block{Header: header})
+		parent = header.Hash
+	}
+
+	return blocks
+}
+
+func TestWindowScheduler(t *testing.T) {
+	ws := newWindowScheduler(1, 400)
+
+	w, ok := ws.pop(1000)
+	assert.True(t, ok)
+	assert.Equal(t, syncWindow{1, maxSkeletonHeadersAmount}, w)
+
+	w, ok = ws.pop(1000)
+	assert.True(t, ok)
+	assert.Equal(t, syncWindow{maxSkeletonHeadersAmount + 1, 2 * maxSkeletonHeadersAmount}, w)
+
+	// new windows are not created beyond the limit
+	_, ok = ws.pop(2 * maxSkeletonHeadersAmount)
+	assert.False(t, ok)
+
+	// re-queued windows come first, lowest first
+	ws.push(syncWindow{100, 190})
+	ws.push(syncWindow{10, 20})
+
+	w, _ = ws.pop(0)
+	assert.Equal(t, syncWindow{10, 20}, w)
+
+	w, _ = ws.pop(0)
+	assert.Equal(t, syncWindow{100, 190}, w)
+
+	// the last window is cut at the target
+	w, ok = ws.pop(1000)
+	assert.True(t, ok)
+	assert.Equal(t, syncWindow{2*maxSkeletonHeadersAmount + 1, 400}, w)
+
+	_, ok = ws.pop(1000)
+	assert.False(t, ok)
+}
+
+func TestValidateWindow(t *testing.T) {
+	w := syncWindow{10, 12}
+
+	assert.ErrorIs(t, validateWindow(w, nil), errEmptyWindowResponse)
+	assert.NoError(t, validateWindow(w, newTestChain(10, 11)))
+	assert.ErrorIs(t, validateWindow(w, []*types.Block{newTestBlock(11)}), errInvalidBlockNumber)
+	assert.ErrorIs(t, validateWindow(w, []*types.Block{
+		newTestBlock(10), newTestBlock(11), newTestBlock(12), newTestBlock(13),
+	}), errInvalidBlockNumber)
+
+	// the numbers match, but the blocks are not linked
+	unlinked := newTestChain(10, 12)
+	unlinked[2] = newTestChain(12, 12)[0]
+	assert.ErrorIs(t, validateWindow(w, unlinked), errInvalidParentHash)
+}
+
+func TestWindowSchedulerPushHeights(t *testing.T) {
+	ws := newWindowScheduler(1, 0)
+
+	ws.pushHeights([]uint64{7, 3, 4, 5, 9})
+
+	for _, expected := range []syncWindow{{3, 5}, {7, 7}, {9, 9}} {
+		w, ok := ws.pop(0)
+		assert.True(t, ok)
+		assert.Equal(t, expected, w)
+	}
+
+	_, ok := ws.pop(0)
+	assert.False(t, ok)
+}
+
+func TestPriorityQueueRemove(t *testing.T) {
+	queue := NewPriorityQueue(16, false)
+	assert.NoError(t, queue.Put(newTestChain(1, 6)...))
+
+	removed := queue.Remove(func(b *types.Block) bool {
+		return b.Number()%2 == 0
+	})
+	assert.Len(t, removed, 3)
+	assert.Equal(t, 3, queue.Len())
+
+	// removed heights may be queued again
+	assert.NoError(t, queue.Put(newTestBlock(2)))
+
+	items, err := queue.Get(4)
+	assert.NoError(t, err)
+
+	numbers := make([]uint64, 0, len(items))
+	for _, item := range items {
+		numbers = append(numbers, item.Number())
+	}
+
+	assert.Equal(t, []uint64{1, 2, 3, 5}, numbers)
+}
+
+func TestSyncer_WriteBlocksInOrder(t *testing.T) {
+	store := &writerStore{}
+	s := &Syncer{blockchain: store}
+
+	var (
+		queue   = NewPriorityQueue(16, false)
+		readyCh = make(chan struct{}, 1)
+		errCh   = make(chan error, 1)
+		written atomic.Uint64
+	)
+
+	go func() {
+		errCh <- s.writeBlocks(context.Background(), queue, newBlockOrigins(), 1, 6, readyCh, &written)
+	}()
+
+	// windows arrive out of order and overlap
+	for _, window := range [][]uint64{{4, 5, 6}, {2, 3}, {1, 2}} {
+		blocks := make([]*types.Block, 0, len(window))
+		for _, n := range window {
+			blocks = append(blocks, newTestBlock(n))
+		}
+
+		assert.NoError(t, queue.Put(blocks...))
+		readyCh <- struct{}{}
+	}
+
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, store.written)
+	assert.Equal(t, uint64(6), written.Load())
+}
+
+func TestSyncer_WriteBlocksFailure(t *testing.T) {
+	store := &writerStore{}
+	s := &Syncer{blockchain: store}
+
+	var (
+		queue   = NewPriorityQueue(16, false)
+		origins = newBlockOrigins()
+		readyCh = make(chan struct{}, 1)
+		written atomic.Uint64
+	)
+
+	good, bad := newTestChain(1, 2), newTestChain(3, 4)
+	bad[0].Header.ExtraData = []byte{1}
+
+	origins.add("good", good)
+	origins.add("bad", bad)
+	assert.NoError(t, queue.Put(append(good, bad...)...))
+	readyCh <- struct{}{}
+
+	err := s.writeBlocks(context.Background(), queue, origins, 1, 4, readyCh, &written)
+
+	var writeErr *blockWriteError
+	assert.ErrorAs(t, err, &writeErr)
+	assert.Equal(t, uint64(3), writeErr.number)
+	assert.Equal(t, peer.ID("bad"), writeErr.peer)
+	assert.Equal(t, uint64(2), written.Load())
+
+	// the rest of the bad peer is still queued
+	assert.Equal(t, map[uint64]struct{}{4: {}}, origins.drop("bad"))
+	assert.Empty(t, origins.drop("good"))
+}
+
+func TestSyncer_WriteBlocksSetHead(t *testing.T) {
+	store := newChainStore()
+	s := &Syncer{blockchain: store}
+
+	var (
+		queue   = NewPriorityQueue(16, false)
+		readyCh = make(chan struct{}, 1)
+		errCh   = make(chan error, 1)
+		written atomic.Uint64
+	)
+
+	chain := newTestChain(1, 6)
+
+	go func() {
+		errCh <- s.writeBlocks(context.Background(), queue, newBlockOrigins(), 1, 6, readyCh, &written)
+	}()
+
+	assert.NoError(t, queue.Put(chain[:3]...))
+	readyCh <- struct{}{}
+
+	assert.Eventually(t, func() bool {
+		return written.Load() == 3
+	}, time.Second, time.Millisecond)
+
+	// rewind while the rest of the range is downloaded
+	store.SetHead(1)
+
+	assert.NoError(t, queue.Put(chain[3:]...))
+	readyCh <- struct{}{}
+
+	// the peer is not blamed, the sync restarts from the new head
+	err := <-errCh
+	assert.ErrorIs(t, err, errHeadMoved)
+
+	var writeErr *blockWriteError
+	assert.False(t, errors.As(err, &writeErr))
+	assert.Equal(t, uint64(1), store.Header().Number)
+}
+
+func TestSyncer_WriteBlocksUnknownParent(t *testing.T) {
+	store := newChainStore()
+	s := &Syncer{blockchain: store}
+
+	var (
+		queue   = NewPriorityQueue(16, false)
+		origins = newBlockOrigins()
+		readyCh = make(chan struct{}, 1)
+		written atomic.Uint64
+	)
+
+	// a block of another fork at the next height is the fault of its peer
+	chain := newTestChain(1, 1)
+	chain[0].Header.ParentHash = types.StringToHash("0x1")
+
+	origins.add("fork", chain)
+	assert.NoError(t, queue.Put(chain...))
+	readyCh <- struct{}{}
+
+	err := s.writeBlocks(context.Background(), queue, origins, 1, 1, readyCh, &written)
+
+	var writeErr *blockWriteError
+	assert.ErrorAs(t, err, &writeErr)
+	assert.Equal(t, peer.ID("fork"), writeErr.peer)
+	assert.ErrorIs(t, err, blockchain.ErrParentNotFound)
+}
+
+func TestSyncer_TakePeerByHeight(t *testing.T) {
+	s := &Syncer{
+		logger: hclog.NewNullLogger(),
+		peers:  hashmap.New[peer.ID, *SyncPeer](),
+	}
+
+	for i, number := range []uint64{5, 10, 20, 30} {
+		id := peer.ID(rune('a' + i))
+		s.peers.Set(id, &SyncPeer{
+			peer:   id,
+			status: &Status{Number: number, Difficulty: big.NewInt(0)},
+		})
+	}
+
+	assert.Len(t, s.TakePeerByHeight(8, 10), 3)
+	assert.Len(t, s.TakePeerByHeight(8, 2), 2)
+	assert.Len(t, s.TakePeerByHeight(30, 10), 0)
+	assert.Len(t, s.TakePeerByHeight(0, 0), 0)
+
+	for _, p := range s.TakePeerByHeight(15, 10) {
+		assert.Greater(t, p.Number(), uint64(15))
+	}
+}
@@ -241,6 +241,35 @@ func (pq *PriorityQueue) Peek() *types.Block {
 	return nil
 }
 
+// Remove drops the items matching the filter from the queue and returns
+// them.
+func (pq *PriorityQueue) Remove(filter func(*types.Block) bool) []*types.Block {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	var (
+		removed []*types.Block
+		kept    = make(priorityItems, 0, len(pq.items))
+	)
+
+	for _, item := range pq.items {
+		if !filter(item) {
+			kept.push(item)
+
+			continue
+		}
+
+		removed = append(removed, item)
+		if !pq.allowDuplicates {
+			pq.itemMap.Del(item.Number())
+		}
+	}
+
+	pq.items = kept
+
+	return removed
+}
+
 func (pq *PriorityQueue) Latest() uint64 {
 	var num uint64
 	pq.lock.Lock()
@@ -7,6 +7,7 @@ import (
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/sunvim/dogesyncer/helper/common"
 	"github.com/sunvim/dogesyncer/network/grpc"
 	"github.com/sunvim/dogesyncer/protocol/proto"
 	"github.com/sunvim/dogesyncer/types"
@@ -18,6 +19,12 @@ var (
 	errInvalidHeadersRequest = errors.New("cannot provide both a number and a hash")
 	errNilRawRequest         = errors.New("notify request raw is nil")
 	errNilStatusRequest      = errors.New("notify request status is nil")
+	errInvalidBlocksRequest  = errors.New("invalid blocks request range")
+
+	// maxBlocksResponseSize is the cutoff for the encoded blocks of a single
+	// GetBlocks response, leaving headroom below the grpc message limit for
+	// the protobuf framing
+	maxBlocksResponseSize = common.MaxGrpcMsgSize - 1024*1024
 )
 
 // serviceV1 is the GRPC server implementation for the v1 protocol
@@ -94,7 +101,10 @@ func (s *serviceV1) GetObjectsByHash(_ context.Context, req *proto.HashRequest)
 		var obj rlpObject
 
 		if req.Type == proto.HashRequest_BODIES {
-			obj, _ = s.store.GetBodyByHash(hash)
+			// avoid a typed nil in the interface for missing bodies
+			if body, ok := s.store.GetBodyByHash(hash); ok {
+				obj = body
+			}
 		} else if req.Type == proto.HashRequest_RECEIPTS {
 			var raw []*types.Receipt
 			raw, err = s.store.GetReceiptsByHash(hash)
@@ -190,6 +200,87 @@ func (s *serviceV1) GetHeaders(_ context.Context, req *proto.GetHeadersRequest)
 	return resp, nil
 }
 
+// GetBlocks implements the V1Server interface. It returns the canonical blocks
+// in the range [from, to], stopping early at the local head, at
+// maxSkeletonHeadersAmount blocks or when the response would grow beyond
+// maxBlocksResponseSize.
+func (s *serviceV1) GetBlocks(_ context.Context, req *proto.GetBlocksRequest) (*proto.GetBlocksResponse, error) {
+	if req.From > req.To {
+		return nil, errInvalidBlocksRequest
+	}
+
+	to := req.To
+	if to-req.From >= maxSkeletonHeadersAmount {
+		to = req.From + maxSkeletonHeadersAmount - 1
+	}
+
+	head := s.store.Header()
+	if head == nil || req.From > head.Number {
+		return &proto.GetBlocksResponse{From: req.From}, nil
+	}
+
+	if to > head.Number {
+		to = head.Number
+	}
+
+	var (
+		blocks = make([][]byte, 0, to-req.From+1)
+		size   int
+	)
+
+	for number := req.From; number <= to; number++ {
+		header, ok := s.store.GetHeaderByNumber(number)
+		if !ok {
+			break
+		}
+
+		body, ok := s.store.GetBodyByHash(header.Hash)
+		if !ok {
+			break
+		}
+
+		block := &types.Block{
+			Header:       header,
+			Transactions: body.Transactions,
+			Uncles:       body.Uncles,
+		}
+
+		data := block.MarshalRLPTo(nil)
+
+		// always send at least one block, so the peer can make progress
+		if len(blocks) > 0 && size+len(data) > maxBlocksResponseSize {
+			break
+		}
+
+		blocks = append(blocks, data)
+		size += len(data)
+	}
+
+	resp := &proto.GetBlocksResponse{
+		From:   req.From,
+		Blocks: blocks,
+	}
+
+	if len(blocks) > 0 {
+		resp.To = req.From + uint64(len(blocks)) - 1
+	}
+
+	return resp, nil
+}
+
+// GetStatus implements the V1Server interface
+func (s *serviceV1) GetStatus(_ context.Context, _ *empty.Empty) (*proto.SyncPeerStatus, error) {
+	var number uint64
+
+	if header := s.store.Header(); header != nil {
+		number = header.Number
+	}
+
+	return &proto.SyncPeerStatus{
+		Number: number,
+	}, nil
+}
+
 // Helper functions to decode responses from the grpc layer
 func getBodies(ctx context.Context, clt proto.V1Client, hashes []types.Hash) ([]*types.Body, error) {
 	input := make([]string, 0, len(hashes))
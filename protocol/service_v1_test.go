@@ -0,0 +1,131 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/protocol/proto"
+	"github.com/sunvim/dogesyncer/types"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// mockStore serves a fixed canonical chain, the remaining blockchainShim
+// methods are left unimplemented
+type mockStore struct {
+	blockchainShim
+
+	headers []*types.Header
+	bodies  map[types.Hash]*types.Body
+}
+
+func newMockStore(length int, txsPerBlock int, input []byte) *mockStore {
+	m := &mockStore{
+		headers: make([]*types.Header, length),
+		bodies:  make(map[types.Hash]*types.Body, length),
+	}
+
+	for i := 0; i < length; i++ {
+		h := &types.Header{Number: uint64(i)}
+		h.Hash = types.BytesToHash([]byte{byte(i >> 8), byte(i), 1})
+
+		body := &types.Body{}
+		for j := 0; j < txsPerBlock; j++ {
+			body.Transactions = append(body.Transactions, &types.Transaction{
+				Nonce: uint64(j),
+				Input: input,
+			})
+		}
+
+		m.headers[i] = h
+		m.bodies[h.Hash] = body
+	}
+
+	return m
+}
+
+func (m *mockStore) Header() *types.Header {
+	return m.headers[len(m.headers)-1]
+}
+
+func (m *mockStore) GetHeaderByNumber(n uint64) (*types.Header, bool) {
+	if n >= uint64(len(m.headers)) {
+		return nil, false
+	}
+
+	return m.headers[n], true
+}
+
+func (m *mockStore) GetBodyByHash(hash types.Hash) (*types.Body, bool) {
+	body, ok := m.bodies[hash]
+
+	return body, ok
+}
+
+func TestServiceV1_GetBlocks(t *testing.T) {
+	store := newMockStore(300, 1, nil)
+	service := &serviceV1{store: store}
+
+	cases := []struct {
+		name     string
+		from, to uint64
+		expected int
+	}{
+		{"single block", 5, 5, 1},
+		{"inclusive range", 10, 19, 10},
+		{"capped by head", 290, 400, 10},
+		{"capped by max amount", 0, 299, maxSkeletonHeadersAmount},
+		{"beyond head", 400, 410, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			resp, err := service.GetBlocks(context.Background(), &proto.GetBlocksRequest{
+				From: c.from,
+				To:   c.to,
+			})
+			assert.NoError(t, err)
+			assert.Len(t, resp.Blocks, c.expected)
+
+			for i, raw := range resp.Blocks {
+				block := new(types.Block)
+				assert.NoError(t, block.UnmarshalRLP(raw))
+				assert.Equal(t, c.from+uint64(i), block.Number())
+				assert.Len(t, block.Transactions, 1)
+			}
+		})
+	}
+
+	_, err := service.GetBlocks(context.Background(), &proto.GetBlocksRequest{From: 2, To: 1})
+	assert.ErrorIs(t, err, errInvalidBlocksRequest)
+}
+
+func TestServiceV1_GetBlocksSizeCutoff(t *testing.T) {
+	// every block is roughly 4MB, so only a few fit in one message
+	store := newMockStore(10, 4, make([]byte, 1024*1024))
+	service := &serviceV1{store: store}
+
+	resp, err := service.GetBlocks(context.Background(), &proto.GetBlocksRequest{
+		From: 0,
+		To:   9,
+	})
+	assert.NoError(t, err)
+
+	size := 0
+	for _, raw := range resp.Blocks {
+		size += len(raw)
+	}
+
+	assert.NotEmpty(t, resp.Blocks)
+	assert.Less(t, len(resp.Blocks), 10)
+	assert.LessOrEqual(t, size, maxBlocksResponseSize)
+	assert.Equal(t, uint64(len(resp.Blocks)-1), resp.To)
+}
+
+func TestServiceV1_GetStatus(t *testing.T) {
+	service := &serviceV1{store: newMockStore(42, 0, nil)}
+
+	status, err := service.GetStatus(context.Background(), &empty.Empty{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(41), status.Number)
+}
@@ -114,7 +114,8 @@ func (s *skeleton) getBlocksFromPeer(
 	return nil
 }
 
-// GetBlocks returns a stream of blocks from given height to peer's latest
+// GetBlocks returns at most amount blocks from given height, the peer may
+// return less of them if it does not have them or they exceed the message size
 func (s *skeleton) GetBlocks(
 	ctx context.Context,
 	peerID peer.ID,
@@ -130,7 +131,7 @@ func (s *skeleton) GetBlocks(
 
 	rsp, err := clt.GetBlocks(ctx, &proto.GetBlocksRequest{
 		From: from,
-		To:   from + uint64(s.amount),
+		To:   from + uint64(s.amount) - 1,
 	})
 	if err != nil {
 		return nil, err
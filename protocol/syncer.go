@@ -19,8 +19,6 @@ import (
 	libp2pGrpc "github.com/sunvim/dogesyncer/network/grpc"
 	"github.com/sunvim/dogesyncer/protocol/proto"
 	"github.com/sunvim/dogesyncer/types"
-	grpccodes "google.golang.org/grpc/codes"
-	grpcstatus "google.golang.org/grpc/status"
 	anypb "google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -63,6 +61,7 @@ type Syncer struct {
 
 	server          *network.Server
 	syncProgression *progress.ProgressionWrapper
+	syncing         bool // whether syncProgression is running, owned by SyncWork
 
 	// save new block info from remote peer
 	enqueue   *PriorityQueue
@@ -70,10 +69,18 @@ type Syncer struct {
 	stxRecv   bool
 	onceSend  *sync.Once
 	stopSync  chan struct{}
+
+	metrics *Metrics
 }
 
 // NewSyncer creates a new Syncer instance
-func NewSyncer(logger hclog.Logger, server *network.Server, blockchain blockchainShim, datadir string) *Syncer {
+func NewSyncer(
+	logger hclog.Logger,
+	server *network.Server,
+	blockchain blockchainShim,
+	datadir string,
+	metrics *Metrics,
+) *Syncer {
 
 	const defQueueSize = 819200
 	s := &Syncer{
@@ -86,6 +93,7 @@ func NewSyncer(logger hclog.Logger, server *network.Server, blockchain blockchai
 		enqueueCh:       chanx.NewUnboundedChan[struct{}](defQueueSize),
 		onceSend:        &sync.Once{},
 		stopSync:        make(chan struct{}),
+		metrics:         NewDummyMetrics(metrics),
 	}
 
 	return s
@@ -156,7 +164,8 @@ func (s *Syncer) updateStatus(status *Status) {
 func (s *Syncer) enqueueBlock(peerID peer.ID, b *types.Block) {
 	s.logger.Debug("enqueue block", "peer", peerID, "number", b.Number(), "hash", b.Hash())
 
-	if s.blockchain.Header().Number >= b.Number() {
+	// blocks below the head are kept, they might belong to a heavier fork
+	if _, ok := s.blockchain.GetTD(b.Hash()); ok {
 		return
 	}
 
@@ -290,6 +299,14 @@ func (s *Syncer) WatchSync(ctx context.Context) {
 			newblock = items[0]
 			stx := time.Now()
 			err = s.blockchain.WriteBlock(newblock)
+			if errors.Is(err, blockchain.ErrParentNotFound) {
+				// the block belongs to a fork we do not know yet, it is
+				// picked up by SyncWork once that fork is the best chain
+				s.logger.Warn("handle new block", "number", newblock.Number(), "hash", newblock.Hash(), "err", err)
+
+				continue
+			}
+
 			if err != nil {
 				s.logger.Error("handle new block", "err", err)
 				return
@@ -300,103 +317,112 @@ func (s *Syncer) WatchSync(ctx context.Context) {
 }
 
 const (
+	// syncRetryInterval is how long SyncWork waits after a failed sync
+	syncRetryInterval = 10 * time.Second
+
 	syncFinishedSize          = 16
 	maxSkeletonHeadersAmount  = 190
 	stepSkeletonHeadersAmount = 30
 )
 
-// number: 687 have tx
+// SyncWork keeps the local chain in sync with the best peer. The blocks
+// are downloaded from several peers at once, see syncRange.
 func (s *Syncer) SyncWork(ctx context.Context) {
 	s.logger.Info("starting to sync block ...")
 	defer s.logger.Info("exit sync work!")
-
-	var (
-		p        *SyncPeer
-		ancestor *types.Header
-		err      error
-		blockCh  = make(chan []*types.Block, 4096)
-	)
+	defer s.stopSyncProgression()
 
 	for {
 		select {
 		case <-s.stopSync:
 			return
+		case <-ctx.Done():
+			return
 		default:
-			p = s.BestPeer()
-			if p == nil {
-				s.logger.Info("not found best peer")
-				time.Sleep(10 * time.Second)
-				continue
-			}
+		}
 
-			// find the common ancestor
-			ancestor, _, err = s.findCommonAncestor(p.client, p.status)
-			// check whether peer network same with us
-			if isDifferentNetworkError(err) {
-				s.server.DisconnectFromPeer(p.peer, "Different network")
-			}
+		p := s.BestPeer()
+		if p == nil {
+			// caught up with all peers
+			s.stopSyncProgression()
 
-			// return error
-			if err != nil {
-				continue
-			}
+			s.logger.Info("not found best peer")
+			time.Sleep(10 * time.Second)
 
-			s.logger.Info("fork found", "ancestor", ancestor.Number, "target", p.status.Number, "peer", p.ID())
+			continue
+		}
 
-			// start to revieve new block
-			if ancestor.Number+syncFinishedSize > p.status.Number {
-				s.StartToRecieveNewBlock()
-			}
+		// find the common ancestor
+		ancestor, _, err := s.findCommonAncestor(p.client, p.status)
+		// check whether peer network same with us
+		if isDifferentNetworkError(err) {
+			s.server.DisconnectFromPeer(p.peer, "Different network")
+		}
 
-			var (
-				target            uint64 = p.status.Number
-				currentSyncHeight        = ancestor.Number + 1
-			)
+		// return error
+		if err != nil {
+			continue
+		}
 
-			// sync finished
-			if currentSyncHeight == target {
-				close(blockCh)
-				return
-			}
-			blockAmount := maxSkeletonHeadersAmount
-			for {
-				sk := &skeleton{
-					server: s.server,
-					amount: int64(blockAmount),
-				}
+		target := p.Number()
 
-				blocks, err := sk.GetBlocks(ctx, p.ID(), currentSyncHeight)
-				if err != nil {
-					if rpcErr, ok := grpcstatus.FromError(err); ok {
-						// the data size exceeds grpc server/client message size
-						if rpcErr.Code() == grpccodes.ResourceExhausted {
-							blockAmount /= 2
-
-							continue
-						}
-					}
-					break
-				}
-				blockAmount = maxSkeletonHeadersAmount
-
-				for _, block := range blocks {
-					err = s.blockchain.WriteBlock(block)
-					if err != nil {
-						s.logger.Error("write block", "err", err)
-						return
-					}
-				}
+		s.logger.Info("fork found", "ancestor", ancestor.Number, "target", target, "peer", p.ID())
 
-				currentSyncHeight += uint64(len(blocks))
+		// start to revieve new block
+		if ancestor.Number+syncFinishedSize > target {
+			s.StartToRecieveNewBlock()
+		}
 
-				// check again
-				if currentSyncHeight >= target {
-					continue
-				}
+		if ancestor.Number >= target {
+			continue
+		}
+
+		s.trackSyncProgression(target)
+
+		err = s.syncRange(ctx, ancestor.Number+1, target)
+
+		switch {
+		case err == nil:
+		case errors.Is(err, errNoSyncPeers):
+			s.logger.Info("sync peers gone", "height", s.blockchain.Header().Number)
+		case errors.Is(err, errHeadMoved):
+			// sync again right away from the common ancestor of the new head
+			s.logger.Info("restart sync", "height", s.blockchain.Header().Number, "err", err)
+		default:
+			// the failing heights are fetched again from the common
+			// ancestor with the best peer
+			s.logger.Error("sync blocks", "err", err)
+
+			select {
+			case <-s.stopSync:
+				return
+			case <-ctx.Done():
+				return
+			case <-time.After(syncRetryInterval):
 			}
 		}
+	}
+}
+
+// trackSyncProgression starts the progression of a catch-up with the target
+// as the highest block, or raises the target of the running one
+func (s *Syncer) trackSyncProgression(target uint64) {
+	if !s.syncing {
+		s.syncProgression.StartProgression(s.blockchain.Header().Number, s.blockchain.SubscribeEvents())
+		s.syncing = true
+	}
+
+	s.syncProgression.UpdateHighestProgression(target)
+}
 
+// stopSyncProgression stops the progression of the running catch-up, if any
+func (s *Syncer) stopSyncProgression() {
+	if !s.syncing {
+		return
 	}
+
+	s.syncProgression.StopProgression()
+	s.syncing = false
 }
 
 func (s *Syncer) StartToRecieveNewBlock() {
@@ -464,6 +490,10 @@ func (s *Syncer) BestPeer() *SyncPeer {
 		return true
 	})
 
+	if bestPeer != nil {
+		s.metrics.BestPeerHeight.Set(float64(bestBlockNumber))
+	}
+
 	if bestBlockNumber <= s.blockchain.Header().Number {
 		bestPeer = nil
 	}
@@ -471,21 +501,19 @@ func (s *Syncer) BestPeer() *SyncPeer {
 	return bestPeer
 }
 
+// TakePeerByHeight returns at most num peers whose latest block is above height
 func (s *Syncer) TakePeerByHeight(height, num uint64) []*SyncPeer {
-	var (
-		rs    = make([]*SyncPeer, num)
-		count uint64
-	)
+	rs := make([]*SyncPeer, 0, num)
+	if num == 0 {
+		return rs
+	}
 
 	s.peers.Range(func(peerID peer.ID, sp *SyncPeer) bool {
 		if sp.Number() > height {
-			rs[count] = sp
+			rs = append(rs, sp)
 		}
-		count++
-		if count >= num {
-			return false
-		}
-		return true
+
+		return uint64(len(rs)) < num
 	})
 
 	return rs
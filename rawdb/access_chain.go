@@ -22,10 +22,16 @@ func ReadTD(db ethdb.Database, hash types.Hash) (*big.Int, bool) {
 
 }
 
-func WriteTD(db ethdb.Database, hash types.Hash, number uint64) error {
+func WriteTD(db ethdb.Writer, hash types.Hash, number uint64) error {
 	return db.Set(ethdb.TODBI, hash[:], helper.EncodeVarint(number))
 }
 
+// DeleteTD removes the total difficulty of the block, which makes the block
+// unknown to the chain
+func DeleteTD(db ethdb.Writer, hash types.Hash) error {
+	return db.Remove(ethdb.TODBI, hash[:])
+}
+
 func ReadHeadNumber(db ethdb.Database) (uint64, bool) {
 
 	v, ok, err := db.Get(ethdb.AssistDBI, latestBlockNumber)
@@ -37,7 +43,7 @@ func ReadHeadNumber(db ethdb.Database) (uint64, bool) {
 
 }
 
-func WriteHeadNumber(db ethdb.Database, number uint64) error {
+func WriteHeadNumber(db ethdb.Writer, number uint64) error {
 	return db.Set(ethdb.AssistDBI, latestBlockNumber, helper.EncodeVarint(number))
 }
 
@@ -51,17 +57,75 @@ func ReadHeadHash(db ethdb.Database) (types.Hash, bool) {
 
 }
 
-func WriteHeadHash(db ethdb.Database, hash types.Hash) error {
+func WriteHeadHash(db ethdb.Writer, hash types.Hash) error {
 	return db.Set(ethdb.AssistDBI, latestBlockHash, hash.Bytes())
 }
 
-func WriteBlockByHash(db ethdb.Database, hash types.Hash, block *types.Block) error {
+// ReadGCMode returns how the database keeps the states of the blocks
+func ReadGCMode(db ethdb.Database) (string, bool) {
+	v, ok, err := db.Get(ethdb.AssistDBI, gcMode)
+	if err != nil || !ok {
+		return "", false
+	}
+
+	return string(v), true
+}
+
+// WriteGCMode records how the database keeps the states of the blocks
+func WriteGCMode(db ethdb.Writer, mode string) error {
+	return db.Set(ethdb.AssistDBI, gcMode, []byte(mode))
+}
+
+// ReadCumulativeTD reports whether the total difficulties are stored as
+// the sum over the chain, older databases store the block difficulty
+func ReadCumulativeTD(db ethdb.Database) bool {
+	_, ok, err := db.Get(ethdb.AssistDBI, cumulativeTD)
+
+	return err == nil && ok
+}
+
+// WriteCumulativeTD records that the total difficulties are stored as the
+// sum over the chain
+func WriteCumulativeTD(db ethdb.Writer) error {
+	return db.Set(ethdb.AssistDBI, cumulativeTD, []byte{1})
+}
+
+func WriteBlockByHash(db ethdb.Writer, hash types.Hash, block *types.Block) error {
 
 	return nil
 }
 
+// ReadBlockByHash assembles a block from its header, body and transactions
 func ReadBlockByHash(db ethdb.Database, hash types.Hash) (*types.Block, bool) {
-	return nil, false
+	header, err := ReadHeader(db, hash)
+	if err != nil {
+		return nil, false
+	}
+
+	block := &types.Block{
+		Header:       header,
+		Transactions: []*types.Transaction{},
+	}
+
+	txhashes, err := ReadBody(db, hash)
+	if err != nil {
+		// empty blocks have no body stored
+		if header.TxRoot == types.EmptyRootHash {
+			return block, true
+		}
+
+		return nil, false
+	}
+
+	for _, txhash := range txhashes {
+		tx, err := ReadTransaction(db, txhash)
+		if err != nil {
+			return nil, false
+		}
+		block.Transactions = append(block.Transactions, tx)
+	}
+
+	return block, true
 }
 
 func ReadCanonicalHash(db ethdb.Database, number uint64) (types.Hash, bool) {
@@ -72,11 +136,17 @@ func ReadCanonicalHash(db ethdb.Database, number uint64) (types.Hash, bool) {
 	return types.BytesToHash(v), true
 }
 
-func WriteCanonicalHash(db ethdb.Database, number uint64, hash types.Hash) error {
+func WriteCanonicalHash(db ethdb.Writer, number uint64, hash types.Hash) error {
 	return db.Set(ethdb.NumHashDBI, helper.EncodeVarint(number), hash.Bytes())
 }
 
-func WriteHeader(db ethdb.Database, header *types.Header) error {
+// DeleteCanonicalHash removes the canonical hash of the number, used when
+// the chain is rewound below it
+func DeleteCanonicalHash(db ethdb.Writer, number uint64) error {
+	return db.Remove(ethdb.NumHashDBI, helper.EncodeVarint(number))
+}
+
+func WriteHeader(db ethdb.Writer, header *types.Header) error {
 	return db.Set(ethdb.HeadDBI, header.Hash.Bytes(), header.MarshalRLPTo(nil))
 }
 
@@ -88,24 +158,41 @@ func ReadHeader(db ethdb.Database, hash types.Hash) (*types.Header, error) {
 		return header, err
 	}
 
-	if ok {
-		err = header.UnmarshalRLP(v)
-		if err != nil {
-			return nil, err
-		}
+	if !ok {
+		return nil, ethdb.ErrNotFound
+	}
+
+	err = header.UnmarshalRLP(v)
+	if err != nil {
+		return nil, err
 	}
+	// headers are keyed by their hash
+	header.Hash = hash
 
 	return header, nil
 }
 
-func WriteTxLookUp(db ethdb.Database, number uint64, txes []*types.Transaction) error {
+func WriteTxLookUp(db ethdb.Writer, number uint64, txes []*types.Transaction) error {
 	if len(txes) == 0 {
 		return nil
 	}
 	blockNumber := helper.EncodeVarint(number)
 	for _, tx := range txes {
-		db.Set(ethdb.TxLookUpDBI, tx.Hash().Bytes(), blockNumber)
+		if err := db.Set(ethdb.TxLookUpDBI, tx.Hash().Bytes(), blockNumber); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteTxLookUp removes the block lookups of the transactions
+func DeleteTxLookUp(db ethdb.Writer, txhashes []types.Hash) error {
+	for _, txhash := range txhashes {
+		if err := db.Remove(ethdb.TxLookUpDBI, txhash[:]); err != nil {
+			return err
+		}
 	}
+
 	return nil
 }
 
@@ -118,7 +205,7 @@ func ReadTxLookUp(db ethdb.Database, txhash types.Hash) (uint64, bool) {
 	return 0, false
 }
 
-func WriteBody(db ethdb.Database, hash types.Hash, txes []*types.Transaction) error {
+func WriteBody(db ethdb.Writer, hash types.Hash, txes []*types.Transaction) error {
 
 	if len(txes) == 0 {
 		return nil
@@ -159,29 +246,23 @@ func ReadBody(db ethdb.Database, hash types.Hash) ([]types.Hash, error) {
 	return nil, fmt.Errorf("body not found")
 }
 
-func WriteTransactions(db ethdb.Database, txes []*types.Transaction) error {
+func WriteTransactions(db ethdb.Writer, txes []*types.Transaction) error {
 
 	if len(txes) == 0 {
 		return nil
 	}
 
-	batch := db.Batch()
-
 	for _, tx := range txes {
-		err := batch.Set(ethdb.TxesDBI, tx.Hash().Bytes(), tx.MarshalStoreRLPTo(nil))
+		err := db.Set(ethdb.TxesDBI, tx.Hash().Bytes(), tx.MarshalStoreRLPTo(nil))
 		if err != nil {
 			return err
 		}
 	}
 
-	if err := batch.Write(); err != nil {
-		return err
-	}
-
 	return nil
 }
 
-func WriteTransaction(db ethdb.Database, tx *types.Transaction) error {
+func WriteTransaction(db ethdb.Writer, tx *types.Transaction) error {
 	return db.Set(ethdb.TxesDBI, tx.Hash().Bytes(), tx.MarshalStoreRLPTo(nil))
 }
 
@@ -202,26 +283,32 @@ func ReadTransaction(db ethdb.Database, hash types.Hash) (*types.Transaction, er
 	return nil, fmt.Errorf("not found tx")
 }
 
-func WrteReceipts(db ethdb.Database, receipts types.Receipts) error {
-	batch := db.Batch()
-
+func WrteReceipts(db ethdb.Writer, receipts types.Receipts) error {
 	for _, rx := range receipts {
-		err := batch.Set(ethdb.ReceiptsDBI, rx.TxHash.Bytes(), rx.MarshalStoreRLPTo(nil))
+		err := db.Set(ethdb.ReceiptsDBI, rx.TxHash.Bytes(), rx.MarshalStoreRLPTo(nil))
 		if err != nil {
 			return err
 		}
 	}
 
-	if err := batch.Write(); err != nil {
-		return err
-	}
 	return nil
 }
 
-func WrteReceipt(db ethdb.Database, receipt *types.Receipt) error {
+func WrteReceipt(db ethdb.Writer, receipt *types.Receipt) error {
 	return db.Set(ethdb.ReceiptsDBI, receipt.TxHash.Bytes(), receipt.MarshalStoreRLPTo(nil))
 }
 
+// DeleteReceipts removes the receipts of the transactions
+func DeleteReceipts(db ethdb.Writer, txhashes []types.Hash) error {
+	for _, txhash := range txhashes {
+		if err := db.Remove(ethdb.ReceiptsDBI, txhash[:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func ReadReceipt(db ethdb.Database, hash types.Hash) (*types.Receipt, error) {
 
 	receipt := &types.Receipt{}
@@ -231,11 +318,13 @@ func ReadReceipt(db ethdb.Database, hash types.Hash) (*types.Receipt, error) {
 		return nil, err
 	}
 
-	if ok {
-		err = receipt.UnmarshalStoreRLP(v)
-		if err != nil {
-			return nil, err
-		}
+	if !ok {
+		return nil, ethdb.ErrNotFound
+	}
+
+	err = receipt.UnmarshalStoreRLP(v)
+	if err != nil {
+		return nil, err
 	}
 
 	return receipt, nil
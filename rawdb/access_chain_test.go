@@ -0,0 +1,98 @@
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func TestReadBlockByHash(t *testing.T) {
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer db.Close()
+
+	to := types.StringToAddress("0x1")
+	txes := []*types.Transaction{
+		{Nonce: 0, GasPrice: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(1),
+			V: big.NewInt(27), R: big.NewInt(1), S: big.NewInt(1)},
+		{Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(2),
+			V: big.NewInt(27), R: big.NewInt(1), S: big.NewInt(1)},
+	}
+
+	full := &types.Header{Number: 1, TxRoot: types.StringToHash("0x1"), Hash: types.StringToHash("0xa")}
+	empty := &types.Header{Number: 2, TxRoot: types.EmptyRootHash, Hash: types.StringToHash("0xb")}
+
+	for _, h := range []*types.Header{full, empty} {
+		if err := WriteHeader(db, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := WriteTransactions(db, txes); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteBody(db, full.Hash, txes); err != nil {
+		t.Fatal(err)
+	}
+
+	blk, ok := ReadBlockByHash(db, full.Hash)
+	if !ok {
+		t.Fatal("block not found")
+	}
+
+	if len(blk.Transactions) != len(txes) {
+		t.Fatalf("expected %d transactions, got %d", len(txes), len(blk.Transactions))
+	}
+
+	for i, tx := range blk.Transactions {
+		if tx.Hash() != txes[i].Hash() {
+			t.Fatalf("transaction %d mismatch", i)
+		}
+	}
+
+	blk, ok = ReadBlockByHash(db, empty.Hash)
+	if !ok || len(blk.Transactions) != 0 || blk.Hash() != empty.Hash {
+		t.Fatal("expected empty block")
+	}
+
+	if _, ok := ReadBlockByHash(db, types.StringToHash("0x2")); ok {
+		t.Fatal("expected unknown block")
+	}
+}
+
+func TestGCMode(t *testing.T) {
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer db.Close()
+
+	if _, ok := ReadGCMode(db); ok {
+		t.Fatal("expected no gc mode")
+	}
+
+	if err := WriteGCMode(db, "full"); err != nil {
+		t.Fatal(err)
+	}
+
+	if mode, ok := ReadGCMode(db); !ok || mode != "full" {
+		t.Fatalf("unexpected gc mode %q", mode)
+	}
+}
+
+func TestCumulativeTD(t *testing.T) {
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer db.Close()
+
+	if ReadCumulativeTD(db) {
+		t.Fatal("expected no cumulative total difficulty")
+	}
+
+	if err := WriteCumulativeTD(db); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ReadCumulativeTD(db) {
+		t.Fatal("expected cumulative total difficulty")
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/sunvim/dogesyncer/types"
 )
 
-func WriteSnap(db ethdb.Database, number uint64, snap *types.Snapshot) error {
+func WriteSnap(db ethdb.Writer, number uint64, snap *types.Snapshot) error {
 	out, err := snap.Marshal()
 	if err != nil {
 		return err
@@ -39,3 +39,34 @@ func ReadState(db ethdb.Database, root types.Hash) ([]byte, error) {
 	}
 	return nil, ethdb.ErrNotFound
 }
+
+// WriteBloomIndex indexes the logs bloom of the canonical block by number,
+// so log queries can skip blocks without reading their headers
+func WriteBloomIndex(db ethdb.Writer, number uint64, hash types.Hash, bloom types.Bloom) error {
+	v := make([]byte, 0, types.HashLength+types.BloomByteLength)
+	v = append(v, hash.Bytes()...)
+	v = append(v, bloom[:]...)
+
+	return db.Set(ethdb.BloomDBI, helper.EncodeVarint(number), v)
+}
+
+func ReadBloomIndex(db ethdb.Database, number uint64) (types.Hash, types.Bloom, bool) {
+	var (
+		hash  types.Hash
+		bloom types.Bloom
+	)
+
+	v, ok, _ := db.Get(ethdb.BloomDBI, helper.EncodeVarint(number))
+	if !ok || len(v) != types.HashLength+types.BloomByteLength {
+		return hash, bloom, false
+	}
+
+	copy(hash[:], v[:types.HashLength])
+	copy(bloom[:], v[types.HashLength:])
+
+	return hash, bloom, true
+}
+
+func DeleteBloomIndex(db ethdb.Writer, number uint64) error {
+	return db.Remove(ethdb.BloomDBI, helper.EncodeVarint(number))
+}
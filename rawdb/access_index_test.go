@@ -0,0 +1,36 @@
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func TestBloomIndex(t *testing.T) {
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer db.Close()
+
+	hash := types.StringToHash("0x1")
+	bloom := types.CreateBloom([]*types.Receipt{{
+		Logs: []*types.Log{{Address: types.StringToAddress("0x2")}},
+	}})
+
+	if _, _, ok := ReadBloomIndex(db, 1); ok {
+		t.Fatal("expected missing index")
+	}
+
+	if err := WriteBloomIndex(db, 1, hash, bloom); err != nil {
+		t.Fatal(err)
+	}
+
+	h, b, ok := ReadBloomIndex(db, 1)
+	if !ok {
+		t.Fatal("expected index")
+	}
+
+	if h != hash || b != bloom {
+		t.Fatal("index mismatch")
+	}
+}
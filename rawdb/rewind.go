@@ -0,0 +1,93 @@
+package rawdb
+
+import (
+	"fmt"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// Rewind moves the head back to the canonical block of the number and
+// removes the canonical data above it in a single batch. The blocks above
+// lose their total difficulty, so they are written and executed again when
+// they are synced. It returns the new head.
+func Rewind(db ethdb.Database, number uint64) (*types.Header, error) {
+	head, ok := ReadHeadNumber(db)
+	if !ok {
+		return nil, fmt.Errorf("head not found")
+	}
+
+	if number > head {
+		return nil, fmt.Errorf("block %d is above the head %d", number, head)
+	}
+
+	hash, ok := ReadCanonicalHash(db, number)
+	if !ok {
+		return nil, fmt.Errorf("canonical block %d not found", number)
+	}
+
+	header, err := ReadHeader(db, hash)
+	if err != nil {
+		return nil, fmt.Errorf("header of block %d: %w", number, err)
+	}
+
+	// the blocks on top of the new head are executed on its state
+	if header.StateRoot != types.EmptyRootHash {
+		if _, err := ReadState(db, header.StateRoot); err != nil {
+			return nil, fmt.Errorf("state %s of block %d: %w", header.StateRoot, number, err)
+		}
+	}
+
+	batch := db.Batch()
+	defer batch.Discard()
+
+	for n := head; n > number; n-- {
+		hash, ok := ReadCanonicalHash(db, n)
+		if !ok {
+			continue
+		}
+
+		if err := DeleteCanonicalData(db, batch, n, hash); err != nil {
+			return nil, err
+		}
+
+		if err := DeleteTD(batch, hash); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := WriteHeadHash(batch, hash); err != nil {
+		return nil, err
+	}
+
+	if err := WriteHeadNumber(batch, number); err != nil {
+		return nil, err
+	}
+
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+
+	return header, nil
+}
+
+// DeleteCanonicalData removes what makes the block canonical: its hash at
+// the number, its bloom index and the lookups and receipts of its
+// transactions. The header, the body and the total difficulty are kept.
+func DeleteCanonicalData(db ethdb.Database, batch ethdb.Writer, number uint64, hash types.Hash) error {
+	if txhashes, err := ReadBody(db, hash); err == nil {
+		if err := DeleteTxLookUp(batch, txhashes); err != nil {
+			return err
+		}
+
+		if err := DeleteReceipts(batch, txhashes); err != nil {
+			return err
+		}
+	}
+
+	if err := DeleteCanonicalHash(batch, number); err != nil {
+		return err
+	}
+
+	return DeleteBloomIndex(batch, number)
+}
@@ -0,0 +1,111 @@
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// writeCanonicalBlock writes a block with a single transaction and the
+// canonical data of the number
+func writeCanonicalBlock(t *testing.T, db ethdb.Database, number uint64) (types.Hash, types.Hash) {
+	t.Helper()
+
+	to := types.StringToAddress("0x1")
+	tx := &types.Transaction{
+		Nonce: number, GasPrice: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(1),
+		V: big.NewInt(27), R: big.NewInt(1), S: big.NewInt(1),
+	}
+
+	header := &types.Header{Number: number, TxRoot: types.StringToHash("0x1"), StateRoot: types.EmptyRootHash}
+	types.PutIbftExtraValidators(header, nil)
+	header.ComputeHash()
+
+	receipt := &types.Receipt{TxHash: tx.Hash()}
+	receipt.SetStatus(types.ReceiptSuccess)
+
+	for _, err := range []error{
+		WriteHeader(db, header),
+		WriteTD(db, header.Hash, number+1),
+		WriteCanonicalHash(db, number, header.Hash),
+		WriteTransactions(db, []*types.Transaction{tx}),
+		WriteBody(db, header.Hash, []*types.Transaction{tx}),
+		WriteTxLookUp(db, number, []*types.Transaction{tx}),
+		WrteReceipts(db, types.Receipts{receipt}),
+		WriteBloomIndex(db, number, header.Hash, types.Bloom{}),
+		WriteHeadHash(db, header.Hash),
+		WriteHeadNumber(db, number),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return header.Hash, tx.Hash()
+}
+
+func TestRewind(t *testing.T) {
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer db.Close()
+
+	hashes := make([]types.Hash, 4)
+	txhashes := make([]types.Hash, 4)
+
+	for i := range hashes {
+		hashes[i], txhashes[i] = writeCanonicalBlock(t, db, uint64(i))
+	}
+
+	if _, err := Rewind(db, 4); err == nil {
+		t.Fatal("expected rewinding above the head to fail")
+	}
+
+	header, err := Rewind(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Hash != hashes[1] {
+		t.Fatalf("unexpected head %s", header.Hash)
+	}
+
+	if hash, ok := ReadHeadHash(db); !ok || hash != hashes[1] {
+		t.Fatalf("unexpected head hash %s", hash)
+	}
+
+	if number, ok := ReadHeadNumber(db); !ok || number != 1 {
+		t.Fatalf("unexpected head number %d", number)
+	}
+
+	for i := range hashes {
+		kept := i <= 1
+
+		if _, ok := ReadCanonicalHash(db, uint64(i)); ok != kept {
+			t.Fatalf("block %d: canonical hash kept %v", i, ok)
+		}
+
+		if _, ok := ReadTD(db, hashes[i]); ok != kept {
+			t.Fatalf("block %d: total difficulty kept %v", i, ok)
+		}
+
+		if _, ok := ReadTxLookUp(db, txhashes[i]); ok != kept {
+			t.Fatalf("block %d: tx lookup kept %v", i, ok)
+		}
+
+		if _, err := ReadReceipt(db, txhashes[i]); (err == nil) != kept {
+			t.Fatalf("block %d: receipt kept %v", i, err == nil)
+		}
+
+		if _, _, ok := ReadBloomIndex(db, uint64(i)); ok != kept {
+			t.Fatalf("block %d: bloom index kept %v", i, ok)
+		}
+
+		// the blocks themselves are kept
+		if _, ok := ReadBlockByHash(db, hashes[i]); !ok {
+			t.Fatalf("block %d: block removed", i)
+		}
+	}
+}
@@ -3,4 +3,6 @@ package rawdb
 var (
 	latestBlockHash   = []byte("latest_hash")
 	latestBlockNumber = []byte("latest_number")
+	gcMode            = []byte("gc_mode")
+	cumulativeTD      = []byte("cumulative_td")
 )
@@ -0,0 +1,247 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/sunvim/dogesyncer/state"
+	"github.com/sunvim/dogesyncer/state/runtime"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// txnArgs is the call object of eth_call and eth_estimateGas
+type txnArgs struct {
+	From     *types.Address `json:"from"`
+	To       *types.Address `json:"to"`
+	Gas      *argUint64     `json:"gas"`
+	GasPrice *argBig        `json:"gasPrice"`
+	Value    *argBig        `json:"value"`
+	Data     *argBytes      `json:"data"`
+	Input    *argBytes      `json:"input"`
+	Nonce    *argUint64     `json:"nonce"`
+}
+
+func paramTxnArgs(params []any, idx int) (*txnArgs, error) {
+	if idx >= len(params) {
+		return nil, NewInvalidParamsError("missing value for required argument transaction")
+	}
+
+	data, err := json.Marshal(params[idx])
+	if err != nil {
+		return nil, NewInvalidParamsError(fmt.Sprintf("invalid argument transaction: %v", err))
+	}
+
+	args := &txnArgs{}
+	if err := json.Unmarshal(data, args); err != nil {
+		return nil, NewInvalidParamsError(fmt.Sprintf("invalid argument transaction: %v", err))
+	}
+
+	return args, nil
+}
+
+// Call executes a message call against the state of the given block
+// without creating a transaction on chain
+func (s *RpcServer) Call(method string, params ...any) any {
+	args, err := paramTxnArgs(params, 0)
+	if err != nil {
+		return err
+	}
+
+	block, err := paramBlockNumberOrHash(params, 1, "block")
+	if err != nil {
+		return err
+	}
+
+	header, ok := s.headerByNumberOrHash(block)
+	if !ok {
+		return NewInvalidParamsError("header not found")
+	}
+
+	msg, err := s.toMessage(args, header)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.applyMessage(header, msg)
+	if err != nil {
+		return err
+	}
+
+	if result.Reverted() {
+		return constructErrorFromRevert(result)
+	}
+
+	if result.Failed() {
+		return fmt.Errorf("unable to execute call: %w", result.Err)
+	}
+
+	return argBytes(result.ReturnValue)
+}
+
+// EstimateGas binary searches the lowest gas limit the message
+// executes successfully with
+func (s *RpcServer) EstimateGas(method string, params ...any) any {
+	args, err := paramTxnArgs(params, 0)
+	if err != nil {
+		return err
+	}
+
+	block, err := paramBlockNumberOrHash(params, 1, "block")
+	if err != nil {
+		return err
+	}
+
+	header, ok := s.headerByNumberOrHash(block)
+	if !ok {
+		return NewInvalidParamsError("header not found")
+	}
+
+	msg, err := s.toMessage(args, header)
+	if err != nil {
+		return err
+	}
+
+	lo := state.TxGas - 1
+	hi := header.GasLimit
+
+	if args.Gas != nil && uint64(*args.Gas) >= state.TxGas && uint64(*args.Gas) < hi {
+		hi = uint64(*args.Gas)
+	}
+
+	// the gas the sender is able to pay for caps the search
+	if msg.GasPrice.Sign() > 0 {
+		txn, err := s.stateAt(header)
+		if err != nil {
+			return err
+		}
+
+		available := new(big.Int).Sub(txn.GetBalance(msg.From), msg.Value)
+		if available.Sign() < 0 {
+			return state.ErrNotEnoughFunds
+		}
+
+		allowance := new(big.Int).Div(available, msg.GasPrice)
+		if allowance.IsUint64() && allowance.Uint64() < hi {
+			hi = allowance.Uint64()
+		}
+	}
+
+	limit := hi
+
+	executable := func(gas uint64) (bool, *runtime.ExecutionResult, error) {
+		msg.Gas = gas
+
+		result, err := s.applyMessage(header, msg)
+		if err != nil {
+			var appErr *state.TransitionApplicationError
+			if errors.As(err, &appErr) && errors.Is(appErr.Err, state.ErrNotEnoughIntrinsicGas) {
+				return true, nil, nil
+			}
+
+			return true, nil, err
+		}
+
+		return result.Failed(), result, nil
+	}
+
+	// bail out early if the message fails with the highest allowance
+	failed, result, err := executable(hi)
+	if err != nil {
+		return err
+	}
+
+	if failed {
+		if result != nil && result.Reverted() {
+			return constructErrorFromRevert(result)
+		}
+
+		if result != nil && !errors.Is(result.Err, runtime.ErrOutOfGas) {
+			return result.Err
+		}
+
+		return fmt.Errorf("gas required exceeds allowance (%d)", limit)
+	}
+
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+
+		failed, _, err := executable(mid)
+		if err != nil {
+			return err
+		}
+
+		if failed {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return argUint64(hi)
+}
+
+// toMessage builds the unsigned message of a call, missing fields fall
+// back to the sender's current nonce, zero values and the block gas limit
+func (s *RpcServer) toMessage(args *txnArgs, header *types.Header) (*types.Transaction, error) {
+	msg := &types.Transaction{
+		To:       args.To,
+		Gas:      header.GasLimit,
+		GasPrice: new(big.Int),
+		Value:    new(big.Int),
+		Input:    []byte{},
+	}
+
+	if args.From != nil {
+		msg.From = *args.From
+	}
+
+	if args.Gas != nil && uint64(*args.Gas) < header.GasLimit {
+		msg.Gas = uint64(*args.Gas)
+	}
+
+	if args.GasPrice != nil {
+		msg.GasPrice = new(big.Int).Set((*big.Int)(args.GasPrice))
+	}
+
+	if args.Value != nil {
+		msg.Value = new(big.Int).Set((*big.Int)(args.Value))
+	}
+
+	if args.Input != nil {
+		msg.Input = *args.Input
+	} else if args.Data != nil {
+		msg.Input = *args.Data
+	}
+
+	if args.Nonce != nil {
+		msg.Nonce = uint64(*args.Nonce)
+	} else {
+		txn, err := s.stateAt(header)
+		if err != nil {
+			return nil, err
+		}
+
+		msg.Nonce = txn.GetNonce(msg.From)
+	}
+
+	return msg, nil
+}
+
+// applyMessage runs the message on top of the state of the block, the
+// transition is discarded afterwards so nothing is ever committed
+func (s *RpcServer) applyMessage(header *types.Header, msg *types.Transaction) (*runtime.ExecutionResult, error) {
+	coinbase, err := s.blockchain.GetBlockCreator(header)
+	if err != nil {
+		// the genesis block is not sealed
+		coinbase = header.Miner
+	}
+
+	transition, err := s.executor.BeginTxn(header.StateRoot, header, coinbase)
+	if err != nil {
+		return nil, stateError(header.Number, err)
+	}
+
+	return transition.Apply(msg)
+}
@@ -0,0 +1,202 @@
+package rpc
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/chain"
+	"github.com/sunvim/dogesyncer/crypto"
+	"github.com/sunvim/dogesyncer/helper/hex"
+	"github.com/sunvim/dogesyncer/state"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+var (
+	// callStorageCode returns the word in slot 0 without calldata and
+	// stores the first calldata word in slot 0 otherwise
+	callStorageCode = hex.MustDecodeHex("0x3615600c57600035600055005b60005460005260206000f3")
+
+	// callStorageInit deploys callStorageCode
+	callStorageInit = append(hex.MustDecodeHex("0x6018600c60003960186000f3"), callStorageCode...)
+
+	// callRevertData is the abi encoded Error("nope")
+	callRevertData = hex.MustDecodeHex("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"6e6f706500000000000000000000000000000000000000000000000000000000")
+
+	// callReverter reverts with callRevertData
+	callReverter = types.StringToAddress("0x5003")
+
+	// callPoor is able to pay for 25000 gas at a gas price of 1
+	callPoor = types.StringToAddress("0x5004")
+)
+
+func callWord(n int64) []byte {
+	return types.BytesToHash(big.NewInt(n).Bytes()).Bytes()
+}
+
+// newCallTestChain returns a chain deploying the storage contract and
+// storing 1 in block 1, then storing 2 in block 2
+func newCallTestChain(t *testing.T) (*testChain, types.Address) {
+	t.Helper()
+
+	// copies the revert data appended to the code into memory and reverts
+	reverterCode := append(hex.MustDecodeHex("0x6064600c60003960646000fd"), callRevertData...)
+
+	c := newTestChain(t, map[types.Address]*chain.GenesisAccount{
+		callReverter: {Code: reverterCode},
+		callPoor:     {Balance: big.NewInt(25000)},
+	})
+
+	contract := crypto.CreateAddress(c.senderAddress(), 0)
+
+	c.addBlock(
+		c.signTx(0, nil, callStorageInit),
+		c.signTx(1, &contract, callWord(1)),
+	)
+	c.addBlock(c.signTx(2, &contract, callWord(2)))
+
+	return c, contract
+}
+
+func TestCall(t *testing.T) {
+	c, contract := newCallTestChain(t)
+
+	cases := []struct {
+		block    any
+		expected string
+	}{
+		{"0x0", "0x"},
+		{"0x1", hex.EncodeToHex(callWord(1))},
+		{"latest", hex.EncodeToHex(callWord(2))},
+		{map[string]any{"blockHash": c.head.Hash.String()}, hex.EncodeToHex(callWord(2))},
+	}
+
+	for _, cs := range cases {
+		res := c.s.Call("eth_call", map[string]any{"to": contract.String()}, cs.block)
+		if err, ok := res.(error); ok {
+			t.Fatalf("block %v: %v", cs.block, err)
+		}
+
+		data, ok := res.(argBytes)
+		assert.True(t, ok)
+		assert.Equal(t, cs.expected, hex.EncodeToHex(data), "block %v", cs.block)
+	}
+}
+
+func TestCall_Revert(t *testing.T) {
+	c, _ := newCallTestChain(t)
+
+	res := c.s.Call("eth_call", map[string]any{"to": callReverter.String()}, "latest")
+
+	var dataErr DataError
+	if err, ok := res.(error); !ok || !errors.As(err, &dataErr) {
+		t.Fatalf("expected a revert error, got %v", res)
+	}
+
+	assert.Equal(t, 3, dataErr.ErrorCode())
+	assert.Equal(t, "execution reverted: nope", dataErr.Error())
+	assert.Equal(t, hex.EncodeToHex(callRevertData), dataErr.ErrorData())
+}
+
+func TestCall_IntrinsicGas(t *testing.T) {
+	c, _ := newCallTestChain(t)
+
+	// the calldata costs gas on top of the 21000 of the transfer
+	res := c.s.Call("eth_call", map[string]any{
+		"to":   callPoor.String(),
+		"gas":  "0x5208",
+		"data": hex.EncodeToHex(callWord(7)),
+	}, "latest")
+
+	err, ok := res.(error)
+	assert.True(t, ok)
+
+	var appErr *state.TransitionApplicationError
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.ErrorIs(t, appErr.Err, state.ErrNotEnoughIntrinsicGas)
+	}
+}
+
+func TestEstimateGas(t *testing.T) {
+	c, contract := newCallTestChain(t)
+
+	// the estimate of storing 2 on top of block 1 is the gas block 2 used
+	// to do it
+	res := c.s.EstimateGas("eth_estimateGas", map[string]any{
+		"from": c.senderAddress().String(),
+		"to":   contract.String(),
+		"data": hex.EncodeToHex(callWord(2)),
+	}, "0x1")
+	if err, ok := res.(error); ok {
+		t.Fatal(err)
+	}
+
+	receipts, err := c.s.blockchain.GetReceiptsByHash(c.head.Hash)
+	assert.NoError(t, err)
+
+	if assert.Len(t, receipts, 1) {
+		assert.Equal(t, argUint64(receipts[0].CumulativeGasUsed), res)
+	}
+
+	// a transfer needs its intrinsic gas only, the search passes the
+	// heights below it
+	res = c.s.EstimateGas("eth_estimateGas", map[string]any{
+		"to":   callPoor.String(),
+		"data": hex.EncodeToHex(callWord(7)),
+	}, "latest")
+	assert.Equal(t, argUint64(state.TxGas+31*4+16), res)
+}
+
+func TestEstimateGas_Errors(t *testing.T) {
+	c, contract := newCallTestChain(t)
+
+	cases := []struct {
+		name     string
+		args     map[string]any
+		expected string
+	}{
+		{
+			"balance cap",
+			map[string]any{
+				"from":     callPoor.String(),
+				"to":       contract.String(),
+				"gasPrice": "0x1",
+				"data":     hex.EncodeToHex(callWord(5)),
+			},
+			"gas required exceeds allowance (25000)",
+		},
+		{
+			"intrinsic gas",
+			map[string]any{
+				"to":   callPoor.String(),
+				"gas":  "0x5208",
+				"data": hex.EncodeToHex(callWord(7)),
+			},
+			"gas required exceeds allowance (21000)",
+		},
+		{
+			"revert",
+			map[string]any{
+				"to": callReverter.String(),
+			},
+			"execution reverted: nope",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			res := c.s.EstimateGas("eth_estimateGas", cs.args, "latest")
+
+			err, ok := res.(error)
+			if !ok {
+				t.Fatalf("expected an error, got %v", res)
+			}
+
+			assert.EqualError(t, err, cs.expected)
+		})
+	}
+}
@@ -3,9 +3,102 @@ package rpc
 import (
 	"strconv"
 	"strings"
+
+	"github.com/sunvim/dogesyncer/types"
 )
 
 func (s *RpcServer) GetBlockNumber(method string, params ...any) any {
 	num := strconv.FormatInt(int64(s.blockchain.Header().Number), 16)
 	return strings.Join([]string{"0x", num}, "")
 }
+
+// ChainId returns the chain id of the current network
+func (s *RpcServer) ChainId(method string, params ...any) any {
+	return argUint64(s.blockchain.Config().Params.ChainID)
+}
+
+// NetVersion returns the network id as a decimal string
+func (s *RpcServer) NetVersion(method string, params ...any) any {
+	return strconv.FormatInt(int64(s.blockchain.Config().Params.ChainID), 10)
+}
+
+// GasPrice returns the average gas price of the recent blocks
+func (s *RpcServer) GasPrice(method string, params ...any) any {
+	return argBig(*s.blockchain.GetAvgGasPrice())
+}
+
+func (s *RpcServer) GetBlockByNumber(method string, params ...any) any {
+	num, err := paramBlockNumber(params, 0, "number")
+	if err != nil {
+		return err
+	}
+
+	full, err := paramBool(params, 1, "full")
+	if err != nil {
+		return err
+	}
+
+	header, ok := s.headerByNumber(num)
+	if !ok {
+		return nil
+	}
+
+	return s.encodeBlock(header.Hash, full)
+}
+
+func (s *RpcServer) GetBlockByHash(method string, params ...any) any {
+	hash, err := paramHash(params, 0, "hash")
+	if err != nil {
+		return err
+	}
+
+	full, err := paramBool(params, 1, "full")
+	if err != nil {
+		return err
+	}
+
+	return s.encodeBlock(hash, full)
+}
+
+func (s *RpcServer) encodeBlock(hash types.Hash, full bool) any {
+	blk, ok := s.blockchain.GetBlockByHash(hash, true)
+	if !ok {
+		return nil
+	}
+
+	if full {
+		for _, txn := range blk.Transactions {
+			s.fillSender(blk.Number(), txn)
+		}
+	}
+
+	td, _ := s.blockchain.GetTD(hash)
+
+	return toBlock(blk, td, full)
+}
+
+// headerByNumber resolves a block number, including the latest, pending
+// and earliest tags, to a canonical header
+func (s *RpcServer) headerByNumber(num BlockNumber) (*types.Header, bool) {
+	switch num {
+	case LatestBlockNumber, PendingBlockNumber:
+		return s.blockchain.Header(), true
+	case EarliestBlockNumber:
+		return s.blockchain.GetHeaderByNumber(0)
+	default:
+		return s.blockchain.GetHeaderByNumber(uint64(num))
+	}
+}
+
+// headerByNumberOrHash resolves an EIP-1898 block selector to a header
+func (s *RpcServer) headerByNumberOrHash(block BlockNumberOrHash) (*types.Header, bool) {
+	if block.BlockHash != nil {
+		return s.blockchain.GetHeaderByHash(*block.BlockHash)
+	}
+
+	if block.BlockNumber != nil {
+		return s.headerByNumber(*block.BlockNumber)
+	}
+
+	return s.headerByNumber(LatestBlockNumber)
+}
@@ -0,0 +1,313 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sunvim/dogesyncer/state"
+	"github.com/sunvim/dogesyncer/state/runtime"
+	"github.com/sunvim/dogesyncer/state/tracer"
+	"github.com/sunvim/dogesyncer/state/tracer/structlogger"
+	"github.com/sunvim/dogesyncer/types"
+
+	// register the native tracers
+	_ "github.com/sunvim/dogesyncer/state/tracer/native"
+)
+
+const (
+	// defaultTraceTimeout is the amount of time the replay of a trace can
+	// execute by default before being forcefully aborted
+	defaultTraceTimeout = 5 * time.Second
+)
+
+var (
+	errTraceGenesis = errors.New("genesis is not traceable")
+	errTraceTimeout = errors.New("execution timeout")
+)
+
+// traceConfig is the tracing options of the debug_trace methods, the
+// struct logger is used when no tracer is named
+type traceConfig struct {
+	DisableStack   bool            `json:"disableStack"`
+	DisableStorage bool            `json:"disableStorage"`
+	EnableMemory   bool            `json:"enableMemory"`
+	Limit          int             `json:"limit"`
+	Tracer         *string         `json:"tracer"`
+	Timeout        *string         `json:"timeout"`
+	TracerConfig   json.RawMessage `json:"tracerConfig"`
+}
+
+// txTraceResult is the result of a single transaction trace of a block
+type txTraceResult struct {
+	TxHash types.Hash      `json:"txHash"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// paramTraceConfig returns the idx-th param as a trace config, an absent
+// param is the default config
+func paramTraceConfig(params []any, idx int) (*traceConfig, error) {
+	cfg := &traceConfig{}
+
+	if idx >= len(params) || params[idx] == nil {
+		return cfg, nil
+	}
+
+	data, err := json.Marshal(params[idx])
+	if err != nil {
+		return nil, NewInvalidParamsError(fmt.Sprintf("invalid argument config: %v", err))
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, NewInvalidParamsError(fmt.Sprintf("invalid argument config: %v", err))
+	}
+
+	return cfg, nil
+}
+
+// timeout returns the execution limit of the replay
+func (cfg *traceConfig) timeout() (time.Duration, error) {
+	if cfg.Timeout == nil {
+		return defaultTraceTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(*cfg.Timeout)
+	if err != nil {
+		return 0, NewInvalidParamsError(fmt.Sprintf("invalid argument config: timeout %v", err))
+	}
+
+	return timeout, nil
+}
+
+// TraceTransaction replays the transaction on top of the state it was
+// executed against and returns the result of the tracer
+func (s *RpcServer) TraceTransaction(method string, params ...any) any {
+	hash, err := paramHash(params, 0, "hash")
+	if err != nil {
+		return err
+	}
+
+	cfg, err := paramTraceConfig(params, 1)
+	if err != nil {
+		return err
+	}
+
+	blk, idx, ok := s.lookupTransaction(hash)
+	if !ok {
+		return fmt.Errorf("transaction %s not found", hash)
+	}
+
+	results, err := s.replayBlock(blk, cfg, &hash)
+	if err != nil {
+		return err
+	}
+
+	if results[idx].Error != "" {
+		return errors.New(results[idx].Error)
+	}
+
+	return results[idx].Result
+}
+
+// TraceBlockByNumber replays all transactions of the block and returns the
+// results of the tracer in block order
+func (s *RpcServer) TraceBlockByNumber(method string, params ...any) any {
+	number, err := paramBlockNumber(params, 0, "number")
+	if err != nil {
+		return err
+	}
+
+	cfg, err := paramTraceConfig(params, 1)
+	if err != nil {
+		return err
+	}
+
+	header, ok := s.headerByNumber(number)
+	if !ok {
+		return fmt.Errorf("block #%d not found", number)
+	}
+
+	return s.traceBlock(header.Hash, cfg)
+}
+
+// TraceBlockByHash replays all transactions of the block and returns the
+// results of the tracer in block order
+func (s *RpcServer) TraceBlockByHash(method string, params ...any) any {
+	hash, err := paramHash(params, 0, "hash")
+	if err != nil {
+		return err
+	}
+
+	cfg, err := paramTraceConfig(params, 1)
+	if err != nil {
+		return err
+	}
+
+	return s.traceBlock(hash, cfg)
+}
+
+// SetHead rewinds the chain to the block, the blocks above it are synced
+// and executed again
+func (s *RpcServer) SetHead(method string, params ...any) any {
+	number, err := paramBlockNumber(params, 0, "number")
+	if err != nil {
+		return err
+	}
+
+	header, ok := s.headerByNumber(number)
+	if !ok {
+		return fmt.Errorf("block #%d not found", number)
+	}
+
+	if err := s.blockchain.SetHead(header.Number); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *RpcServer) traceBlock(hash types.Hash, cfg *traceConfig) any {
+	blk, ok := s.blockchain.GetBlockByHash(hash, true)
+	if !ok {
+		return fmt.Errorf("block %s not found", hash)
+	}
+
+	results, err := s.replayBlock(blk, cfg, nil)
+	if err != nil {
+		return err
+	}
+
+	return results
+}
+
+// replayBlock re-executes the transactions of the block on top of its
+// parent state, in the same order as the import does. Only the transaction
+// of the target hash is traced and the replay stops after it, a nil target
+// traces all transactions. The results are in block order. The replay is
+// cancelled with errTraceTimeout once the timeout of the config elapses.
+func (s *RpcServer) replayBlock(
+	blk *types.Block,
+	cfg *traceConfig,
+	target *types.Hash,
+) ([]*txTraceResult, error) {
+	if blk.Number() == 0 {
+		return nil, errTraceGenesis
+	}
+
+	timeout, err := cfg.timeout()
+	if err != nil {
+		return nil, err
+	}
+
+	// the block executes on top of the state of its parent
+	transition, txs, err := s.blockchain.BeginBlockTransition(blk)
+	if err != nil {
+		return nil, stateError(blk.Number()-1, err)
+	}
+
+	// the position of the transactions in the block
+	index := make(map[types.Hash]int, len(blk.Transactions))
+	for i, tx := range blk.Transactions {
+		index[tx.Hash()] = i
+	}
+
+	results := make([]*txTraceResult, len(blk.Transactions))
+
+	timer := time.AfterFunc(timeout, transition.Cancel)
+	defer timer.Stop()
+
+	for _, tx := range txs {
+		if transition.Cancelled() {
+			return nil, errTraceTimeout
+		}
+
+		hash := tx.Hash()
+		traced := target == nil || *target == hash
+
+		if !traced {
+			if _, err := s.executor.ProcessTransactions(transition, blk.Header.GasLimit, []*types.Transaction{tx}); err != nil {
+				return nil, NewInternalError(err.Error())
+			}
+
+			continue
+		}
+
+		result, err := s.traceTx(transition, blk, index[hash], tx, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		results[index[hash]] = result
+
+		if target != nil {
+			break
+		}
+	}
+
+	// the last transaction may have been aborted
+	if transition.Cancelled() {
+		return nil, errTraceTimeout
+	}
+
+	return results, nil
+}
+
+// traceTx executes the transaction with the tracer of the config
+func (s *RpcServer) traceTx(
+	transition *state.Transition,
+	blk *types.Block,
+	idx int,
+	tx *types.Transaction,
+	cfg *traceConfig,
+) (*txTraceResult, error) {
+	tr, err := newTracer(transition.Txn(), blk, idx, tx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	transition.SetEVMLogger(tr)
+	defer transition.SetEVMLogger(runtime.NewDummyLogger())
+
+	if _, err := s.executor.ProcessTransactions(transition, blk.Header.GasLimit, []*types.Transaction{tx}); err != nil {
+		return nil, NewInternalError(err.Error())
+	}
+
+	result, err := tr.GetResult()
+	if err != nil {
+		return &txTraceResult{TxHash: tx.Hash(), Error: err.Error()}, nil
+	}
+
+	return &txTraceResult{TxHash: tx.Hash(), Result: result}, nil
+}
+
+// newTracer returns the tracer named by the config, or the struct
+// logger if none is named
+func newTracer(
+	txn *state.Txn,
+	blk *types.Block,
+	idx int,
+	tx *types.Transaction,
+	cfg *traceConfig,
+) (tracer.Tracer, error) {
+	if cfg.Tracer == nil {
+		return structlogger.NewStructLogger(txn, &structlogger.Config{
+			EnableMemory:   cfg.EnableMemory,
+			DisableStack:   cfg.DisableStack,
+			DisableStorage: cfg.DisableStorage,
+			Limit:          cfg.Limit,
+		}), nil
+	}
+
+	tr, err := tracer.New(*cfg.Tracer, &tracer.Context{
+		BlockHash: blk.Hash(),
+		TxIndex:   idx,
+		TxHash:    tx.Hash(),
+	}, cfg.TracerConfig)
+	if err != nil {
+		return nil, NewInvalidParamsError(fmt.Sprintf("invalid argument config: tracer %s: %v", *cfg.Tracer, err))
+	}
+
+	return tr, nil
+}
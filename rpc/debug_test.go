@@ -0,0 +1,332 @@
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/blockchain"
+	"github.com/sunvim/dogesyncer/chain"
+	"github.com/sunvim/dogesyncer/crypto"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/helper/hex"
+	"github.com/sunvim/dogesyncer/state"
+	itrie "github.com/sunvim/dogesyncer/state/immutable-trie"
+	"github.com/sunvim/dogesyncer/state/runtime/evm"
+	"github.com/sunvim/dogesyncer/types"
+	"github.com/sunvim/dogesyncer/types/buildroot"
+)
+
+var (
+	// traceReturner returns 0x2a as a 32 bytes word
+	traceReturner = types.StringToAddress("0x5001")
+	// traceReverter reverts without data
+	traceReverter = types.StringToAddress("0x5002")
+	// traceLooper jumps back to its start until it runs out of gas
+	traceLooper = types.StringToAddress("0x5006")
+)
+
+func TestParamTraceConfig(t *testing.T) {
+	cfg, err := paramTraceConfig([]any{"0x1"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if timeout, _ := cfg.timeout(); timeout != defaultTraceTimeout {
+		t.Fatalf("expected default timeout, got %v", timeout)
+	}
+
+	cfg, err = paramTraceConfig([]any{"0x1", map[string]any{
+		"disableStack": true,
+		"enableMemory": true,
+		"limit":        10,
+		"tracer":       "callTracer",
+		"timeout":      "10s",
+		"tracerConfig": map[string]any{"onlyTopCall": true},
+	}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.DisableStack || cfg.DisableStorage || !cfg.EnableMemory || cfg.Limit != 10 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+
+	if cfg.Tracer == nil || *cfg.Tracer != "callTracer" {
+		t.Fatalf("unexpected tracer %v", cfg.Tracer)
+	}
+
+	if string(cfg.TracerConfig) != `{"onlyTopCall":true}` {
+		t.Fatalf("unexpected tracer config %s", cfg.TracerConfig)
+	}
+
+	if timeout, _ := cfg.timeout(); timeout != 10*time.Second {
+		t.Fatalf("expected 10s timeout, got %v", timeout)
+	}
+
+	cfg, err = paramTraceConfig([]any{"0x1", map[string]any{"timeout": "abc"}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cfg.timeout(); err == nil {
+		t.Fatal("expected invalid timeout error")
+	}
+
+	if _, err := paramTraceConfig([]any{"0x1", "abc"}, 1); err == nil {
+		t.Fatal("expected invalid config error")
+	}
+}
+
+// testChain builds a chain of executed and sealed blocks for the tests of
+// the methods running the EVM
+type testChain struct {
+	t *testing.T
+
+	s        *RpcServer
+	executor *state.Executor
+	signer   crypto.TxSigner
+	sender   *ecdsa.PrivateKey
+	miner    *ecdsa.PrivateKey
+	head     *types.Header
+}
+
+// newTestChain returns a chain whose genesis holds the accounts and funds
+// the sender
+func newTestChain(t *testing.T, alloc map[types.Address]*chain.GenesisAccount) *testChain {
+	t.Helper()
+
+	sender, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	miner, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := &chain.Params{Forks: chain.AllForksEnabled, ChainID: 100}
+	st := itrie.NewState(itrie.NewMemoryStorage(), nil)
+
+	executor := state.NewExecutor(params, st, hclog.NewNullLogger())
+	executor.SetRuntime(evm.NewEVM())
+
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	t.Cleanup(func() { db.Close() })
+
+	bc, err := blockchain.NewBlockchain(hclog.NewNullLogger(), db, &chain.Chain{Params: params}, executor, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	executor.GetHash = bc.GetHashHelper
+
+	genesisAlloc := map[types.Address]*chain.GenesisAccount{
+		crypto.PubKeyToAddress(&sender.PublicKey): {Balance: big.NewInt(1e18)},
+	}
+	for addr, account := range alloc {
+		genesisAlloc[addr] = account
+	}
+
+	genesis := &types.Header{
+		Difficulty: 1,
+		GasLimit:   10000000,
+		StateRoot:  executor.WriteGenesis(genesisAlloc),
+		TxRoot:     types.EmptyRootHash,
+		Hash:       types.StringToHash("0x01"),
+	}
+	if err := bc.WriteHeader(genesis); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testChain{
+		t: t,
+		s: &RpcServer{
+			logger:     hclog.NewNullLogger(),
+			blockchain: bc,
+			executor:   executor,
+			config:     &Config{},
+		},
+		executor: executor,
+		signer:   crypto.NewSigner(params.Forks.At(1), uint64(params.ChainID)),
+		sender:   sender,
+		miner:    miner,
+		head:     genesis,
+	}
+}
+
+func (c *testChain) senderAddress() types.Address {
+	return crypto.PubKeyToAddress(&c.sender.PublicKey)
+}
+
+// signTx signs a transaction of the sender, a nil to creates a contract
+func (c *testChain) signTx(nonce uint64, to *types.Address, input []byte) *types.Transaction {
+	c.t.Helper()
+
+	tx, err := c.signer.SignTx(&types.Transaction{
+		Nonce:    nonce,
+		GasPrice: big.NewInt(1),
+		Gas:      1000000,
+		To:       to,
+		Value:    big.NewInt(0),
+		Input:    input,
+	}, c.sender)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	return tx
+}
+
+// addBlock executes the transactions in a new sealed block on top of the
+// head and writes it
+func (c *testChain) addBlock(txs ...*types.Transaction) *types.Header {
+	c.t.Helper()
+
+	parent := c.head
+	header := &types.Header{
+		ParentHash: parent.Hash,
+		Number:     parent.Number + 1,
+		Difficulty: 1,
+		GasLimit:   parent.GasLimit,
+		Timestamp:  parent.Timestamp + 1,
+		Sha3Uncles: types.EmptyUncleHash,
+	}
+
+	// execute the block to fill in the roots
+	transition, err := c.executor.BeginTxn(parent.StateRoot, header, crypto.PubKeyToAddress(&c.miner.PublicKey))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	if _, err := c.executor.ProcessTransactions(transition, header.GasLimit, txs); err != nil {
+		c.t.Fatal(err)
+	}
+
+	_, header.StateRoot = transition.Commit()
+	header.GasUsed = transition.TotalGas()
+	header.ReceiptsRoot = buildroot.CalculateReceiptsRoot(transition.Receipts())
+	header.TxRoot = buildroot.CalculateTransactionsRoot(txs)
+
+	// seal the header with the miner key
+	extra := &types.IstanbulExtra{Validators: []types.Address{}}
+	header.ExtraData = append(make([]byte, types.IstanbulExtraVanity), extra.MarshalRLPTo(nil)...)
+
+	msg, err := types.CalculateHeaderHash(header)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	if extra.Seal, err = crypto.Sign(c.miner, crypto.Keccak256(msg)); err != nil {
+		c.t.Fatal(err)
+	}
+
+	header.ExtraData = append(make([]byte, types.IstanbulExtraVanity), extra.MarshalRLPTo(nil)...)
+
+	block := &types.Block{Header: header, Transactions: txs}
+	if err := c.s.blockchain.WriteBlock(block); err != nil {
+		c.t.Fatal(err)
+	}
+
+	c.head = block.Header
+
+	return c.head
+}
+
+// newTraceTestServer returns a server over a chain whose block 1 calls a
+// returning and a reverting contract
+func newTraceTestServer(t *testing.T) (*RpcServer, []*types.Transaction) {
+	t.Helper()
+
+	c := newTestChain(t, map[types.Address]*chain.GenesisAccount{
+		traceReturner: {Code: hex.MustDecodeHex("0x602a60005260206000f3")},
+		traceReverter: {Code: hex.MustDecodeHex("0x60006000fd")},
+	})
+
+	txs := []*types.Transaction{
+		c.signTx(0, &traceReturner, nil),
+		c.signTx(1, &traceReverter, nil),
+	}
+
+	c.addBlock(txs...)
+
+	return c.s, txs
+}
+
+func TestTraceTransaction(t *testing.T) {
+	s, txs := newTraceTestServer(t)
+
+	cases := []struct {
+		name        string
+		tx          *types.Transaction
+		failed      bool
+		returnValue string
+	}{
+		{"return", txs[0], false, "000000000000000000000000000000000000000000000000000000000000002a"},
+		{"revert", txs[1], true, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := s.TraceTransaction("debug_traceTransaction", c.tx.Hash().String())
+			if err, ok := res.(error); ok {
+				t.Fatal(err)
+			}
+
+			var result struct {
+				Gas         uint64            `json:"gas"`
+				Failed      bool              `json:"failed"`
+				ReturnValue string            `json:"returnValue"`
+				StructLogs  []json.RawMessage `json:"structLogs"`
+			}
+
+			if err := json.Unmarshal(res.(json.RawMessage), &result); err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Failed != c.failed {
+				t.Fatalf("expected failed %v, got %v", c.failed, result.Failed)
+			}
+
+			if result.ReturnValue != c.returnValue {
+				t.Fatalf("expected return value %q, got %q", c.returnValue, result.ReturnValue)
+			}
+
+			if result.Gas == 0 || len(result.StructLogs) == 0 {
+				t.Fatalf("expected an execution trace, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestTraceTransaction_Timeout(t *testing.T) {
+	c := newTestChain(t, map[types.Address]*chain.GenesisAccount{
+		traceLooper: {Code: hex.MustDecodeHex("0x5b600056")},
+	})
+
+	tx := c.signTx(0, &traceLooper, nil)
+	c.addBlock(tx)
+
+	for _, tracer := range []any{nil, "callTracer"} {
+		cfg := map[string]any{"timeout": "1ns"}
+		if tracer != nil {
+			cfg["tracer"] = tracer
+		}
+
+		res := c.s.TraceTransaction("debug_traceTransaction", tx.Hash().String(), cfg)
+		if err, ok := res.(error); !ok || !errors.Is(err, errTraceTimeout) {
+			t.Fatalf("tracer %v: expected %v, got %v", tracer, errTraceTimeout, res)
+		}
+	}
+
+	// the replay of the block is aborted as well
+	res := c.s.TraceBlockByNumber("debug_traceBlockByNumber", "0x1", map[string]any{"timeout": "1ns"})
+	if err, ok := res.(error); !ok || !errors.Is(err, errTraceTimeout) {
+		t.Fatalf("expected %v, got %v", errTraceTimeout, res)
+	}
+}
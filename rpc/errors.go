@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/sunvim/dogesyncer/helper/hex"
+	"github.com/sunvim/dogesyncer/state"
 	"github.com/sunvim/dogesyncer/state/runtime"
 	"github.com/umbracle/go-web3/abi"
 )
@@ -16,6 +18,12 @@ type Error interface {
 	Error() string
 	ErrorCode() int
 }
+
+// DataError is an Error carrying the data member of the error object
+type DataError interface {
+	Error
+	ErrorData() any
+}
 type invalidParamsError struct {
 	err string
 }
@@ -40,6 +48,18 @@ func (e *invalidParamsError) ErrorCode() int {
 	return -32602
 }
 
+type parseError struct {
+	err string
+}
+
+func (e *parseError) Error() string {
+	return e.err
+}
+
+func (e *parseError) ErrorCode() int {
+	return -32700
+}
+
 type invalidRequestError struct {
 	err string
 }
@@ -76,9 +96,46 @@ func (e *methodNotFoundError) ErrorCode() int {
 	return -32601
 }
 
+// stateNotAvailableError is returned for the states the node does not store,
+// a full node prunes the states beyond its retention window
+type stateNotAvailableError struct {
+	err string
+}
+
+func (e *stateNotAvailableError) Error() string {
+	return e.err
+}
+
+func (e *stateNotAvailableError) ErrorCode() int {
+	return -32000
+}
+
+// revertError is returned for a reverted call, the data member is the hex
+// encoded revert data the clients decode custom errors from
+type revertError struct {
+	err  string
+	data string
+}
+
+func (e *revertError) Error() string {
+	return e.err
+}
+
+func (e *revertError) ErrorCode() int {
+	return 3
+}
+
+func (e *revertError) ErrorData() any {
+	return e.data
+}
+
 func NewMethodNotFoundError(method string) *methodNotFoundError {
 	return &methodNotFoundError{fmt.Sprintf("the method %s does not exist/is not available", method)}
 }
+func NewParseError(msg string) *parseError {
+	return &parseError{msg}
+}
+
 func NewInvalidRequestError(msg string) *invalidRequestError {
 	return &invalidRequestError{msg}
 }
@@ -90,15 +147,35 @@ func NewInternalError(msg string) *internalError {
 	return &internalError{msg}
 }
 
+func NewStateNotAvailableError(number uint64) *stateNotAvailableError {
+	return &stateNotAvailableError{fmt.Sprintf("%s at block #%d", state.ErrStateNotAvailable, number)}
+}
+
+// stateError converts the failure to open the state after the block, a
+// state which is not stored is reported as such
+func stateError(number uint64, err error) error {
+	if errors.Is(err, state.ErrStateNotAvailable) {
+		return NewStateNotAvailableError(number)
+	}
+
+	return NewInternalError(err.Error())
+}
+
 func NewSubscriptionNotFoundError(method string) *subscriptionNotFoundError {
 	return &subscriptionNotFoundError{fmt.Sprintf("subscribe method %s not found", method)}
 }
 
+// constructErrorFromRevert returns the error of a reverted call, the reason
+// of an Error(string) revert is added to the message
 func constructErrorFromRevert(result *runtime.ExecutionResult) error {
-	revertErrMsg, unpackErr := abi.UnpackRevertError(result.ReturnValue)
-	if unpackErr != nil {
-		return result.Err
+	err := &revertError{
+		err:  "execution reverted",
+		data: hex.EncodeToHex(result.ReturnValue),
+	}
+
+	if reason, unpackErr := abi.UnpackRevertError(result.ReturnValue); unpackErr == nil {
+		err.err = fmt.Sprintf("%s: %s", err.err, reason)
 	}
 
-	return fmt.Errorf("%w: %s", result.Err, revertErrMsg)
+	return err
 }
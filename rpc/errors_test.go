@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/helper/hex"
+	"github.com/sunvim/dogesyncer/state"
+	"github.com/sunvim/dogesyncer/state/runtime"
+)
+
+func TestStateError(t *testing.T) {
+	err := stateError(7, fmt.Errorf("%w: state root 0x1", state.ErrStateNotAvailable))
+
+	var rpcErr Error
+	assert.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, -32000, rpcErr.ErrorCode())
+	assert.Equal(t, "missing trie node / state not available at block #7", rpcErr.Error())
+
+	err = stateError(7, errors.New("boom"))
+	assert.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, -32603, rpcErr.ErrorCode())
+}
+
+func TestConstructErrorFromRevert(t *testing.T) {
+	// Error("revert reason")
+	data := hex.MustDecodeHex("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000000d" +
+		"72657665727420726561736f6e00000000000000000000000000000000000000")
+
+	err := constructErrorFromRevert(&runtime.ExecutionResult{ReturnValue: data, Err: runtime.ErrExecutionReverted})
+
+	var dataErr DataError
+	assert.True(t, errors.As(err, &dataErr))
+	assert.Equal(t, 3, dataErr.ErrorCode())
+	assert.Equal(t, "execution reverted: revert reason", dataErr.Error())
+	assert.Equal(t, hex.EncodeToHex(data), dataErr.ErrorData())
+
+	// a custom error is only passed on as data
+	custom := hex.MustDecodeHex("0x12345678")
+
+	err = constructErrorFromRevert(&runtime.ExecutionResult{ReturnValue: custom, Err: runtime.ErrExecutionReverted})
+	assert.True(t, errors.As(err, &dataErr))
+	assert.Equal(t, "execution reverted", dataErr.Error())
+	assert.Equal(t, "0x12345678", dataErr.ErrorData())
+
+	resp := NewErrorResponse(1, err)
+	assert.Equal(t, &ObjectError{Code: 3, Message: "execution reverted", Data: "0x12345678"}, resp.Error)
+}
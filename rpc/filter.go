@@ -0,0 +1,235 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// LogQuery is the block range, address and topic criteria logs are
+// matched against
+type LogQuery struct {
+	BlockHash *types.Hash
+	FromBlock BlockNumber
+	ToBlock   BlockNumber
+	Addresses []types.Address
+	Topics    [][]types.Hash
+}
+
+// UnmarshalJSON decodes a filter object. The block range defaults to the
+// latest block and can't be combined with a block hash. The address is a
+// single address or a list of them, each topic position is either null
+// (wildcard), a hash or a list of hashes (or)
+func (q *LogQuery) UnmarshalJSON(data []byte) error {
+	var obj struct {
+		BlockHash *types.Hash `json:"blockHash"`
+		FromBlock string      `json:"fromBlock"`
+		ToBlock   string      `json:"toBlock"`
+		Address   any         `json:"address"`
+		Topics    []any       `json:"topics"`
+	}
+
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	q.BlockHash = obj.BlockHash
+	q.FromBlock = LatestBlockNumber
+	q.ToBlock = LatestBlockNumber
+	q.Addresses = nil
+	q.Topics = nil
+
+	if q.BlockHash != nil && (obj.FromBlock != "" || obj.ToBlock != "") {
+		return fmt.Errorf("cannot specify both blockHash and fromBlock/toBlock")
+	}
+
+	if obj.FromBlock != "" {
+		num, err := StringToBlockNumber(obj.FromBlock)
+		if err != nil {
+			return err
+		}
+
+		q.FromBlock = num
+	}
+
+	if obj.ToBlock != "" {
+		num, err := StringToBlockNumber(obj.ToBlock)
+		if err != nil {
+			return err
+		}
+
+		q.ToBlock = num
+	}
+
+	if obj.Address != nil {
+		switch raw := obj.Address.(type) {
+		case string:
+			addr, err := decodeAddress(raw)
+			if err != nil {
+				return err
+			}
+
+			q.Addresses = append(q.Addresses, addr)
+		case []any:
+			for _, elem := range raw {
+				str, ok := elem.(string)
+				if !ok {
+					return fmt.Errorf("address expected")
+				}
+
+				addr, err := decodeAddress(str)
+				if err != nil {
+					return err
+				}
+
+				q.Addresses = append(q.Addresses, addr)
+			}
+		default:
+			return fmt.Errorf("failed to decode address. Expected either a single address or a list")
+		}
+	}
+
+	for _, topic := range obj.Topics {
+		switch raw := topic.(type) {
+		case nil:
+			// wildcard
+			q.Topics = append(q.Topics, []types.Hash{})
+		case string:
+			hash, err := decodeHash(raw)
+			if err != nil {
+				return err
+			}
+
+			q.Topics = append(q.Topics, []types.Hash{hash})
+		case []any:
+			set := []types.Hash{}
+
+			for _, elem := range raw {
+				str, ok := elem.(string)
+				if !ok {
+					return fmt.Errorf("hash expected")
+				}
+
+				hash, err := decodeHash(str)
+				if err != nil {
+					return err
+				}
+
+				set = append(set, hash)
+			}
+
+			q.Topics = append(q.Topics, set)
+		default:
+			return fmt.Errorf("failed to decode topics. Expected either null, a single topic or a list")
+		}
+	}
+
+	return nil
+}
+
+// Match returns whether the log satisfies the query
+func (q *LogQuery) Match(log *types.Log) bool {
+	if len(q.Addresses) > 0 {
+		match := false
+
+		for _, addr := range q.Addresses {
+			if addr == log.Address {
+				match = true
+
+				break
+			}
+		}
+
+		if !match {
+			return false
+		}
+	}
+
+	if len(q.Topics) > len(log.Topics) {
+		return false
+	}
+
+	for i, set := range q.Topics {
+		if len(set) == 0 {
+			continue
+		}
+
+		match := false
+
+		for _, topic := range set {
+			if topic == log.Topics[i] {
+				match = true
+
+				break
+			}
+		}
+
+		if !match {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchBloom returns whether a block with the given logs bloom may contain
+// logs matching the query
+func (q *LogQuery) MatchBloom(bloom *types.Bloom) bool {
+	if len(q.Addresses) > 0 {
+		match := false
+
+		for _, addr := range q.Addresses {
+			if bloom.IsBytesPresent(addr.Bytes()) {
+				match = true
+
+				break
+			}
+		}
+
+		if !match {
+			return false
+		}
+	}
+
+	for _, set := range q.Topics {
+		if len(set) == 0 {
+			continue
+		}
+
+		match := false
+
+		for _, topic := range set {
+			if bloom.IsBytesPresent(topic.Bytes()) {
+				match = true
+
+				break
+			}
+		}
+
+		if !match {
+			return false
+		}
+	}
+
+	return true
+}
+
+func decodeAddress(str string) (types.Address, error) {
+	var addr types.Address
+
+	if err := addr.UnmarshalText([]byte(str)); err != nil {
+		return addr, fmt.Errorf("invalid address %s: %w", str, err)
+	}
+
+	return addr, nil
+}
+
+func decodeHash(str string) (types.Hash, error) {
+	buf, err := types.ParseBytes(&str)
+	if err != nil || len(buf) != types.HashLength {
+		return types.Hash{}, fmt.Errorf("invalid hash %s", str)
+	}
+
+	return types.BytesToHash(buf), nil
+}
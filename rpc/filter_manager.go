@@ -0,0 +1,216 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/blockchain"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+const (
+	// filterTimeout uninstalls the filters which are not polled for a while
+	filterTimeout = 5 * time.Minute
+)
+
+// pollFilter buffers the changes of an eth_newFilter or eth_newBlockFilter
+// filter until the next eth_getFilterChanges
+type pollFilter struct {
+	id       string
+	query    *LogQuery // nil for block filters
+	hashes   []types.Hash
+	logs     []*Log
+	lastPoll time.Time
+}
+
+func (f *pollFilter) isBlockFilter() bool {
+	return f.query == nil
+}
+
+// filterManager keeps the polled filters up to date with the blockchain
+// events
+type filterManager struct {
+	logger     hclog.Logger
+	blockchain *blockchain.Blockchain
+
+	lock    sync.Mutex
+	filters map[string]*pollFilter
+}
+
+func newFilterManager(logger hclog.Logger, blockchain *blockchain.Blockchain) *filterManager {
+	return &filterManager{
+		logger:     logger.Named("filter"),
+		blockchain: blockchain,
+		filters:    make(map[string]*pollFilter),
+	}
+}
+
+// Run feeds the filters until the context is done
+func (m *filterManager) Run(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(filterTimeout / 5)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.expire()
+			}
+		}
+	}()
+
+	for {
+		err := consumeEvents(ctx, m.blockchain, m.dispatch)
+		if err == nil {
+			return
+		}
+
+		// the changes of the filters have a gap, uninstalling them makes
+		// the clients install them again
+		m.logger.Warn("blockchain events lost, uninstalling the filters", "err", err)
+		m.uninstallAll()
+	}
+}
+
+func (m *filterManager) newBlockFilter() string {
+	return m.install(&pollFilter{})
+}
+
+func (m *filterManager) newLogFilter(query *LogQuery) string {
+	return m.install(&pollFilter{query: query})
+}
+
+func (m *filterManager) install(f *pollFilter) string {
+	f.id = newSubscriptionID()
+	f.lastPoll = time.Now()
+
+	m.lock.Lock()
+	m.filters[f.id] = f
+	m.lock.Unlock()
+
+	return f.id
+}
+
+func (m *filterManager) uninstall(id string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.filters[id]; !ok {
+		return false
+	}
+
+	delete(m.filters, id)
+
+	return true
+}
+
+// changes returns and resets the changes buffered since the last poll
+func (m *filterManager) changes(id string) (any, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	f, ok := m.filters[id]
+	if !ok {
+		return nil, false
+	}
+
+	f.lastPoll = time.Now()
+
+	if f.isBlockFilter() {
+		hashes := f.hashes
+		f.hashes = nil
+
+		if hashes == nil {
+			hashes = []types.Hash{}
+		}
+
+		return hashes, true
+	}
+
+	logs := f.logs
+	f.logs = nil
+
+	if logs == nil {
+		logs = []*Log{}
+	}
+
+	return logs, true
+}
+
+func (m *filterManager) uninstallAll() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.filters = make(map[string]*pollFilter)
+}
+
+func (m *filterManager) expire() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for id, f := range m.filters {
+		if time.Since(f.lastPoll) > filterTimeout {
+			m.logger.Debug("filter timeout", "id", id)
+			delete(m.filters, id)
+		}
+	}
+}
+
+func (m *filterManager) dispatch(ev *blockchain.Event) {
+	if ev.Type == blockchain.EventFork {
+		// side chain blocks are not part of the canonical chain
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if len(m.filters) == 0 {
+		return
+	}
+
+	for _, header := range ev.OldChain {
+		m.appendLogs(header, true)
+	}
+
+	for _, header := range ev.NewChain {
+		for _, f := range m.filters {
+			if f.isBlockFilter() {
+				f.hashes = append(f.hashes, header.Hash)
+			}
+		}
+
+		m.appendLogs(header, false)
+	}
+}
+
+func (m *filterManager) appendLogs(header *types.Header, removed bool) {
+	var logs []*Log
+
+	for _, f := range m.filters {
+		if f.isBlockFilter() || !f.query.MatchBloom(&header.LogsBloom) {
+			continue
+		}
+
+		if logs == nil {
+			var err error
+
+			logs, err = blockLogs(m.blockchain, header, removed)
+			if err != nil {
+				m.logger.Error("read block logs", "number", header.Number, "err", err)
+
+				return
+			}
+		}
+
+		for _, log := range logs {
+			if f.query.Match(log.toLog()) {
+				f.logs = append(f.logs, log)
+			}
+		}
+	}
+}
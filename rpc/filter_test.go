@@ -0,0 +1,177 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/sunvim/dogesyncer/types"
+)
+
+var (
+	addr1  = types.StringToAddress("0x1")
+	addr2  = types.StringToAddress("0x2")
+	topic1 = types.StringToHash("0x4")
+	topic2 = types.StringToHash("0x5")
+)
+
+func TestLogQueryUnmarshal(t *testing.T) {
+	cases := []struct {
+		json  string
+		query *LogQuery
+		err   bool
+	}{
+		{
+			`{}`,
+			&LogQuery{},
+			false,
+		},
+		{
+			`{"address": "` + addr1.String() + `"}`,
+			&LogQuery{Addresses: []types.Address{addr1}},
+			false,
+		},
+		{
+			`{"address": ["` + addr1.String() + `", "` + addr2.String() + `"]}`,
+			&LogQuery{Addresses: []types.Address{addr1, addr2}},
+			false,
+		},
+		{
+			`{"topics": [null, "` + topic1.String() + `", ["` + topic1.String() + `", "` + topic2.String() + `"]]}`,
+			&LogQuery{Topics: [][]types.Hash{{}, {topic1}, {topic1, topic2}}},
+			false,
+		},
+		{
+			`{"address": "0x1"}`,
+			nil,
+			true,
+		},
+		{
+			`{"topics": [1]}`,
+			nil,
+			true,
+		},
+	}
+
+	for _, c := range cases {
+		query := &LogQuery{}
+
+		err := query.UnmarshalJSON([]byte(c.json))
+		if c.err {
+			if err == nil {
+				t.Fatalf("%s: expected error", c.json)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", c.json, err)
+		}
+
+		if len(query.Addresses) != len(c.query.Addresses) {
+			t.Fatalf("%s: unexpected addresses %v", c.json, query.Addresses)
+		}
+
+		for i := range query.Addresses {
+			if query.Addresses[i] != c.query.Addresses[i] {
+				t.Fatalf("%s: unexpected address %d", c.json, i)
+			}
+		}
+
+		if len(query.Topics) != len(c.query.Topics) {
+			t.Fatalf("%s: unexpected topics %v", c.json, query.Topics)
+		}
+
+		for i := range query.Topics {
+			if len(query.Topics[i]) != len(c.query.Topics[i]) {
+				t.Fatalf("%s: unexpected topic set %d", c.json, i)
+			}
+		}
+	}
+}
+
+func TestLogQueryMatch(t *testing.T) {
+	log := &types.Log{
+		Address: addr1,
+		Topics:  []types.Hash{topic1, topic2},
+	}
+
+	cases := []struct {
+		query *LogQuery
+		match bool
+	}{
+		{&LogQuery{}, true},
+		{&LogQuery{Addresses: []types.Address{addr1}}, true},
+		{&LogQuery{Addresses: []types.Address{addr2}}, false},
+		{&LogQuery{Addresses: []types.Address{addr2, addr1}}, true},
+		{&LogQuery{Topics: [][]types.Hash{{topic1}}}, true},
+		{&LogQuery{Topics: [][]types.Hash{{topic2}}}, false},
+		{&LogQuery{Topics: [][]types.Hash{{}, {topic2}}}, true},
+		{&LogQuery{Topics: [][]types.Hash{{topic2, topic1}, {topic2}}}, true},
+		{&LogQuery{Topics: [][]types.Hash{{}, {}, {}}}, false},
+	}
+
+	for i, c := range cases {
+		if c.query.Match(log) != c.match {
+			t.Fatalf("case %d: expected match %v", i, c.match)
+		}
+	}
+}
+
+func TestLogQueryBlockRange(t *testing.T) {
+	hash := types.StringToHash("0x1")
+
+	query := &LogQuery{}
+	if err := query.UnmarshalJSON([]byte(`{"fromBlock": "0x1", "toBlock": "earliest"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if query.FromBlock != 1 || query.ToBlock != EarliestBlockNumber || query.BlockHash != nil {
+		t.Fatalf("unexpected range %d-%d", query.FromBlock, query.ToBlock)
+	}
+
+	if err := query.UnmarshalJSON([]byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if query.FromBlock != LatestBlockNumber || query.ToBlock != LatestBlockNumber {
+		t.Fatal("expected range to default to latest")
+	}
+
+	if err := query.UnmarshalJSON([]byte(`{"blockHash": "` + hash.String() + `"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if query.BlockHash == nil || *query.BlockHash != hash {
+		t.Fatal("expected block hash")
+	}
+
+	err := query.UnmarshalJSON([]byte(`{"blockHash": "` + hash.String() + `", "fromBlock": "0x1"}`))
+	if err == nil {
+		t.Fatal("expected error for block hash with range")
+	}
+}
+
+func TestLogQueryMatchBloom(t *testing.T) {
+	log := &types.Log{
+		Address: addr1,
+		Topics:  []types.Hash{topic1},
+	}
+	bloom := types.CreateBloom([]*types.Receipt{{Logs: []*types.Log{log}}})
+
+	cases := []struct {
+		query *LogQuery
+		match bool
+	}{
+		{&LogQuery{}, true},
+		{&LogQuery{Addresses: []types.Address{addr1}}, true},
+		{&LogQuery{Addresses: []types.Address{addr2}}, false},
+		{&LogQuery{Topics: [][]types.Hash{{}, {topic1}}}, true},
+		{&LogQuery{Topics: [][]types.Hash{{topic2}}}, false},
+	}
+
+	for i, c := range cases {
+		if c.query.MatchBloom(&bloom) != c.match {
+			t.Fatalf("case %d: expected match %v", i, c.match)
+		}
+	}
+}
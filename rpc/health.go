@@ -0,0 +1,115 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sunvim/dogesyncer/helper/progress"
+	"github.com/sunvim/dogesyncer/protocol"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// syncerShim is the part of the block syncer the rpc server reports on
+type syncerShim interface {
+	BestPeer() *protocol.SyncPeer
+	GetSyncProgression() *progress.Progression
+}
+
+// healthStatus is the body of the /health endpoint
+type healthStatus struct {
+	Status string `json:"status"`
+	Head   uint64 `json:"head"`
+}
+
+// readiness is the body of the /ready endpoint
+type readiness struct {
+	Ready    bool                  `json:"ready"`
+	Head     uint64                `json:"head"`
+	HeadHash types.Hash            `json:"headHash"`
+	HeadAge  uint64                `json:"headAge"`
+	BestPeer *uint64               `json:"bestPeer"`
+	Lag      uint64                `json:"lag"`
+	Syncing  *progress.Progression `json:"syncing"`
+	Reasons  []string              `json:"reasons,omitempty"`
+}
+
+// checkReadiness compares the head to the best peer and to the clock. The
+// node is ready when it lags the best peer by at most maxLag blocks and the
+// head is at most maxAge old, a zero limit disables its check.
+func checkReadiness(
+	head *types.Header,
+	bestPeer *uint64,
+	now time.Time,
+	maxLag uint64,
+	maxAge time.Duration,
+) *readiness {
+	r := &readiness{
+		Head:     head.Number,
+		HeadHash: head.Hash,
+		BestPeer: bestPeer,
+	}
+
+	if ts := uint64(now.Unix()); ts > head.Timestamp {
+		r.HeadAge = ts - head.Timestamp
+	}
+
+	if bestPeer != nil && *bestPeer > head.Number {
+		r.Lag = *bestPeer - head.Number
+	}
+
+	if maxLag > 0 && r.Lag > maxLag {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("head is %d blocks behind the best peer, max %d", r.Lag, maxLag))
+	}
+
+	if maxAge > 0 && time.Duration(r.HeadAge)*time.Second > maxAge {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("head is %ds old, max %s", r.HeadAge, maxAge))
+	}
+
+	r.Ready = len(r.Reasons) == 0
+
+	return r
+}
+
+// handleHealth reports the node is alive
+func (s *RpcServer) handleHealth(c *fiber.Ctx) error {
+	return c.JSON(&healthStatus{
+		Status: "ok",
+		Head:   s.blockchain.Header().Number,
+	})
+}
+
+// handleReady reports whether the node is caught up with the network, it
+// answers 503 when it is not so load balancers skip the node
+func (s *RpcServer) handleReady(c *fiber.Ctx) error {
+	var (
+		bestPeer *uint64
+		syncing  *progress.Progression
+	)
+
+	if s.syncer != nil {
+		// no best peer means no connected peer is ahead of us
+		if p := s.syncer.BestPeer(); p != nil {
+			number := p.Number()
+			bestPeer = &number
+		}
+
+		syncing = s.syncer.GetSyncProgression()
+	}
+
+	r := checkReadiness(
+		s.blockchain.Header(),
+		bestPeer,
+		time.Now(),
+		s.config.HealthMaxBlockLag,
+		s.config.HealthMaxHeadAge,
+	)
+	r.Syncing = syncing
+
+	if !r.Ready {
+		c.Status(http.StatusServiceUnavailable)
+	}
+
+	return c.JSON(r)
+}
@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func TestCheckReadiness(t *testing.T) {
+	var (
+		now  = time.Unix(1000, 0)
+		head = &types.Header{Number: 100, Timestamp: 990}
+	)
+
+	peer := func(n uint64) *uint64 {
+		return &n
+	}
+
+	cases := []struct {
+		name     string
+		bestPeer *uint64
+		maxLag   uint64
+		maxAge   time.Duration
+		ready    bool
+		lag      uint64
+	}{
+		{"no peers", nil, 16, time.Minute, true, 0},
+		{"within lag", peer(116), 16, time.Minute, true, 16},
+		{"behind", peer(117), 16, time.Minute, false, 17},
+		{"lag check disabled", peer(1000), 0, time.Minute, true, 900},
+		{"stale head", nil, 16, 5 * time.Second, false, 0},
+		{"age check disabled", nil, 16, 0, true, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := checkReadiness(head, c.bestPeer, now, c.maxLag, c.maxAge)
+
+			assert.Equal(t, c.ready, r.Ready)
+			assert.Equal(t, c.lag, r.Lag)
+			assert.Equal(t, uint64(10), r.HeadAge)
+			assert.Equal(t, !c.ready, len(r.Reasons) > 0)
+		})
+	}
+
+	// a head from the future has no age
+	r := checkReadiness(&types.Header{Timestamp: 2000}, nil, now, 0, time.Second)
+	assert.True(t, r.Ready)
+	assert.Equal(t, uint64(0), r.HeadAge)
+}
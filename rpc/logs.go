@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func paramLogQuery(params []any, idx int) (*LogQuery, error) {
+	if idx >= len(params) {
+		return nil, NewInvalidParamsError("missing value for required argument filter")
+	}
+
+	data, err := json.Marshal(params[idx])
+	if err != nil {
+		return nil, NewInvalidParamsError(fmt.Sprintf("invalid argument filter: %v", err))
+	}
+
+	query := &LogQuery{}
+	if err := query.UnmarshalJSON(data); err != nil {
+		return nil, NewInvalidParamsError(fmt.Sprintf("invalid argument filter: %v", err))
+	}
+
+	return query, nil
+}
+
+// GetLogs returns the logs matching the filter object
+func (s *RpcServer) GetLogs(method string, params ...any) any {
+	query, err := paramLogQuery(params, 0)
+	if err != nil {
+		return err
+	}
+
+	logs, err := s.getLogs(query)
+	if err != nil {
+		return err
+	}
+
+	return logs
+}
+
+func (s *RpcServer) NewFilter(method string, params ...any) any {
+	query, err := paramLogQuery(params, 0)
+	if err != nil {
+		return err
+	}
+
+	return s.filters.newLogFilter(query)
+}
+
+func (s *RpcServer) NewBlockFilter(method string, params ...any) any {
+	return s.filters.newBlockFilter()
+}
+
+// GetFilterChanges returns the block hashes or logs since the last poll
+func (s *RpcServer) GetFilterChanges(method string, params ...any) any {
+	id, err := paramString(params, 0, "id")
+	if err != nil {
+		return err
+	}
+
+	changes, ok := s.filters.changes(id)
+	if !ok {
+		return NewInvalidParamsError("filter not found")
+	}
+
+	return changes
+}
+
+func (s *RpcServer) UninstallFilter(method string, params ...any) any {
+	id, err := paramString(params, 0, "id")
+	if err != nil {
+		return err
+	}
+
+	return s.filters.uninstall(id)
+}
+
+func (s *RpcServer) getLogs(query *LogQuery) ([]*Log, error) {
+	if query.BlockHash != nil {
+		header, ok := s.blockchain.GetHeaderByHash(*query.BlockHash)
+		if !ok {
+			return nil, NewInvalidParamsError("header not found")
+		}
+
+		return s.filterBlockLogs(query, header)
+	}
+
+	head := s.blockchain.Header().Number
+	resolve := func(num BlockNumber) uint64 {
+		switch num {
+		case LatestBlockNumber, PendingBlockNumber:
+			return head
+		case EarliestBlockNumber:
+			return 0
+		default:
+			return uint64(num)
+		}
+	}
+
+	from, to := resolve(query.FromBlock), resolve(query.ToBlock)
+	if to > head {
+		to = head
+	}
+
+	logs := []*Log{}
+	if from > to {
+		return logs, nil
+	}
+
+	if limit := s.config.BlockRangeLimit; limit > 0 && to-from >= limit {
+		return nil, NewInvalidParamsError(
+			fmt.Sprintf("exceed block range limit, range: %d, limit: %d", to-from+1, limit))
+	}
+
+	db := s.blockchain.ChainDB()
+
+	for num := from; num <= to; num++ {
+		hash, bloom, ok := rawdb.ReadBloomIndex(db, num)
+		if !ok {
+			// blocks written before the index existed
+			header, ok := s.blockchain.GetHeaderByNumber(num)
+			if !ok {
+				continue
+			}
+
+			hash, bloom = header.Hash, header.LogsBloom
+		}
+
+		if !query.MatchBloom(&bloom) {
+			continue
+		}
+
+		header, ok := s.blockchain.GetHeaderByHash(hash)
+		if !ok {
+			continue
+		}
+
+		blkLogs, err := s.filterBlockLogs(query, header)
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, blkLogs...)
+	}
+
+	return logs, nil
+}
+
+func (s *RpcServer) filterBlockLogs(query *LogQuery, header *types.Header) ([]*Log, error) {
+	logs, err := blockLogs(s.blockchain, header, false)
+	if err != nil {
+		return nil, NewInternalError(err.Error())
+	}
+
+	res := []*Log{}
+
+	for _, log := range logs {
+		if query.Match(log.toLog()) {
+			res = append(res, log)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,49 @@
+package rpc
+
+import (
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	prometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics represents the rpc metrics
+type Metrics struct {
+	// Time spent serving a request, labelled by method
+	RequestDuration metrics.Histogram
+}
+
+// GetPrometheusMetrics return the rpc metrics instance
+func GetPrometheusMetrics(namespace string, labelsWithValues ...string) *Metrics {
+	labels := []string{}
+
+	for i := 0; i < len(labelsWithValues); i += 2 {
+		labels = append(labels, labelsWithValues[i])
+	}
+
+	return &Metrics{
+		RequestDuration: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "rpc",
+			Name:      "request_duration_seconds",
+			Help:      "Time spent serving a request, labelled by method",
+			Buckets:   stdprometheus.DefBuckets,
+		}, append(labels, "method")).With(labelsWithValues...),
+	}
+}
+
+// NilMetrics will return the non operational rpc metrics
+func NilMetrics() *Metrics {
+	return &Metrics{
+		RequestDuration: discard.NewHistogram(),
+	}
+}
+
+// NewDummyMetrics will return the no nil rpc metrics
+func NewDummyMetrics(metrics *Metrics) *Metrics {
+	if metrics != nil {
+		return metrics
+	}
+
+	return NilMetrics()
+}
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// paramString returns the idx-th param as a string
+func paramString(params []any, idx int, name string) (string, error) {
+	if idx >= len(params) {
+		return "", NewInvalidParamsError(fmt.Sprintf("missing value for required argument %s", name))
+	}
+
+	str, ok := params[idx].(string)
+	if !ok {
+		return "", NewInvalidParamsError(fmt.Sprintf("invalid argument %s: not a string", name))
+	}
+
+	return str, nil
+}
+
+func paramAddress(params []any, idx int, name string) (types.Address, error) {
+	var addr types.Address
+
+	str, err := paramString(params, idx, name)
+	if err != nil {
+		return addr, err
+	}
+
+	if err := addr.UnmarshalText([]byte(str)); err != nil {
+		return addr, NewInvalidParamsError(fmt.Sprintf("invalid argument %s: %v", name, err))
+	}
+
+	return addr, nil
+}
+
+func paramHash(params []any, idx int, name string) (types.Hash, error) {
+	var hash types.Hash
+
+	str, err := paramString(params, idx, name)
+	if err != nil {
+		return hash, err
+	}
+
+	buf, err := types.ParseBytes(&str)
+	if err != nil || len(buf) > types.HashLength {
+		return hash, NewInvalidParamsError(fmt.Sprintf("invalid argument %s: hex string of at most 32 bytes expected", name))
+	}
+
+	return types.BytesToHash(buf), nil
+}
+
+func paramBool(params []any, idx int, name string) (bool, error) {
+	if idx >= len(params) {
+		return false, NewInvalidParamsError(fmt.Sprintf("missing value for required argument %s", name))
+	}
+
+	b, ok := params[idx].(bool)
+	if !ok {
+		return false, NewInvalidParamsError(fmt.Sprintf("invalid argument %s: not a bool", name))
+	}
+
+	return b, nil
+}
+
+// paramBlockNumber returns the idx-th param as a block number, an absent
+// param defaults to latest
+func paramBlockNumber(params []any, idx int, name string) (BlockNumber, error) {
+	if idx >= len(params) || params[idx] == nil {
+		return LatestBlockNumber, nil
+	}
+
+	str, err := paramString(params, idx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	num, err := StringToBlockNumber(str)
+	if err != nil {
+		return 0, NewInvalidParamsError(fmt.Sprintf("invalid argument %s: %v", name, err))
+	}
+
+	return num, nil
+}
+
+// paramBlockNumberOrHash returns the idx-th param as an EIP-1898 block
+// selector, either a number or tag string or a {blockNumber|blockHash}
+// object, an absent param defaults to latest
+func paramBlockNumberOrHash(params []any, idx int, name string) (BlockNumberOrHash, error) {
+	var bnh BlockNumberOrHash
+
+	if idx >= len(params) || params[idx] == nil {
+		num := LatestBlockNumber
+		bnh.BlockNumber = &num
+
+		return bnh, nil
+	}
+
+	data, err := json.Marshal(params[idx])
+	if err != nil {
+		return bnh, NewInvalidParamsError(fmt.Sprintf("invalid argument %s: %v", name, err))
+	}
+
+	if err := bnh.UnmarshalJSON(data); err != nil {
+		return bnh, NewInvalidParamsError(fmt.Sprintf("invalid argument %s: %v", name, err))
+	}
+
+	return bnh, nil
+}
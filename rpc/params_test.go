@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func TestParamBlockNumberOrHash(t *testing.T) {
+	hash := types.StringToHash("0xe0ee62fd4a39a6988e24df0b406b90af71932e1b01d5561400a8eb0003a3da4")
+
+	cases := []struct {
+		param  any
+		number *BlockNumber
+		hash   *types.Hash
+		err    bool
+	}{
+		{nil, numberPtr(LatestBlockNumber), nil, false},
+		{"latest", numberPtr(LatestBlockNumber), nil, false},
+		{"earliest", numberPtr(EarliestBlockNumber), nil, false},
+		{"0x2", numberPtr(2), nil, false},
+		{map[string]any{"blockNumber": "0x2"}, numberPtr(2), nil, false},
+		{map[string]any{"blockHash": hash.String()}, nil, &hash, false},
+		{map[string]any{"blockNumber": "0x2", "blockHash": hash.String()}, nil, nil, true},
+		{map[string]any{}, nil, nil, true},
+		{"abc", nil, nil, true},
+	}
+
+	for _, c := range cases {
+		bnh, err := paramBlockNumberOrHash([]any{c.param}, 0, "block")
+		if c.err {
+			if err == nil {
+				t.Fatalf("%v: expected error", c.param)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%v: unexpected error %v", c.param, err)
+		}
+
+		if (c.number == nil) != (bnh.BlockNumber == nil) ||
+			(c.number != nil && *c.number != *bnh.BlockNumber) {
+			t.Fatalf("%v: unexpected block number", c.param)
+		}
+
+		if (c.hash == nil) != (bnh.BlockHash == nil) ||
+			(c.hash != nil && *c.hash != *bnh.BlockHash) {
+			t.Fatalf("%v: unexpected block hash", c.param)
+		}
+	}
+}
+
+func TestParamAddress(t *testing.T) {
+	if _, err := paramAddress([]any{"0x1"}, 0, "address"); err == nil {
+		t.Fatal("expected error for short address")
+	}
+
+	addr, err := paramAddress([]any{"0x0000000000000000000000000000000000001010"}, 0, "address")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if addr != types.StringToAddress("0x1010") {
+		t.Fatalf("unexpected address %s", addr)
+	}
+}
+
+func numberPtr(n BlockNumber) *BlockNumber {
+	return &n
+}
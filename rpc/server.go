@@ -1,33 +1,82 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v2"
 	"github.com/hashicorp/go-hclog"
 	"github.com/sunvim/dogesyncer/blockchain"
+	"github.com/sunvim/dogesyncer/state"
 )
 
+// Config is the configuration of the rpc server
+type Config struct {
+	Addr string
+	Port string
+
+	// BatchLengthLimit is the max number of requests in a batch, 0 means
+	// no limit
+	BatchLengthLimit uint64
+
+	// BlockRangeLimit is the max number of blocks a log query spans, 0
+	// means no limit
+	BlockRangeLimit uint64
+
+	// EnableWS serves the websocket endpoint on WSPort
+	EnableWS bool
+	WSPort   string
+
+	// HealthMaxBlockLag is the max number of blocks the head lags the best
+	// peer while ready, 0 disables the check
+	HealthMaxBlockLag uint64
+
+	// HealthMaxHeadAge is the max age of the head block while ready, 0
+	// disables the check
+	HealthMaxHeadAge time.Duration
+
+	// EnableUnsafe serves the methods which modify the node, such as
+	// debug_setHead. The endpoints are not authenticated, so they are off
+	// by default.
+	EnableUnsafe bool
+
+	// Metrics records the request latency, nil disables it
+	Metrics *Metrics
+}
+
 type RpcServer struct {
 	logger     hclog.Logger
 	ctx        context.Context
 	blockchain *blockchain.Blockchain
-	addr       string
-	port       string
+	executor   *state.Executor
+	syncer     syncerShim
+	config     *Config
 	routers    map[string]RpcFunc
+	subs       *subscriptionManager
+	filters    *filterManager
+	metrics    *Metrics
 }
 
+// NewRpcServer creates the rpc server, the syncer is optional and only
+// reported by the readiness endpoint
 func NewRpcServer(logger hclog.Logger,
 	blockchain *blockchain.Blockchain,
-	addr, port string) *RpcServer {
+	executor *state.Executor,
+	syncer syncerShim,
+	config *Config) *RpcServer {
 	s := &RpcServer{
 		logger:     logger.Named("rpc"),
-		addr:       addr,
-		port:       port,
+		config:     config,
 		blockchain: blockchain,
+		executor:   executor,
+		syncer:     syncer,
+		metrics:    NewDummyMetrics(config.Metrics),
 	}
+	s.subs = newSubscriptionManager(s.logger, blockchain)
+	s.filters = newFilterManager(s.logger, blockchain)
 	s.initmethods()
 	return s
 }
@@ -42,50 +91,158 @@ func (s *RpcServer) Start(ctx context.Context) error {
 			JSONDecoder:           sonic.Unmarshal,
 		})
 
-		ap := fmt.Sprintf("%s:%s", s.addr, s.port)
-		s.logger.Info("boot", "address", s.addr, "port", s.port)
+		ap := fmt.Sprintf("%s:%s", s.config.Addr, s.config.Port)
+		s.logger.Info("boot", "address", s.config.Addr, "port", s.config.Port)
 
 		// handle rpc request
-		svc.Post("/", func(c *fiber.Ctx) error {
-
-			c.Accepts("application/json")
-			req := reqPool.Get().(*Request)
-			defer reqPool.Put(req)
-			err := c.BodyParser(req)
-			if err != nil {
-				s.logger.Error("route", "err", err)
-				c.Status(fiber.StatusBadRequest).SendString("error request")
-				return nil
-			}
-
-			rsp := resPool.Get().(*Response)
-			defer resPool.Put(rsp)
-
-			exeMethod, ok := s.routers[req.Method]
-			if !ok {
-				s.logger.Error("route", "not support method", req.Method)
-				c.Status(fiber.StatusBadRequest).SendString("not support method")
-				return nil
-			}
-
-			rsp.Result = exeMethod(req.Method, req.Params)
-			rsp.ID = req.ID
-			rsp.Version = req.Version
-
-			c.Status(fiber.StatusOK).JSON(rsp)
-
-			return nil
-		})
+		svc.Post("/", s.handle)
+
+		// health checks
+		svc.Get("/health", s.handleHealth)
+		svc.Get("/ready", s.handleReady)
 
 		svc.Listen(ap)
 	}(ctx)
 
+	go s.filters.Run(ctx)
+
+	if s.config.EnableWS {
+		go s.subs.Run(ctx)
+		go s.serveWS(ctx)
+	}
+
 	return nil
 }
 
+// handle serves a single request object or a batch of them, failures are
+// always answered with JSON-RPC error objects
+func (s *RpcServer) handle(c *fiber.Ctx) error {
+	rsp := s.dispatch(c.Body(), s.handleRequest)
+	if rsp == nil {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	return c.JSON(rsp)
+}
+
+// dispatch decodes the body as a request object or a batch and runs the
+// handler on each request, it returns nil if there is nothing to answer
+// because all requests are notifications
+func (s *RpcServer) dispatch(body []byte, handler func(*Request) any) any {
+	body = bytes.TrimLeft(body, " \t\r\n")
+
+	if len(body) > 0 && body[0] == '[' {
+		return s.dispatchBatch(body, handler)
+	}
+
+	req := reqPool.Get().(*Request)
+	defer reqPool.Put(req)
+
+	*req = Request{}
+	if err := sonic.Unmarshal(body, req); err != nil {
+		s.logger.Error("route", "err", err)
+
+		return NewErrorResponse(nil, NewParseError("invalid json request"))
+	}
+
+	return s.respond(req, handler)
+}
+
+func (s *RpcServer) dispatchBatch(body []byte, handler func(*Request) any) any {
+	var reqs []*Request
+	if err := sonic.Unmarshal(body, &reqs); err != nil {
+		s.logger.Error("route", "err", err)
+
+		return NewErrorResponse(nil, NewParseError("invalid json request"))
+	}
+
+	if len(reqs) == 0 {
+		return NewErrorResponse(nil, NewInvalidRequestError("empty batch request"))
+	}
+
+	if limit := s.config.BatchLengthLimit; limit > 0 && uint64(len(reqs)) > limit {
+		return NewErrorResponse(nil, NewInvalidRequestError(
+			fmt.Sprintf("batch request length too long, max %d", limit)))
+	}
+
+	rsps := make([]any, 0, len(reqs))
+	for _, req := range reqs {
+		if req == nil {
+			rsps = append(rsps, NewErrorResponse(nil, NewInvalidRequestError("invalid request object")))
+
+			continue
+		}
+
+		if rsp := s.respond(req, handler); rsp != nil {
+			rsps = append(rsps, rsp)
+		}
+	}
+
+	if len(rsps) == 0 {
+		return nil
+	}
+
+	return rsps
+}
+
+// respond runs the handler on the request and drops the response of
+// notifications, invalid requests are still answered
+func (s *RpcServer) respond(req *Request, handler func(*Request) any) any {
+	rsp := handler(req)
+	if req.Method != "" && req.IsNotification() {
+		return nil
+	}
+
+	return rsp
+}
+
+func (s *RpcServer) handleRequest(req *Request) any {
+	if req.Method == "" {
+		return NewErrorResponse(req.ID, NewInvalidRequestError("missing method"))
+	}
+
+	exeMethod, ok := s.routers[req.Method]
+	if !ok {
+		s.logger.Debug("route", "not support method", req.Method)
+
+		return NewErrorResponse(req.ID, NewMethodNotFoundError(req.Method))
+	}
+
+	defer func(start time.Time) {
+		s.metrics.RequestDuration.With("method", req.Method).Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return NewResponse(req.ID, exeMethod(req.Method, req.Params...))
+}
+
 func (s *RpcServer) initmethods() {
 	s.routers = map[string]RpcFunc{
-		"eth_blockNumber": s.GetBlockNumber,
-		"eth_getBalance":  s.GetBalance,
+		"eth_blockNumber":           s.GetBlockNumber,
+		"eth_syncing":               s.Syncing,
+		"eth_call":                  s.Call,
+		"eth_estimateGas":           s.EstimateGas,
+		"eth_chainId":               s.ChainId,
+		"eth_gasPrice":              s.GasPrice,
+		"eth_getBlockByNumber":      s.GetBlockByNumber,
+		"eth_getBlockByHash":        s.GetBlockByHash,
+		"eth_getTransactionByHash":  s.GetTransactionByHash,
+		"eth_getTransactionReceipt": s.GetTransactionReceipt,
+		"eth_getBalance":            s.GetBalance,
+		"eth_getCode":               s.GetCode,
+		"eth_getLogs":               s.GetLogs,
+		"eth_newFilter":             s.NewFilter,
+		"eth_newBlockFilter":        s.NewBlockFilter,
+		"eth_getFilterChanges":      s.GetFilterChanges,
+		"eth_uninstallFilter":       s.UninstallFilter,
+		"eth_getStorageAt":          s.GetStorageAt,
+		"eth_getTransactionCount":   s.GetTransactionCount,
+		"net_version":               s.NetVersion,
+		"debug_traceTransaction":    s.TraceTransaction,
+		"debug_traceBlockByNumber":  s.TraceBlockByNumber,
+		"debug_traceBlockByHash":    s.TraceBlockByHash,
+	}
+
+	if s.config.EnableUnsafe {
+		s.routers["debug_setHead"] = s.SetHead
 	}
 }
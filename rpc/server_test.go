@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestDispatchNotifications(t *testing.T) {
+	s := &RpcServer{
+		logger: hclog.NewNullLogger(),
+		config: &Config{},
+		routers: map[string]RpcFunc{
+			"net_version": func(method string, params ...any) any {
+				return "2000"
+			},
+		},
+		metrics: NewDummyMetrics(nil),
+	}
+
+	cases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			"request",
+			`{"jsonrpc":"2.0","method":"net_version","id":1}`,
+			`{"id":1,"jsonrpc":"2.0","result":"2000"}`,
+		},
+		{
+			"null id",
+			`{"jsonrpc":"2.0","method":"net_version","id":null}`,
+			`{"id":null,"jsonrpc":"2.0","result":"2000"}`,
+		},
+		{
+			"notification",
+			`{"jsonrpc":"2.0","method":"net_version"}`,
+			`null`,
+		},
+		{
+			"unknown method notification",
+			`{"jsonrpc":"2.0","method":"eth_unknown"}`,
+			`null`,
+		},
+		{
+			"missing method",
+			`{"jsonrpc":"2.0"}`,
+			`{"id":null,"jsonrpc":"2.0","error":{"code":-32600,"message":"missing method"}}`,
+		},
+		{
+			"mixed batch",
+			`[{"jsonrpc":"2.0","method":"net_version"},{"jsonrpc":"2.0","method":"net_version","id":"a"}]`,
+			`[{"id":"a","jsonrpc":"2.0","result":"2000"}]`,
+		},
+		{
+			"notification batch",
+			`[{"jsonrpc":"2.0","method":"net_version"},{"jsonrpc":"2.0","method":"net_version"}]`,
+			`null`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := json.Marshal(s.dispatch([]byte(c.body), s.handleRequest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, data)
+			}
+		})
+	}
+}
+
+func TestUnsafeMethods(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		s := &RpcServer{config: &Config{EnableUnsafe: enabled}}
+		s.initmethods()
+
+		if _, ok := s.routers["debug_setHead"]; ok != enabled {
+			t.Fatalf("enable unsafe %v: expected debug_setHead served %v, got %v", enabled, enabled, ok)
+		}
+	}
+}
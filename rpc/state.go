@@ -1,48 +1,119 @@
 package rpc
 
 import (
-	"fmt"
-
+	"github.com/sunvim/dogesyncer/state"
 	"github.com/sunvim/dogesyncer/types"
 )
 
 type GetBalanceParams struct {
 	Address types.Address
-	Number  *BlockNumber
+	Block   BlockNumberOrHash
 }
 
 func (gp *GetBalanceParams) Unmarshal(params ...any) error {
-	if len(params) != 2 {
-		return fmt.Errorf("error params")
+	var err error
+
+	gp.Address, err = paramAddress(params, 0, "address")
+	if err != nil {
+		return err
 	}
-	addrs, ok := params[0].(string)
-	if !ok {
-		return fmt.Errorf("error param wallet address")
+
+	gp.Block, err = paramBlockNumberOrHash(params, 1, "block")
+
+	return err
+}
+
+// GetBalance returns the balance of the account at the given block,
+// selected by number, tag or EIP-1898 object
+func (s *RpcServer) GetBalance(method string, params ...any) any {
+	gp := &GetBalanceParams{}
+	if err := gp.Unmarshal(params...); err != nil {
+		return err
 	}
-	gp.Address = types.StringToAddress(addrs)
 
-	nums, ok := params[1].(string)
-	if !ok {
-		return fmt.Errorf("error param block number")
+	txn, err := s.stateAtBlock(gp.Block)
+	if err != nil {
+		return err
 	}
-	var err error
-	gp.Number, err = CreateBlockNumberPointer(nums)
+
+	return argBig(*txn.GetBalance(gp.Address))
+}
+
+func (s *RpcServer) GetTransactionCount(method string, params ...any) any {
+	addr, txn, err := s.accountStateParams(params)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return argUint64(txn.GetNonce(addr))
 }
 
-// not support "earliest" and "pending"
-func (s *RpcServer) GetBalance(method string, params ...any) any {
-	var gp *GetBalanceParams
-	err := gp.Unmarshal(params...)
+func (s *RpcServer) GetCode(method string, params ...any) any {
+	addr, txn, err := s.accountStateParams(params)
+	if err != nil {
+		return err
+	}
+
+	return argBytes(txn.GetCode(addr))
+}
+
+func (s *RpcServer) GetStorageAt(method string, params ...any) any {
+	addr, err := paramAddress(params, 0, "address")
 	if err != nil {
 		return err
 	}
-	if *gp.Number == PendingBlockNumber || *gp.Number == EarliestBlockNumber {
-		return fmt.Errorf("not support pending and earliest block query")
+
+	key, err := paramHash(params, 1, "key")
+	if err != nil {
+		return err
+	}
+
+	block, err := paramBlockNumberOrHash(params, 2, "block")
+	if err != nil {
+		return err
+	}
+
+	txn, err := s.stateAtBlock(block)
+	if err != nil {
+		return err
+	}
+
+	return txn.GetState(addr, key)
+}
+
+// accountStateParams parses the [address, block] params shared by the
+// account state methods
+func (s *RpcServer) accountStateParams(params []any) (types.Address, *state.Txn, error) {
+	addr, err := paramAddress(params, 0, "address")
+	if err != nil {
+		return addr, nil, err
+	}
+
+	block, err := paramBlockNumberOrHash(params, 1, "block")
+	if err != nil {
+		return addr, nil, err
+	}
+
+	txn, err := s.stateAtBlock(block)
+
+	return addr, txn, err
+}
+
+func (s *RpcServer) stateAtBlock(block BlockNumberOrHash) (*state.Txn, error) {
+	header, ok := s.headerByNumberOrHash(block)
+	if !ok {
+		return nil, NewInvalidParamsError("header not found")
+	}
+
+	return s.stateAt(header)
+}
+
+// stateAt returns a read only view of the state after the given block
+func (s *RpcServer) stateAt(header *types.Header) (*state.Txn, error) {
+	snap, err := s.executor.StateAt(header.StateRoot)
+	if err != nil {
+		return nil, stateError(header.Number, err)
 	}
 
-	return nil
+	return state.NewTxn(s.executor.State(), snap), nil
 }
@@ -0,0 +1,273 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/sunvim/dogesyncer/blockchain"
+	"github.com/sunvim/dogesyncer/helper/hex"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+// subscription kinds of eth_subscribe
+const (
+	subscriptionNewHeads = "newHeads"
+	subscriptionLogs     = "logs"
+)
+
+type wsSubscription struct {
+	id    string
+	kind  string
+	query *LogQuery
+	conn  *wsConn
+}
+
+// subscriptionNotification is the eth_subscription message pushed to
+// websocket clients
+type subscriptionNotification struct {
+	Version string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  subscriptionResult `json:"params"`
+}
+
+type subscriptionResult struct {
+	Subscription string `json:"subscription"`
+	Result       any    `json:"result"`
+}
+
+// subscriptionManager fans the blockchain events out to the websocket
+// subscriptions
+type subscriptionManager struct {
+	logger     hclog.Logger
+	blockchain *blockchain.Blockchain
+
+	lock sync.RWMutex
+	subs map[string]*wsSubscription
+}
+
+func newSubscriptionManager(logger hclog.Logger, blockchain *blockchain.Blockchain) *subscriptionManager {
+	return &subscriptionManager{
+		logger:     logger.Named("subscription"),
+		blockchain: blockchain,
+		subs:       make(map[string]*wsSubscription),
+	}
+}
+
+// Run dispatches blockchain events until the context is done
+func (m *subscriptionManager) Run(ctx context.Context) {
+	for {
+		err := consumeEvents(ctx, m.blockchain, m.dispatch)
+		if err == nil {
+			return
+		}
+
+		// the clients can not be told which blocks they missed, they
+		// reconnect and subscribe again
+		m.logger.Warn("blockchain events lost, closing the subscribed connections", "err", err)
+		m.closeAll()
+	}
+}
+
+// consumeEvents dispatches the blockchain events until the context is done.
+// It returns the error of a subscription closed by the blockchain, the
+// events after the last dispatched one are lost then.
+func consumeEvents(ctx context.Context, bc *blockchain.Blockchain, dispatch func(*blockchain.Event)) error {
+	sub := bc.SubscribeEvents()
+	defer sub.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		ev := sub.GetEvent()
+		if ev == nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return sub.Err()
+		}
+
+		dispatch(ev)
+	}
+}
+
+func (m *subscriptionManager) subscribe(kind string, query *LogQuery, conn *wsConn) string {
+	sub := &wsSubscription{
+		id:    newSubscriptionID(),
+		kind:  kind,
+		query: query,
+		conn:  conn,
+	}
+
+	m.lock.Lock()
+	m.subs[sub.id] = sub
+	m.lock.Unlock()
+
+	return sub.id
+}
+
+// unsubscribe removes the subscription if it belongs to the connection
+func (m *subscriptionManager) unsubscribe(id string, conn *wsConn) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	sub, ok := m.subs[id]
+	if !ok || sub.conn != conn {
+		return false
+	}
+
+	delete(m.subs, id)
+
+	return true
+}
+
+// removeConn drops all the subscriptions of a closed connection
+func (m *subscriptionManager) removeConn(conn *wsConn) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for id, sub := range m.subs {
+		if sub.conn == conn {
+			delete(m.subs, id)
+		}
+	}
+}
+
+// closeAll drops all the subscriptions and closes their connections
+func (m *subscriptionManager) closeAll() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for id, sub := range m.subs {
+		sub.conn.close()
+		delete(m.subs, id)
+	}
+}
+
+func (m *subscriptionManager) dispatch(ev *blockchain.Event) {
+	if ev.Type == blockchain.EventFork {
+		// side chain blocks are not part of the canonical chain
+		return
+	}
+
+	m.lock.RLock()
+	subs := make([]*wsSubscription, 0, len(m.subs))
+
+	for _, sub := range m.subs {
+		subs = append(subs, sub)
+	}
+	m.lock.RUnlock()
+
+	if len(subs) == 0 {
+		return
+	}
+
+	// logs of the dropped blocks are sent again with the removed flag
+	for _, header := range ev.OldChain {
+		m.notifyLogs(subs, header, true)
+	}
+
+	for _, header := range ev.NewChain {
+		for _, sub := range subs {
+			if sub.kind == subscriptionNewHeads {
+				m.notify(sub, toHeader(header))
+			}
+		}
+
+		m.notifyLogs(subs, header, false)
+	}
+}
+
+func (m *subscriptionManager) notifyLogs(subs []*wsSubscription, header *types.Header, removed bool) {
+	var logs []*Log
+
+	for _, sub := range subs {
+		if sub.kind != subscriptionLogs || !sub.query.MatchBloom(&header.LogsBloom) {
+			continue
+		}
+
+		if logs == nil {
+			var err error
+
+			logs, err = blockLogs(m.blockchain, header, removed)
+			if err != nil {
+				m.logger.Error("read block logs", "number", header.Number, "err", err)
+
+				return
+			}
+		}
+
+		for _, log := range logs {
+			if sub.query.Match(log.toLog()) {
+				m.notify(sub, log)
+			}
+		}
+	}
+}
+
+func (m *subscriptionManager) notify(sub *wsSubscription, result any) {
+	err := sub.conn.writeJSON(&subscriptionNotification{
+		Version: jsonRPCVersion,
+		Method:  "eth_subscription",
+		Params: subscriptionResult{
+			Subscription: sub.id,
+			Result:       result,
+		},
+	})
+	if err != nil {
+		m.logger.Debug("notify subscription", "id", sub.id, "err", err)
+	}
+}
+
+func newSubscriptionID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+
+	return hex.EncodeToHex(buf)
+}
+
+// blockLogs returns the logs of all the transactions in the block
+func blockLogs(bc *blockchain.Blockchain, header *types.Header, removed bool) ([]*Log, error) {
+	logs := []*Log{}
+
+	if header.TxRoot == types.EmptyRootHash {
+		return logs, nil
+	}
+
+	receipts, err := bc.GetReceiptsByHash(header.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var logIndex uint64
+
+	for txIndex, rcpt := range receipts {
+		for _, elem := range rcpt.Logs {
+			logs = append(logs, &Log{
+				Address:     elem.Address,
+				Topics:      elem.Topics,
+				Data:        argBytes(elem.Data),
+				BlockNumber: argUint64(header.Number),
+				TxHash:      rcpt.TxHash,
+				TxIndex:     argUint64(txIndex),
+				BlockHash:   header.Hash,
+				LogIndex:    argUint64(logIndex),
+				Removed:     removed,
+			})
+			logIndex++
+		}
+	}
+
+	return logs, nil
+}
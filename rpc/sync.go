@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/sunvim/dogesyncer/helper/progress"
+)
+
+// syncingResult is the eth_syncing result while the node catches up
+type syncingResult struct {
+	StartingBlock   argUint64 `json:"startingBlock"`
+	CurrentBlock    argUint64 `json:"currentBlock"`
+	HighestBlock    argUint64 `json:"highestBlock"`
+	BlocksPerSecond float64   `json:"blocksPerSecond"`
+	ETA             argUint64 `json:"eta"` // seconds
+}
+
+// toSyncingResult encodes the progression, false if no sync is running or
+// the highest block is reached
+func toSyncingResult(p *progress.Progression, now time.Time) any {
+	if p == nil || p.CurrentBlock >= p.HighestBlock {
+		return false
+	}
+
+	return &syncingResult{
+		StartingBlock:   argUint64(p.StartingBlock),
+		CurrentBlock:    argUint64(p.CurrentBlock),
+		HighestBlock:    argUint64(p.HighestBlock),
+		BlocksPerSecond: p.Rate(now),
+		ETA:             argUint64(p.ETA(now) / time.Second),
+	}
+}
+
+// Syncing returns the progress of the running catch-up with the network,
+// or false if the node is not syncing
+func (s *RpcServer) Syncing(method string, params ...any) any {
+	if s.syncer == nil {
+		return false
+	}
+
+	return toSyncingResult(s.syncer.GetSyncProgression(), time.Now())
+}
@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/helper/progress"
+)
+
+func TestToSyncingResult(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	assert.Equal(t, false, toSyncingResult(nil, now))
+
+	// the highest block is reached
+	assert.Equal(t, false, toSyncingResult(&progress.Progression{
+		CurrentBlock: 10,
+		HighestBlock: 10,
+	}, now))
+
+	result := toSyncingResult(&progress.Progression{
+		StartingBlock: 100,
+		CurrentBlock:  300,
+		HighestBlock:  1300,
+		StartTime:     now.Add(-10 * time.Second),
+	}, now)
+
+	data, err := json.Marshal(result)
+	assert.NoError(t, err)
+	assert.JSONEq(t,
+		`{"startingBlock":"0x64","currentBlock":"0x12c","highestBlock":"0x514","blocksPerSecond":20,"eta":"0x32"}`,
+		string(data),
+	)
+}
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"github.com/sunvim/dogesyncer/crypto"
+	"github.com/sunvim/dogesyncer/rawdb"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func (s *RpcServer) GetTransactionByHash(method string, params ...any) any {
+	hash, err := paramHash(params, 0, "hash")
+	if err != nil {
+		return err
+	}
+
+	blk, idx, ok := s.lookupTransaction(hash)
+	if !ok {
+		return nil
+	}
+
+	txn := blk.Transactions[idx]
+	s.fillSender(blk.Number(), txn)
+
+	return toTransaction(txn, argUintPtr(blk.Number()), &blk.Header.Hash, &idx)
+}
+
+func (s *RpcServer) GetTransactionReceipt(method string, params ...any) any {
+	hash, err := paramHash(params, 0, "hash")
+	if err != nil {
+		return err
+	}
+
+	blk, idx, ok := s.lookupTransaction(hash)
+	if !ok {
+		return nil
+	}
+
+	receipts, err := s.blockchain.GetReceiptsByHash(blk.Hash())
+	if err != nil || len(receipts) != len(blk.Transactions) {
+		// the block is known but not executed yet
+		return nil
+	}
+
+	var logIndex uint64
+	for _, rcpt := range receipts[:idx] {
+		logIndex += uint64(len(rcpt.Logs))
+	}
+
+	txn := blk.Transactions[idx]
+	s.fillSender(blk.Number(), txn)
+
+	return toReceipt(receipts[idx], txn, blk.Header, uint64(idx), logIndex)
+}
+
+// lookupTransaction returns the canonical block including the transaction
+// and the position of the transaction in it
+func (s *RpcServer) lookupTransaction(hash types.Hash) (*types.Block, int, bool) {
+	db := s.blockchain.ChainDB()
+
+	number, ok := rawdb.ReadTxLookUp(db, hash)
+	if !ok {
+		return nil, 0, false
+	}
+
+	blkHash, ok := rawdb.ReadCanonicalHash(db, number)
+	if !ok {
+		return nil, 0, false
+	}
+
+	blk, ok := s.blockchain.GetBlockByHash(blkHash, true)
+	if !ok {
+		return nil, 0, false
+	}
+
+	for idx, txn := range blk.Transactions {
+		if txn.Hash() == hash {
+			return blk, idx, true
+		}
+	}
+
+	return nil, 0, false
+}
+
+// fillSender recovers the sender of transactions persisted before
+// execution, which are stored without it
+func (s *RpcServer) fillSender(number uint64, txn *types.Transaction) {
+	if txn.From != types.ZeroAddress {
+		return
+	}
+
+	params := s.blockchain.Config().Params
+	signer := crypto.NewSigner(params.Forks.At(number), uint64(params.ChainID))
+
+	from, err := signer.Sender(txn)
+	if err != nil {
+		s.logger.Debug("recover sender", "hash", txn.Hash(), "err", err)
+
+		return
+	}
+
+	txn.From = from
+}
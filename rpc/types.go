@@ -3,9 +3,13 @@ package rpc
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
+	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/bytedance/sonic"
+	"github.com/sunvim/dogesyncer/helper/hex"
 	"github.com/sunvim/dogesyncer/types"
 )
 
@@ -16,6 +20,40 @@ type Request struct {
 	Method  string `json:"method"`
 	Params  []any  `json:"params"`
 	ID      any    `json:"id"`
+
+	// hasID tells a request with a null id apart from a notification
+	hasID bool
+}
+
+// UnmarshalJSON decodes the request and records whether the id member
+// exists at all
+func (r *Request) UnmarshalJSON(data []byte) error {
+	type requestCopy Request
+
+	var raw struct {
+		requestCopy
+		ID json.RawMessage `json:"id"`
+	}
+
+	if err := sonic.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*r = Request(raw.requestCopy)
+	r.ID = nil
+	r.hasID = len(raw.ID) > 0
+
+	if r.hasID {
+		return sonic.Unmarshal(raw.ID, &r.ID)
+	}
+
+	return nil
+}
+
+// IsNotification returns true if the request has no id, notifications
+// must not be answered
+func (r *Request) IsNotification() bool {
+	return !r.hasID
 }
 
 type Response struct {
@@ -24,18 +62,63 @@ type Response struct {
 	Result  any    `json:"result"`
 }
 
+// ErrorResponse is the response of a failed request, the result member
+// must not exist in that case
+type ErrorResponse struct {
+	ID      any          `json:"id"`
+	Version string       `json:"jsonrpc"`
+	Error   *ObjectError `json:"error"`
+}
+
+// ObjectError is the error member of a JSON-RPC response
+type ObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// NewResponse wraps the result of a method into a response, error results
+// are turned into error objects
+func NewResponse(id any, result any) any {
+	err, ok := result.(error)
+	if !ok {
+		return &Response{ID: id, Version: jsonRPCVersion, Result: result}
+	}
+
+	return NewErrorResponse(id, err)
+}
+
+// NewErrorResponse returns an error response, errors without a JSON-RPC
+// error code are reported as internal errors
+func NewErrorResponse(id any, err error) *ErrorResponse {
+	objErr := &ObjectError{
+		Code:    (&internalError{}).ErrorCode(),
+		Message: err.Error(),
+	}
+
+	if rpcErr, ok := err.(Error); ok { //nolint:errorlint
+		objErr.Code = rpcErr.ErrorCode()
+	}
+
+	if dataErr, ok := err.(DataError); ok { //nolint:errorlint
+		objErr.Data = dataErr.ErrorData()
+	}
+
+	return &ErrorResponse{
+		ID:      id,
+		Version: jsonRPCVersion,
+		Error:   objErr,
+	}
+}
+
+const jsonRPCVersion = "2.0"
+
 var (
 	reqPool = &sync.Pool{
 		New: func() any {
 			return &Request{}
 		},
 	}
-
-	resPool = &sync.Pool{
-		New: func() any {
-			return &Response{}
-		},
-	}
 )
 
 const (
@@ -135,3 +218,316 @@ func (b *BlockNumber) UnmarshalJSON(buffer []byte) error {
 
 	return nil
 }
+
+// argUint64 is a uint64 encoded as a 0x-prefixed hex quantity
+type argUint64 uint64
+
+func argUintPtr(n uint64) *argUint64 {
+	v := argUint64(n)
+
+	return &v
+}
+
+func (u argUint64) MarshalText() ([]byte, error) {
+	buf := make([]byte, 2, 10)
+	copy(buf, `0x`)
+	buf = strconv.AppendUint(buf, uint64(u), 16)
+
+	return buf, nil
+}
+
+func (u *argUint64) UnmarshalText(input []byte) error {
+	str := strings.Trim(string(input), "\"")
+
+	num, err := types.ParseUint64orHex(&str)
+	if err != nil {
+		return err
+	}
+
+	*u = argUint64(num)
+
+	return nil
+}
+
+// argBig is a big.Int encoded as a 0x-prefixed hex quantity
+type argBig big.Int
+
+func (a argBig) MarshalText() ([]byte, error) {
+	b := (*big.Int)(&a)
+
+	return []byte("0x" + b.Text(16)), nil
+}
+
+func (a *argBig) UnmarshalText(input []byte) error {
+	str := strings.Trim(string(input), "\"")
+
+	b, err := types.ParseUint256orHex(&str)
+	if err != nil {
+		return err
+	}
+
+	*a = argBig(*b)
+
+	return nil
+}
+
+// argBytes is a byte slice encoded as 0x-prefixed hex data
+type argBytes []byte
+
+func (b argBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToHex(b)), nil
+}
+
+func (b *argBytes) UnmarshalText(input []byte) error {
+	str := strings.Trim(string(input), "\"")
+
+	buf, err := types.ParseBytes(&str)
+	if err != nil {
+		return err
+	}
+
+	*b = buf
+
+	return nil
+}
+
+// transactionOrHash is either a full transaction object or its hash,
+// depending on the full flag of the block query
+type transactionOrHash interface {
+	getHash() types.Hash
+}
+
+type transactionHash types.Hash
+
+func (h transactionHash) getHash() types.Hash {
+	return types.Hash(h)
+}
+
+func (h transactionHash) MarshalText() ([]byte, error) {
+	return []byte(types.Hash(h).String()), nil
+}
+
+type transaction struct {
+	Nonce       argUint64      `json:"nonce"`
+	GasPrice    argBig         `json:"gasPrice"`
+	Gas         argUint64      `json:"gas"`
+	To          *types.Address `json:"to"`
+	Value       argBig         `json:"value"`
+	Input       argBytes       `json:"input"`
+	V           argBig         `json:"v"`
+	R           argBig         `json:"r"`
+	S           argBig         `json:"s"`
+	Hash        types.Hash     `json:"hash"`
+	From        types.Address  `json:"from"`
+	Type        argUint64      `json:"type"`
+	BlockHash   *types.Hash    `json:"blockHash"`
+	BlockNumber *argUint64     `json:"blockNumber"`
+	TxIndex     *argUint64     `json:"transactionIndex"`
+}
+
+func (t transaction) getHash() types.Hash {
+	return t.Hash
+}
+
+func toTransaction(
+	t *types.Transaction,
+	blockNumber *argUint64,
+	blockHash *types.Hash,
+	txIndex *int,
+) *transaction {
+	res := &transaction{
+		Nonce:       argUint64(t.Nonce),
+		GasPrice:    argBig(*bigOrZero(t.GasPrice)),
+		Gas:         argUint64(t.Gas),
+		To:          t.To,
+		Value:       argBig(*bigOrZero(t.Value)),
+		Input:       argBytes(t.Input),
+		V:           argBig(*bigOrZero(t.V)),
+		R:           argBig(*bigOrZero(t.R)),
+		S:           argBig(*bigOrZero(t.S)),
+		Hash:        t.Hash(),
+		From:        t.From,
+		BlockHash:   blockHash,
+		BlockNumber: blockNumber,
+	}
+
+	if txIndex != nil {
+		res.TxIndex = argUintPtr(uint64(*txIndex))
+	}
+
+	return res
+}
+
+func bigOrZero(b *big.Int) *big.Int {
+	if b == nil {
+		return new(big.Int)
+	}
+
+	return b
+}
+
+type header struct {
+	ParentHash   types.Hash    `json:"parentHash"`
+	Sha3Uncles   types.Hash    `json:"sha3Uncles"`
+	Miner        types.Address `json:"miner"`
+	StateRoot    types.Hash    `json:"stateRoot"`
+	TxRoot       types.Hash    `json:"transactionsRoot"`
+	ReceiptsRoot types.Hash    `json:"receiptsRoot"`
+	LogsBloom    types.Bloom   `json:"logsBloom"`
+	Difficulty   argUint64     `json:"difficulty"`
+	Number       argUint64     `json:"number"`
+	GasLimit     argUint64     `json:"gasLimit"`
+	GasUsed      argUint64     `json:"gasUsed"`
+	Timestamp    argUint64     `json:"timestamp"`
+	ExtraData    argBytes      `json:"extraData"`
+	MixHash      types.Hash    `json:"mixHash"`
+	Nonce        types.Nonce   `json:"nonce"`
+	Hash         types.Hash    `json:"hash"`
+}
+
+func toHeader(h *types.Header) *header {
+	return &header{
+		ParentHash:   h.ParentHash,
+		Sha3Uncles:   h.Sha3Uncles,
+		Miner:        h.Miner,
+		StateRoot:    h.StateRoot,
+		TxRoot:       h.TxRoot,
+		ReceiptsRoot: h.ReceiptsRoot,
+		LogsBloom:    h.LogsBloom,
+		Difficulty:   argUint64(h.Difficulty),
+		Number:       argUint64(h.Number),
+		GasLimit:     argUint64(h.GasLimit),
+		GasUsed:      argUint64(h.GasUsed),
+		Timestamp:    argUint64(h.Timestamp),
+		ExtraData:    argBytes(h.ExtraData),
+		MixHash:      h.MixHash,
+		Nonce:        h.Nonce,
+		Hash:         h.Hash,
+	}
+}
+
+type block struct {
+	header
+	TotalDifficulty argBig              `json:"totalDifficulty"`
+	Size            argUint64           `json:"size"`
+	Transactions    []transactionOrHash `json:"transactions"`
+	Uncles          []types.Hash        `json:"uncles"`
+}
+
+func toBlock(b *types.Block, td *big.Int, fullTx bool) *block {
+	h := b.Header
+	res := &block{
+		header:          *toHeader(h),
+		TotalDifficulty: argBig(*bigOrZero(td)),
+		Size:            argUint64(b.Size()),
+		Transactions:    []transactionOrHash{},
+		Uncles:          []types.Hash{},
+	}
+
+	for idx, txn := range b.Transactions {
+		if fullTx {
+			index := idx
+			res.Transactions = append(
+				res.Transactions,
+				toTransaction(txn, argUintPtr(h.Number), &h.Hash, &index),
+			)
+		} else {
+			res.Transactions = append(res.Transactions, transactionHash(txn.Hash()))
+		}
+	}
+
+	for _, uncle := range b.Uncles {
+		res.Uncles = append(res.Uncles, uncle.Hash)
+	}
+
+	return res
+}
+
+type receipt struct {
+	Root              *types.Hash    `json:"root,omitempty"`
+	CumulativeGasUsed argUint64      `json:"cumulativeGasUsed"`
+	LogsBloom         types.Bloom    `json:"logsBloom"`
+	Logs              []*Log         `json:"logs"`
+	Status            *argUint64     `json:"status,omitempty"`
+	TxHash            types.Hash     `json:"transactionHash"`
+	TxIndex           argUint64      `json:"transactionIndex"`
+	BlockHash         types.Hash     `json:"blockHash"`
+	BlockNumber       argUint64      `json:"blockNumber"`
+	GasUsed           argUint64      `json:"gasUsed"`
+	EffectiveGasPrice argBig         `json:"effectiveGasPrice"`
+	ContractAddress   *types.Address `json:"contractAddress"`
+	FromAddr          types.Address  `json:"from"`
+	ToAddr            *types.Address `json:"to"`
+	Type              argUint64      `json:"type"`
+}
+
+// Log is a log entry in the JSON encoding used by receipts and filters
+type Log struct {
+	Address     types.Address `json:"address"`
+	Topics      []types.Hash  `json:"topics"`
+	Data        argBytes      `json:"data"`
+	BlockNumber argUint64     `json:"blockNumber"`
+	TxHash      types.Hash    `json:"transactionHash"`
+	TxIndex     argUint64     `json:"transactionIndex"`
+	BlockHash   types.Hash    `json:"blockHash"`
+	LogIndex    argUint64     `json:"logIndex"`
+	Removed     bool          `json:"removed"`
+}
+
+// toReceipt encodes the receipt of the txIndex-th transaction in the block,
+// logIndex is the block-wide index of the first log of the receipt
+func toReceipt(
+	raw *types.Receipt,
+	txn *types.Transaction,
+	header *types.Header,
+	txIndex uint64,
+	logIndex uint64,
+) *receipt {
+	logs := make([]*Log, 0, len(raw.Logs))
+	for _, elem := range raw.Logs {
+		logs = append(logs, &Log{
+			Address:     elem.Address,
+			Topics:      elem.Topics,
+			Data:        argBytes(elem.Data),
+			BlockNumber: argUint64(header.Number),
+			TxHash:      txn.Hash(),
+			TxIndex:     argUint64(txIndex),
+			BlockHash:   header.Hash,
+			LogIndex:    argUint64(logIndex),
+		})
+		logIndex++
+	}
+
+	res := &receipt{
+		CumulativeGasUsed: argUint64(raw.CumulativeGasUsed),
+		LogsBloom:         raw.LogsBloom,
+		Logs:              logs,
+		TxHash:            txn.Hash(),
+		TxIndex:           argUint64(txIndex),
+		BlockHash:         header.Hash,
+		BlockNumber:       argUint64(header.Number),
+		GasUsed:           argUint64(raw.GasUsed),
+		EffectiveGasPrice: argBig(*bigOrZero(txn.GasPrice)),
+		ContractAddress:   raw.ContractAddress,
+		FromAddr:          txn.From,
+		ToAddr:            txn.To,
+	}
+
+	if raw.Status != nil {
+		res.Status = argUintPtr(uint64(*raw.Status))
+	} else {
+		root := raw.Root
+		res.Root = &root
+	}
+
+	return res
+}
+
+// toLog returns the consensus part of the log
+func (l *Log) toLog() *types.Log {
+	return &types.Log{
+		Address: l.Address,
+		Topics:  l.Topics,
+		Data:    l.Data,
+	}
+}
@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func TestNewResponse(t *testing.T) {
+	cases := []struct {
+		result   any
+		expected string
+	}{
+		{
+			argUint64(16),
+			`{"id":1,"jsonrpc":"2.0","result":"0x10"}`,
+		},
+		{
+			nil,
+			`{"id":1,"jsonrpc":"2.0","result":null}`,
+		},
+		{
+			NewInvalidParamsError("invalid argument"),
+			`{"id":1,"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid argument"}}`,
+		},
+		{
+			errors.New("execution failed"),
+			`{"id":1,"jsonrpc":"2.0","error":{"code":-32603,"message":"execution failed"}}`,
+		},
+	}
+
+	for _, c := range cases {
+		data, err := json.Marshal(NewResponse(1, c.result))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != c.expected {
+			t.Fatalf("expected %s, got %s", c.expected, data)
+		}
+	}
+}
+
+func TestBlockEncoding(t *testing.T) {
+	h := &types.Header{Number: 2, GasLimit: 30, Hash: types.StringToHash("0x2")}
+	blk := &types.Block{Header: h, Transactions: []*types.Transaction{}}
+
+	data, err := json.Marshal(toBlock(blk, big.NewInt(5), false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]any{
+		"number":          "0x2",
+		"gasLimit":        "0x1e",
+		"totalDifficulty": "0x5",
+		"hash":            h.Hash.String(),
+	}
+
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Fatalf("field %s: expected %v, got %v", k, v, fields[k])
+		}
+	}
+
+	if txs, ok := fields["transactions"].([]any); !ok || len(txs) != 0 {
+		t.Fatalf("expected empty transactions, got %v", fields["transactions"])
+	}
+}
@@ -0,0 +1,223 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+
+	// wsSendQueueSize is the number of messages queued per connection
+	// before the client is considered too slow and dropped
+	wsSendQueueSize = 256
+)
+
+var (
+	errWSConnClosed   = errors.New("websocket connection closed")
+	errWSSlowConsumer = errors.New("websocket client too slow")
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// wsConn queues the responses and notifications of a connection, a single
+// writer drains the queue so a slow client never blocks the senders
+type wsConn struct {
+	conn *websocket.Conn
+	send chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func newWSConn(conn *websocket.Conn, queueSize int) *wsConn {
+	return &wsConn{
+		conn: conn,
+		send: make(chan []byte, queueSize),
+		done: make(chan struct{}),
+	}
+}
+
+// writeJSON queues the message, the connection is closed if its queue is
+// full
+func (c *wsConn) writeJSON(v any) error {
+	data, err := sonic.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-c.done:
+		return errWSConnClosed
+	default:
+	}
+
+	select {
+	case c.send <- data:
+		return nil
+	default:
+		c.close()
+
+		return errWSSlowConsumer
+	}
+}
+
+// writeLoop writes the queued messages until the connection is closed
+func (c *wsConn) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case data := <-c.send:
+			if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+				c.close()
+
+				return
+			}
+
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.close()
+
+				return
+			}
+		}
+	}
+}
+
+// close stops the writer and closes the connection, which also ends the
+// read loop
+func (c *wsConn) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// serveWS runs the websocket endpoint, it serves the same methods as the
+// http endpoint plus eth_subscribe and eth_unsubscribe
+func (s *RpcServer) serveWS(ctx context.Context) {
+	addr := fmt.Sprintf("%s:%s", s.config.Addr, s.config.WSPort)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           http.HandlerFunc(s.handleWS),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	s.logger.Info("boot websocket", "address", s.config.Addr, "port", s.config.WSPort)
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		s.logger.Error("websocket server", "err", err)
+	}
+}
+
+func (s *RpcServer) handleWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.logger.Debug("websocket upgrade", "err", err)
+
+		return
+	}
+
+	wc := newWSConn(conn, wsSendQueueSize)
+	go wc.writeLoop()
+
+	defer func() {
+		s.subs.removeConn(wc)
+		wc.close()
+	}()
+
+	for {
+		msgType, msg, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				s.logger.Debug("websocket read", "err", err)
+			}
+
+			return
+		}
+
+		if msgType != websocket.TextMessage {
+			continue
+		}
+
+		rsp := s.dispatch(msg, func(req *Request) any {
+			return s.handleWSRequest(wc, req)
+		})
+		if rsp == nil {
+			continue
+		}
+
+		if err := wc.writeJSON(rsp); err != nil {
+			s.logger.Debug("websocket write", "err", err)
+
+			return
+		}
+	}
+}
+
+func (s *RpcServer) handleWSRequest(wc *wsConn, req *Request) any {
+	switch req.Method {
+	case "eth_subscribe":
+		return NewResponse(req.ID, s.subscribe(wc, req.Params...))
+	case "eth_unsubscribe":
+		return NewResponse(req.ID, s.unsubscribe(wc, req.Params...))
+	default:
+		return s.handleRequest(req)
+	}
+}
+
+func (s *RpcServer) subscribe(wc *wsConn, params ...any) any {
+	kind, err := paramString(params, 0, "subscription")
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case subscriptionNewHeads:
+		return s.subs.subscribe(kind, nil, wc)
+	case subscriptionLogs:
+		query := &LogQuery{}
+
+		if len(params) > 1 && params[1] != nil {
+			data, err := json.Marshal(params[1])
+			if err != nil {
+				return NewInvalidParamsError(err.Error())
+			}
+
+			if err := query.UnmarshalJSON(data); err != nil {
+				return NewInvalidParamsError(err.Error())
+			}
+		}
+
+		return s.subs.subscribe(kind, query, wc)
+	default:
+		return NewSubscriptionNotFoundError(kind)
+	}
+}
+
+func (s *RpcServer) unsubscribe(wc *wsConn, params ...any) any {
+	id, err := paramString(params, 0, "id")
+	if err != nil {
+		return err
+	}
+
+	return s.subs.unsubscribe(id, wc)
+}
@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestWSConn returns both sides of a websocket connection, the writer
+// of the server side is not started
+func newTestWSConn(t *testing.T, queueSize int) (*wsConn, *websocket.Conn) {
+	t.Helper()
+
+	conns := make(chan *wsConn, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+
+			return
+		}
+
+		conns <- newWSConn(conn, queueSize)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return <-conns, client
+}
+
+func TestWSConnSlowConsumer(t *testing.T) {
+	wc, _ := newTestWSConn(t, 2)
+
+	for i := 0; i < 2; i++ {
+		if err := wc.writeJSON(i); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	// the queue is full and nobody drains it
+	if err := wc.writeJSON(2); err != errWSSlowConsumer {
+		t.Fatalf("expected %v, got %v", errWSSlowConsumer, err)
+	}
+
+	select {
+	case <-wc.done:
+	default:
+		t.Fatal("expected the connection to be closed")
+	}
+
+	if err := wc.writeJSON(3); err != errWSConnClosed {
+		t.Fatalf("expected %v, got %v", errWSConnClosed, err)
+	}
+}
+
+func TestWSConnWriteLoop(t *testing.T) {
+	wc, client := newTestWSConn(t, 4)
+
+	for i := 0; i < 3; i++ {
+		if err := wc.writeJSON(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	go wc.writeLoop()
+	defer wc.close()
+
+	for i := 0; i < 3; i++ {
+		_, msg, err := client.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := strconv.Itoa(i); string(msg) != expected {
+			t.Fatalf("expected %s, got %s", expected, msg)
+		}
+	}
+}
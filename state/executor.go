@@ -243,6 +243,8 @@ type Transition struct {
 	// then we wouldn't have to judge any tracing flag
 	evmLogger runtime.EVMLogger
 	needDebug bool
+
+	cancelled uint32
 }
 
 // SetEVMLogger sets a non nil tracer to it
@@ -263,6 +265,18 @@ func (t *Transition) GetEVMLogger() runtime.EVMLogger {
 	return t.evmLogger
 }
 
+// Cancel aborts the running and the following executions of the
+// transition at their next instruction, they fail with
+// runtime.ErrExecutionCancelled. It is safe to call from another goroutine.
+func (t *Transition) Cancel() {
+	atomic.StoreUint32(&t.cancelled, 1)
+}
+
+// Cancelled reports whether the transition was cancelled
+func (t *Transition) Cancelled() bool {
+	return atomic.LoadUint32(&t.cancelled) > 0
+}
+
 // HookTotalGas uses hook to return total gas
 //
 // Use it for testing
@@ -610,6 +624,11 @@ func (t *Transition) apply(msg *types.Transaction) (*runtime.ExecutionResult, er
 	// 6. caller has enough balance to cover asset transfer for **topmost** call
 	txn := t.state
 
+	if t.needDebug {
+		// tracers see the state before the transaction changes it
+		t.evmLogger.CaptureTxStart(txn.Copy(), t.ctx, msg)
+	}
+
 	t.logger.Debug("try to apply transaction",
 		"hash", msg.Hash(), "from", msg.From, "nonce", msg.Nonce, "price", msg.GasPrice.String(),
 		"remainingGas", t.gasPool, "wantGas", msg.Gas)
@@ -685,6 +704,10 @@ func (t *Transition) apply(msg *types.Transaction) (*runtime.ExecutionResult, er
 	// return gas to the pool
 	t.addGasPool(result.GasLeft)
 
+	if t.needDebug {
+		t.evmLogger.CaptureTxEnd(result.GasLeft)
+	}
+
 	return result, nil
 }
 
@@ -747,7 +770,7 @@ func (t *Transition) applyCall(
 	c *runtime.Contract,
 	callType runtime.CallType,
 	host runtime.Host,
-) *runtime.ExecutionResult {
+) (result *runtime.ExecutionResult) {
 	if c.Depth > int(1024)+1 {
 		return &runtime.ExecutionResult{
 			GasLeft: c.Gas,
@@ -755,26 +778,12 @@ func (t *Transition) applyCall(
 		}
 	}
 
-	var result *runtime.ExecutionResult
-
 	if t.needDebug {
-		if c.Depth == 0 {
-			t.evmLogger.CaptureStart(t.Txn(), c.Caller, c.Address, false, c.Input, c.Gas, c.Value)
-
-			defer func(result *runtime.ExecutionResult) {
-				if result != nil {
-					t.evmLogger.CaptureEnd(result.ReturnValue, result.GasUsed, time.Since(time.Now()), result.Err)
-				}
-			}(result)
-		} else {
-			t.evmLogger.CaptureEnter(int(evm.RuntimeType2OpCode(callType)), c.Caller, c.Address, c.Input, c.Gas, c.Value)
+		t.captureCall(c, callType, false)
 
-			defer func(result *runtime.ExecutionResult) {
-				if result != nil {
-					t.evmLogger.CaptureExit(result.ReturnValue, result.GasUsed, result.Err)
-				}
-			}(result)
-		}
+		defer func(start time.Time) {
+			t.captureCallEnd(c, result, start)
+		}(time.Now())
 	}
 
 	//nolint:ifshort
@@ -818,7 +827,7 @@ func (t *Transition) hasCodeOrNonce(addr types.Address) bool {
 	return false
 }
 
-func (t *Transition) applyCreate(c *runtime.Contract, host runtime.Host) *runtime.ExecutionResult {
+func (t *Transition) applyCreate(c *runtime.Contract, host runtime.Host) (result *runtime.ExecutionResult) {
 	gasLimit := c.Gas
 
 	if c.Depth > int(1024)+1 {
@@ -848,26 +857,12 @@ func (t *Transition) applyCreate(c *runtime.Contract, host runtime.Host) *runtim
 		t.state.IncrNonce(c.Address)
 	}
 
-	var result *runtime.ExecutionResult
-
 	if t.needDebug {
-		if c.Depth == 0 {
-			t.evmLogger.CaptureStart(t.Txn(), c.Caller, c.Address, true, c.Input, c.Gas, c.Value)
-
-			defer func(result *runtime.ExecutionResult) {
-				if result != nil {
-					t.evmLogger.CaptureEnd(result.ReturnValue, result.GasUsed, time.Since(time.Now()), result.Err)
-				}
-			}(result)
-		} else {
-			t.evmLogger.CaptureEnter(int(evm.RuntimeType2OpCode(c.Type)), c.Caller, c.Address, c.Input, c.Gas, c.Value)
+		t.captureCall(c, c.Type, true)
 
-			defer func(result *runtime.ExecutionResult) {
-				if result != nil {
-					t.evmLogger.CaptureExit(result.ReturnValue, result.GasUsed, result.Err)
-				}
-			}(result)
-		}
+		defer func(start time.Time) {
+			t.captureCallEnd(c, result, start)
+		}(time.Now())
 	}
 
 	// Transfer the value
@@ -920,6 +915,38 @@ func (t *Transition) applyCreate(c *runtime.Contract, host runtime.Host) *runtim
 	return result
 }
 
+// captureCall reports the start of a call frame to the tracer, the top
+// call frame of a transaction has depth 1
+func (t *Transition) captureCall(c *runtime.Contract, callType runtime.CallType, create bool) {
+	if c.Depth == 1 {
+		t.evmLogger.CaptureStart(t.Txn(), c.Caller, c.Address, create, c.Input, c.Gas, c.Value)
+
+		return
+	}
+
+	t.evmLogger.CaptureEnter(int(evm.RuntimeType2OpCode(callType)), c.Caller, c.Address, c.Input, c.Gas, c.Value)
+}
+
+// captureCallEnd reports the result of a call frame to the tracer
+func (t *Transition) captureCallEnd(c *runtime.Contract, result *runtime.ExecutionResult, start time.Time) {
+	if result == nil {
+		return
+	}
+
+	var gasUsed uint64
+	if result.GasLeft < c.Gas {
+		gasUsed = c.Gas - result.GasLeft
+	}
+
+	if c.Depth == 1 {
+		t.evmLogger.CaptureEnd(result.ReturnValue, gasUsed, time.Since(start), result.Err)
+
+		return
+	}
+
+	t.evmLogger.CaptureExit(result.ReturnValue, gasUsed, result.Err)
+}
+
 func (t *Transition) SetStorage(
 	addr types.Address,
 	key types.Hash,
@@ -0,0 +1,641 @@
+package itrie
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/state"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+const (
+	// flatDiffLayers is the number of block diffs kept in memory on top of
+	// the flat state on disk
+	flatDiffLayers = 64
+
+	// MinPruneRetain is the smallest retention of a pruner running next to
+	// the flat state. The disk layer trails the head by up to flatDiffLayers
+	// blocks and is generated from the trie at its root, so that state must
+	// outlive the diffs on top of it, with room for diffs not applied yet.
+	MinPruneRetain = 2 * flatDiffLayers
+)
+
+// keys of the flat state records in ethdb.SnapAccDBI, they never collide
+// with the account hashes
+var (
+	flatRootKey      = []byte("root")      // state root of the disk layer
+	flatGeneratorKey = []byte("generator") // last generated account hash
+)
+
+// flatLayer answers the reads of the state at its root. A read the layer
+// can not answer is not known and goes to the trie instead, an absent key
+// is known with a nil value.
+type flatLayer interface {
+	Root() types.Hash
+	account(hash types.Hash) ([]byte, bool, error)
+	storage(account, slot types.Hash) ([]byte, bool, error)
+}
+
+// flatDisk is the flat state stored in the database. It is generated from
+// the trie in the background, in account hash order.
+type flatDisk struct {
+	db   ethdb.Database
+	root types.Hash
+
+	// last generated account hash, nil once the generation is done
+	marker []byte
+
+	// a newer disk layer replaced this one
+	stale bool
+}
+
+func (d *flatDisk) Root() types.Hash {
+	return d.root
+}
+
+// generated reports whether the account and its storage were generated
+func (d *flatDisk) generated(hash types.Hash) bool {
+	return d.marker == nil || bytes.Compare(hash.Bytes(), d.marker) <= 0
+}
+
+func (d *flatDisk) account(hash types.Hash) ([]byte, bool, error) {
+	if d.stale || !d.generated(hash) {
+		return nil, false, nil
+	}
+
+	data, ok, err := d.db.Get(ethdb.SnapAccDBI, hash.Bytes())
+	if err != nil || !ok {
+		return nil, err == nil, err
+	}
+
+	return data, true, nil
+}
+
+func (d *flatDisk) storage(account, slot types.Hash) ([]byte, bool, error) {
+	if d.stale || !d.generated(account) {
+		return nil, false, nil
+	}
+
+	data, ok, err := d.db.Get(ethdb.SnapStoDBI, flatStorageKey(account, slot))
+	if err != nil || !ok {
+		return nil, err == nil, err
+	}
+
+	return data, true, nil
+}
+
+// flatDiff is the changes of a block on top of the state of its parent
+type flatDiff struct {
+	parent flatLayer
+	root   types.Hash
+
+	accounts  map[types.Hash][]byte                // nil for the deleted accounts
+	storages  map[types.Hash]map[types.Hash][]byte // nil for the deleted slots
+	destructs map[types.Hash]struct{}              // accounts whose storage was wiped first
+
+	// the diff was flattened or dropped with its fork
+	stale bool
+}
+
+func (d *flatDiff) Root() types.Hash {
+	return d.root
+}
+
+func (d *flatDiff) account(hash types.Hash) ([]byte, bool, error) {
+	if d.stale {
+		return nil, false, nil
+	}
+
+	if data, ok := d.accounts[hash]; ok {
+		return data, true, nil
+	}
+
+	return d.parent.account(hash)
+}
+
+func (d *flatDiff) storage(account, slot types.Hash) ([]byte, bool, error) {
+	if d.stale {
+		return nil, false, nil
+	}
+
+	if data, ok := d.storages[account][slot]; ok {
+		return data, true, nil
+	}
+
+	if _, ok := d.destructs[account]; ok {
+		return nil, true, nil
+	}
+
+	return d.parent.storage(account, slot)
+}
+
+// FlatState keeps the accounts and the storage slots of the recent states
+// by key, so reading them does not walk the tries. The state of the disk
+// layer is stored in the database, the blocks on top of it are diff layers
+// in memory until they are flattened into the disk layer.
+type FlatState struct {
+	logger hclog.Logger
+	db     ethdb.Database
+	state  *State
+
+	// lock guards the layers, and the disk layer reads against its writes
+	lock   sync.RWMutex
+	disk   *flatDisk
+	layers map[types.Hash]flatLayer
+
+	// genLock serializes the generation with the flattening
+	genLock sync.Mutex
+	genCh   chan struct{}
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewFlatState returns the flat state of the state, the disk layer is
+// rebuilt from the trie of the head root unless it is already at the head
+func NewFlatState(logger hclog.Logger, st *State, db ethdb.Database, head types.Hash) (*FlatState, error) {
+	f := &FlatState{
+		logger:  logger.Named("flat"),
+		db:      db,
+		state:   st,
+		layers:  map[types.Hash]flatLayer{},
+		genCh:   make(chan struct{}, 1),
+		closeCh: make(chan struct{}),
+	}
+
+	root, ok, err := db.Get(ethdb.SnapAccDBI, flatRootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok || types.BytesToHash(root) != head {
+		if err := f.rebuild(head); err != nil {
+			return nil, err
+		}
+	} else {
+		marker, ok, err := db.Get(ethdb.SnapAccDBI, flatGeneratorKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			marker = nil
+		} else if marker == nil {
+			marker = []byte{}
+		}
+
+		f.setDisk(&flatDisk{db: db, root: head, marker: marker})
+	}
+
+	f.wg.Add(1)
+
+	go f.generate()
+
+	f.triggerGeneration()
+
+	st.flat = f
+
+	return f, nil
+}
+
+// Generated reports whether the disk layer is fully generated
+func (f *FlatState) Generated() bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.disk.marker == nil
+}
+
+// Close stops the generation and flattens the diff layers up to the head,
+// so the flat state is reused on the next start
+func (f *FlatState) Close(head types.Hash) error {
+	close(f.closeCh)
+	f.wg.Wait()
+
+	f.genLock.Lock()
+	defer f.genLock.Unlock()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for f.disk.root != head {
+		top, ok := f.layers[head].(*flatDiff)
+		if !ok {
+			// the head is not a layer, the next start rebuilds the flat state
+			return nil
+		}
+
+		bottom := top
+		for {
+			parent, ok := bottom.parent.(*flatDiff)
+			if !ok {
+				break
+			}
+
+			bottom = parent
+		}
+
+		if err := f.flatten(bottom); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *FlatState) setDisk(disk *flatDisk) {
+	f.disk = disk
+	f.layers[disk.root] = disk
+}
+
+// snapshotAt returns the snapshot of the root, nil if no layer is at the root
+func (f *FlatState) snapshotAt(root types.Hash) state.Snapshot {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	layer, ok := f.layers[root]
+	if !ok {
+		return nil
+	}
+
+	return &flatSnapshot{flat: f, layer: layer}
+}
+
+func (f *FlatState) account(layer flatLayer, hash types.Hash) ([]byte, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	data, known, err := layer.account(hash)
+	if err != nil {
+		f.logger.Error("failed to read account", "hash", hash, "err", err)
+
+		return nil, false
+	}
+
+	return data, known
+}
+
+func (f *FlatState) storage(layer flatLayer, account, slot types.Hash) ([]byte, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	data, known, err := layer.storage(account, slot)
+	if err != nil {
+		f.logger.Error("failed to read storage", "account", account, "slot", slot, "err", err)
+
+		return nil, false
+	}
+
+	return data, known
+}
+
+// update adds the diff of the commit on top of the parent layer, it returns
+// nil if the parent layer is gone
+func (f *FlatState) update(parent *flatSnapshot, root types.Hash, objs []*state.Object, t *Trie) state.Snapshot {
+	if root == parent.layer.Root() {
+		return parent
+	}
+
+	diff := &flatDiff{
+		parent:    parent.layer,
+		root:      root,
+		accounts:  make(map[types.Hash][]byte, len(objs)),
+		storages:  map[types.Hash]map[types.Hash][]byte{},
+		destructs: map[types.Hash]struct{}{},
+	}
+
+	ar := stateArenaPool.Get()
+	defer stateArenaPool.Put(ar)
+
+	for _, obj := range objs {
+		hash := types.BytesToHash(hashit(obj.Address.Bytes()))
+
+		if obj.Deleted {
+			diff.accounts[hash] = nil
+			diff.destructs[hash] = struct{}{}
+
+			continue
+		}
+
+		// the storage is built on another root when the account was
+		// destructed and created again
+		prevRoot := types.EmptyRootHash
+
+		if data, ok := parent.Get(hash.Bytes()); ok {
+			var prev state.Account
+			if err := prev.UnmarshalRlp(data); err == nil {
+				prevRoot = prev.Root
+			}
+		}
+
+		if obj.Root != prevRoot {
+			diff.destructs[hash] = struct{}{}
+		}
+
+		data, _ := t.Get(hash.Bytes())
+		diff.accounts[hash] = data
+
+		if len(obj.Storage) == 0 {
+			continue
+		}
+
+		slots := make(map[types.Hash][]byte, len(obj.Storage))
+
+		for _, entry := range obj.Storage {
+			slot := types.BytesToHash(hashit(entry.Key))
+
+			if entry.Deleted {
+				slots[slot] = nil
+			} else {
+				slots[slot] = encodeStorageValue(ar, entry.Val)
+			}
+		}
+
+		diff.storages[hash] = slots
+
+		ar.Reset()
+	}
+
+	f.lock.Lock()
+
+	if layer, ok := f.layers[root]; ok {
+		// the block was executed before
+		f.lock.Unlock()
+
+		return &flatSnapshot{flat: f, layer: layer}
+	}
+
+	if _, ok := f.layers[parent.layer.Root()]; !ok {
+		f.lock.Unlock()
+
+		return nil
+	}
+
+	f.layers[root] = diff
+	f.lock.Unlock()
+
+	f.cap(diff)
+
+	return &flatSnapshot{flat: f, layer: diff}
+}
+
+// cap flattens the bottom diffs below the top while there are too many. It
+// is skipped while a generation chunk runs and caught up after it.
+func (f *FlatState) cap(top *flatDiff) {
+	if !f.genLock.TryLock() {
+		return
+	}
+	defer f.genLock.Unlock()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for {
+		if top.stale {
+			return
+		}
+
+		depth, bottom := 1, top
+		for {
+			parent, ok := bottom.parent.(*flatDiff)
+			if !ok {
+				break
+			}
+
+			depth, bottom = depth+1, parent
+		}
+
+		if depth <= flatDiffLayers {
+			return
+		}
+
+		if err := f.flatten(bottom); err != nil {
+			f.logger.Error("failed to flatten the flat state, rebuild it", "err", err)
+
+			if err := f.rebuildLocked(top.root); err != nil {
+				f.logger.Error("failed to rebuild the flat state", "err", err)
+			}
+
+			return
+		}
+	}
+}
+
+// flatten writes the bottom diff into the disk layer, the diffs which do
+// not descend from it are dropped. The generated part of the disk layer is
+// updated, the rest is generated from the new root later on.
+func (f *FlatState) flatten(bottom *flatDiff) error {
+	disk := f.disk
+	batch := f.db.Batch()
+	defer batch.Discard()
+
+	for hash := range bottom.destructs {
+		if !disk.generated(hash) {
+			continue
+		}
+
+		if err := f.wipeStorage(batch, hash); err != nil {
+			return err
+		}
+	}
+
+	for hash, data := range bottom.accounts {
+		if !disk.generated(hash) {
+			continue
+		}
+
+		if data == nil {
+			batch.Remove(ethdb.SnapAccDBI, hash.Bytes())
+		} else {
+			batch.Set(ethdb.SnapAccDBI, hash.Bytes(), data)
+		}
+	}
+
+	for account, slots := range bottom.storages {
+		if !disk.generated(account) {
+			continue
+		}
+
+		for slot, data := range slots {
+			if data == nil {
+				batch.Remove(ethdb.SnapStoDBI, flatStorageKey(account, slot))
+			} else {
+				batch.Set(ethdb.SnapStoDBI, flatStorageKey(account, slot), data)
+			}
+		}
+	}
+
+	batch.Set(ethdb.SnapAccDBI, flatRootKey, bottom.root.Bytes())
+
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	disk.stale = true
+	bottom.stale = true
+
+	delete(f.layers, disk.root)
+	f.setDisk(&flatDisk{db: f.db, root: bottom.root, marker: disk.marker})
+
+	for _, layer := range f.layers {
+		if diff, ok := layer.(*flatDiff); ok && diff.parent == bottom {
+			diff.parent = f.disk
+		}
+	}
+
+	// the forks of the flattened diff are left on the old disk layer
+	for root, layer := range f.layers {
+		if diff, ok := layer.(*flatDiff); ok && !f.onDisk(diff) {
+			diff.stale = true
+			delete(f.layers, root)
+		}
+	}
+
+	return nil
+}
+
+// onDisk reports whether the diff is on top of the current disk layer
+func (f *FlatState) onDisk(diff *flatDiff) bool {
+	for {
+		switch parent := diff.parent.(type) {
+		case *flatDiff:
+			diff = parent
+		case *flatDisk:
+			return parent == f.disk
+		default:
+			return false
+		}
+	}
+}
+
+// wipeStorage removes the stored slots of the account on the disk layer,
+// they are listed by the storage trie of the account
+func (f *FlatState) wipeStorage(batch ethdb.Batch, hash types.Hash) error {
+	data, ok, err := f.db.Get(ethdb.SnapAccDBI, hash.Bytes())
+	if err != nil || !ok {
+		return err
+	}
+
+	var account state.Account
+	if err := account.UnmarshalRlp(data); err != nil {
+		return err
+	}
+
+	return walkTrie(f.state.storage, account.Root, nil, func(slot, _ []byte) (bool, error) {
+		batch.Remove(ethdb.SnapStoDBI, flatStorageKey(hash, types.BytesToHash(slot)))
+
+		return true, nil
+	})
+}
+
+// flatSnapshot reads the state at the root of its layer, the keys the
+// layer does not know are read from the trie
+type flatSnapshot struct {
+	flat  *FlatState
+	layer flatLayer
+
+	once sync.Once
+	trie *Trie
+	err  error
+}
+
+func (s *flatSnapshot) openTrie() (*Trie, error) {
+	s.once.Do(func() {
+		s.trie, s.err = s.flat.state.trieAt(s.layer.Root())
+	})
+
+	return s.trie, s.err
+}
+
+func (s *flatSnapshot) Get(k []byte) ([]byte, bool) {
+	if data, known := s.flat.account(s.layer, types.BytesToHash(k)); known {
+		return data, data != nil
+	}
+
+	t, err := s.openTrie()
+	if err != nil {
+		return nil, false
+	}
+
+	return t.Get(k)
+}
+
+// StorageAt returns the storage of the account at this state, the root is
+// the storage root of the account
+func (s *flatSnapshot) StorageAt(addrHash types.Hash, root types.Hash) (state.Snapshot, error) {
+	return &flatStorage{
+		flat:    s.flat,
+		layer:   s.layer,
+		account: addrHash,
+		root:    root,
+	}, nil
+}
+
+func (s *flatSnapshot) Commit(objs []*state.Object) (state.Snapshot, []byte) {
+	t, err := s.openTrie()
+	if err != nil {
+		panic(err)
+	}
+
+	snap, root := t.Commit(objs)
+
+	nt, ok := snap.(*Trie)
+	if !ok {
+		panic("invalid type assertion")
+	}
+
+	if flat := s.flat.update(s, types.BytesToHash(root), objs, nt); flat != nil {
+		return flat, root
+	}
+
+	return snap, root
+}
+
+// flatStorage reads the storage of an account at the root of the layer
+type flatStorage struct {
+	flat    *FlatState
+	layer   flatLayer
+	account types.Hash
+	root    types.Hash
+
+	once sync.Once
+	trie *Trie
+	err  error
+}
+
+func (s *flatStorage) openTrie() (*Trie, error) {
+	s.once.Do(func() {
+		s.trie, s.err = s.flat.state.trieAt(s.root)
+	})
+
+	return s.trie, s.err
+}
+
+func (s *flatStorage) Get(k []byte) ([]byte, bool) {
+	if data, known := s.flat.storage(s.layer, s.account, types.BytesToHash(k)); known {
+		return data, data != nil
+	}
+
+	t, err := s.openTrie()
+	if err != nil {
+		return nil, false
+	}
+
+	return t.Get(k)
+}
+
+func (s *flatStorage) Commit(objs []*state.Object) (state.Snapshot, []byte) {
+	t, err := s.openTrie()
+	if err != nil {
+		panic(err)
+	}
+
+	return t.Commit(objs)
+}
+
+func flatStorageKey(account, slot types.Hash) []byte {
+	return append(append(make([]byte, 0, 2*types.HashLength), account.Bytes()...), slot.Bytes()...)
+}
@@ -0,0 +1,291 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/state"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+const (
+	// flatGenerateChunk is the number of records generated per batch, the
+	// flattening waits for a running batch
+	flatGenerateChunk = 10000
+)
+
+// rebuild drops the flat state and starts over at the root, the generation
+// has to be triggered afterwards
+func (f *FlatState) rebuild(root types.Hash) error {
+	for _, layer := range f.layers {
+		switch l := layer.(type) {
+		case *flatDisk:
+			l.stale = true
+		case *flatDiff:
+			l.stale = true
+		}
+	}
+
+	f.layers = map[types.Hash]flatLayer{}
+
+	if err := f.db.Clear(ethdb.SnapAccDBI); err != nil {
+		return err
+	}
+
+	if err := f.db.Clear(ethdb.SnapStoDBI); err != nil {
+		return err
+	}
+
+	batch := f.db.Batch()
+	defer batch.Discard()
+	batch.Set(ethdb.SnapAccDBI, flatRootKey, root.Bytes())
+	batch.Set(ethdb.SnapAccDBI, flatGeneratorKey, []byte{})
+
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	f.setDisk(&flatDisk{db: f.db, root: root, marker: []byte{}})
+
+	return nil
+}
+
+// rebuildLocked rebuilds the flat state while the locks are held
+func (f *FlatState) rebuildLocked(root types.Hash) error {
+	if err := f.rebuild(root); err != nil {
+		return err
+	}
+
+	f.triggerGeneration()
+
+	return nil
+}
+
+func (f *FlatState) triggerGeneration() {
+	select {
+	case f.genCh <- struct{}{}:
+	default:
+	}
+}
+
+// generate fills the disk layer from its trie in the background
+func (f *FlatState) generate() {
+	defer f.wg.Done()
+
+	for {
+		select {
+		case <-f.closeCh:
+			return
+		case <-f.genCh:
+		}
+
+		f.logger.Info("generating flat state")
+
+		for {
+			done, err := f.generateChunk()
+			if err != nil {
+				f.logger.Error("failed to generate flat state", "err", err)
+
+				break
+			}
+
+			if done {
+				f.logger.Info("flat state generated")
+
+				break
+			}
+
+			select {
+			case <-f.closeCh:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// generateChunk writes the next accounts after the marker with their
+// storage, it reports whether the disk layer is fully generated
+func (f *FlatState) generateChunk() (bool, error) {
+	f.genLock.Lock()
+	defer f.genLock.Unlock()
+
+	f.lock.RLock()
+	disk := f.disk
+	f.lock.RUnlock()
+
+	if disk.marker == nil {
+		return true, nil
+	}
+
+	var (
+		batch   = f.db.Batch()
+		count   = 0
+		last    []byte
+		stopped = false
+	)
+
+	err := walkTrie(f.state.storage, disk.root, disk.marker, func(key, value []byte) (bool, error) {
+		if len(disk.marker) != 0 && bytes.Compare(key, disk.marker) <= 0 {
+			return true, nil
+		}
+
+		if count >= flatGenerateChunk {
+			stopped = true
+
+			return false, nil
+		}
+
+		batch.Set(ethdb.SnapAccDBI, key, value)
+
+		var account state.Account
+		if err := account.UnmarshalRlp(value); err != nil {
+			return false, err
+		}
+
+		hash := types.BytesToHash(key)
+
+		if err := walkTrie(f.state.storage, account.Root, nil, func(slot, value []byte) (bool, error) {
+			batch.Set(ethdb.SnapStoDBI, flatStorageKey(hash, types.BytesToHash(slot)), value)
+			count++
+
+			return true, nil
+		}); err != nil {
+			return false, err
+		}
+
+		count++
+		last = key
+
+		return true, nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if stopped {
+		batch.Set(ethdb.SnapAccDBI, flatGeneratorKey, last)
+	} else {
+		last = nil
+
+		batch.Remove(ethdb.SnapAccDBI, flatGeneratorKey)
+	}
+
+	if err := batch.Write(); err != nil {
+		return false, err
+	}
+
+	f.lock.Lock()
+	disk.marker = last
+	f.lock.Unlock()
+
+	return !stopped, nil
+}
+
+// walkTrie calls fn in key order with the leaves of the trie at the root
+// whose keys are not below the start, until fn returns false
+func walkTrie(storage Storage, root types.Hash, start []byte, fn func(key, value []byte) (bool, error)) error {
+	if root == types.EmptyRootHash {
+		return nil
+	}
+
+	n, ok, err := GetNode(root.Bytes(), storage)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: trie node %s", state.ErrStateNotAvailable, root)
+	}
+
+	// the start in nibbles, without the terminator
+	startHex := bytesToHexNibbles(start)
+	startHex = startHex[:len(startHex)-1]
+
+	_, err = walkNode(storage, n, nil, startHex, fn)
+
+	return err
+}
+
+func walkNode(
+	storage Storage,
+	node Node,
+	path []byte,
+	start []byte,
+	fn func(key, value []byte) (bool, error),
+) (bool, error) {
+	// skip the subtrees whose keys are all below the start
+	if l := len(path); l <= len(start) && bytes.Compare(path, start[:l]) < 0 {
+		return true, nil
+	}
+
+	switch n := node.(type) {
+	case nil:
+		return true, nil
+
+	case *ValueNode:
+		if n.hash {
+			nc, ok, err := GetNode(n.buf, storage)
+			if err != nil {
+				return false, err
+			}
+
+			if !ok {
+				return false, fmt.Errorf("%w: trie node %x", state.ErrStateNotAvailable, n.buf)
+			}
+
+			return walkNode(storage, nc, path, start, fn)
+		}
+
+		return fn(hexNibblesToKey(path), n.buf)
+
+	case *ShortNode:
+		return walkNode(storage, n.child, appendNibbles(path, n.key...), start, fn)
+
+	case *FullNode:
+		if n.value != nil {
+			if more, err := walkNode(storage, n.value, path, start, fn); !more || err != nil {
+				return more, err
+			}
+		}
+
+		for i, child := range n.children {
+			if child == nil {
+				continue
+			}
+
+			if more, err := walkNode(storage, child, appendNibbles(path, byte(i)), start, fn); !more || err != nil {
+				return more, err
+			}
+		}
+
+		return true, nil
+
+	default:
+		return false, fmt.Errorf("unknown node type %v", n)
+	}
+}
+
+// appendNibbles returns a new path, leaving out the terminator
+func appendNibbles(path []byte, nibbles ...byte) []byte {
+	res := make([]byte, len(path), len(path)+len(nibbles))
+	copy(res, path)
+
+	for _, nibble := range nibbles {
+		if nibble != 16 {
+			res = append(res, nibble)
+		}
+	}
+
+	return res
+}
+
+func hexNibblesToKey(path []byte) []byte {
+	key := make([]byte, len(path)/2)
+	for i := range key {
+		key[i] = path[2*i]<<4 | path[2*i+1]
+	}
+
+	return key
+}
@@ -0,0 +1,204 @@
+package itrie
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/state"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+var flatAddrs = []types.Address{
+	types.StringToAddress("1"),
+	types.StringToAddress("2"),
+	types.StringToAddress("3"),
+}
+
+func flatSlot(i uint64) []byte {
+	return types.BytesToHash(big.NewInt(int64(i)).Bytes()).Bytes()
+}
+
+// storageRootOf returns the storage root of the address at the snapshot
+func storageRootOf(t *testing.T, snap state.Snapshot, addr types.Address) types.Hash {
+	t.Helper()
+
+	data, ok := snap.Get(hashit(addr.Bytes()))
+	if !ok {
+		return types.EmptyRootHash
+	}
+
+	var account state.Account
+
+	assert.NoError(t, account.UnmarshalRlp(data))
+
+	return account.Root
+}
+
+// commitFlatBlock commits a state on top of the parent. The first account
+// updates and deletes slots, the second one is deleted and created again
+// and the third one keeps adding slots.
+func commitFlatBlock(t *testing.T, st *State, parent types.Hash, number uint64) types.Hash {
+	t.Helper()
+
+	snap, err := st.NewSnapshotAt(parent)
+	assert.NoError(t, err)
+
+	first := &state.Object{
+		Address: flatAddrs[0],
+		Balance: big.NewInt(int64(number)),
+		Root:    storageRootOf(t, snap, flatAddrs[0]),
+		Storage: []*state.StorageObject{
+			{Key: flatSlot(number % 4), Val: flatSlot(number + 1)},
+		},
+	}
+
+	if number%3 == 0 {
+		first.Storage = append(first.Storage, &state.StorageObject{Key: flatSlot((number + 2) % 4), Deleted: true})
+	}
+
+	second := &state.Object{
+		Address: flatAddrs[1],
+		Balance: big.NewInt(int64(number)),
+		Root:    types.EmptyRootHash,
+		Storage: []*state.StorageObject{
+			{Key: flatSlot(number % 2), Val: flatSlot(number + 1)},
+		},
+	}
+
+	if number%5 == 0 {
+		second = &state.Object{Address: flatAddrs[1], Deleted: true}
+	}
+
+	third := &state.Object{
+		Address: flatAddrs[2],
+		Balance: big.NewInt(int64(number)),
+		Root:    storageRootOf(t, snap, flatAddrs[2]),
+		Storage: []*state.StorageObject{
+			{Key: flatSlot(number), Val: flatSlot(number + 1)},
+		},
+	}
+
+	_, root := snap.Commit([]*state.Object{first, second, third})
+
+	return types.BytesToHash(root)
+}
+
+// assertFlatState checks the flat state answers the reads of the state at
+// the root like its trie does
+func assertFlatState(t *testing.T, st *State, root types.Hash, slots uint64) {
+	t.Helper()
+
+	snap, err := st.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	flat, ok := snap.(*flatSnapshot)
+	if !assert.True(t, ok, "no flat layer at %s", root) {
+		return
+	}
+
+	trie, err := st.trieAt(root)
+	assert.NoError(t, err)
+
+	for _, addr := range flatAddrs {
+		hash := types.BytesToHash(hashit(addr.Bytes()))
+
+		data, known := st.flat.account(flat.layer, hash)
+		assert.True(t, known)
+
+		expected, _ := trie.Get(hash.Bytes())
+		assert.Equal(t, expected, data)
+
+		storageRoot := storageRootOf(t, trie, addr)
+
+		storage, err := flat.StorageAt(hash, storageRoot)
+		assert.NoError(t, err)
+
+		storageTrie, err := st.trieAt(storageRoot)
+		assert.NoError(t, err)
+
+		for i := uint64(0); i <= slots; i++ {
+			slot := hashit(flatSlot(i))
+
+			data, known := st.flat.storage(flat.layer, hash, types.BytesToHash(slot))
+			assert.True(t, known)
+
+			expected, _ := storageTrie.Get(slot)
+			assert.Equal(t, expected, data)
+
+			value, ok := storage.Get(slot)
+			assert.Equal(t, expected != nil, ok)
+			assert.Equal(t, expected, value)
+		}
+	}
+}
+
+func waitGenerated(t *testing.T, flat *FlatState) {
+	t.Helper()
+
+	assert.Eventually(t, flat.Generated, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestFlatState(t *testing.T) {
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer db.Close()
+
+	st := NewState(NewKVStorage(db), nil)
+
+	// the genesis state is generated from the trie
+	roots := []types.Hash{commitFlatBlock(t, st, types.EmptyRootHash, 0)}
+
+	flat, err := NewFlatState(hclog.NewNullLogger(), st, db, roots[0])
+	assert.NoError(t, err)
+	waitGenerated(t, flat)
+
+	assertFlatState(t, st, roots[0], 0)
+
+	// more blocks than the diff layers flatten the bottom ones
+	blocks := uint64(flatDiffLayers + 16)
+
+	for i := uint64(1); i <= blocks; i++ {
+		roots = append(roots, commitFlatBlock(t, st, roots[i-1], i))
+		assertFlatState(t, st, roots[i], i)
+	}
+
+	// the flattened states are read from the tries
+	_, ok := flat.layers[roots[1]]
+	assert.False(t, ok)
+	assert.Equal(t, roots[blocks-flatDiffLayers], flat.disk.root)
+
+	// a fork of a flattened state is not tracked
+	snap, err := st.NewSnapshotAt(roots[1])
+	assert.NoError(t, err)
+
+	_, ok = snap.(*Trie)
+	assert.True(t, ok)
+
+	head := roots[blocks]
+	assert.NoError(t, flat.Close(head))
+
+	// the flat state is reused at the same head
+	st = NewState(NewKVStorage(db), nil)
+
+	flat, err = NewFlatState(hclog.NewNullLogger(), st, db, head)
+	assert.NoError(t, err)
+	assert.True(t, flat.Generated())
+	assert.Equal(t, head, flat.disk.root)
+
+	assertFlatState(t, st, head, blocks)
+	assert.NoError(t, flat.Close(head))
+
+	// and rebuilt at another one
+	st = NewState(NewKVStorage(db), nil)
+
+	flat, err = NewFlatState(hclog.NewNullLogger(), st, db, roots[10])
+	assert.NoError(t, err)
+	waitGenerated(t, flat)
+
+	assertFlatState(t, st, roots[10], blocks)
+	assert.NoError(t, flat.Close(roots[10]))
+}
@@ -0,0 +1,560 @@
+package itrie
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/dogechain-lab/fastrlp"
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+const (
+	// DefaultPruneRetain is the number of recent block states kept by default
+	DefaultPruneRetain = 128
+
+	// defaultPruneBatchSize is the number of deletions written at once
+	defaultPruneBatchSize = 4096
+)
+
+// keys of the pruner records in ethdb.PruneDBI
+var (
+	refCountPrefix   = []byte("r") // node hash -> reference count
+	pinnedRootPrefix = []byte("p") // block number -> state roots of the block
+	lastPrunedKey    = []byte("last")
+)
+
+var (
+	ErrPruneRetain = errors.New("the pruner must retain at least one state")
+)
+
+// PrunerConfig is the retention of the state pruner
+type PrunerConfig struct {
+	// Retain is the number of recent block states kept
+	Retain uint64
+
+	// Checkpoints are the block numbers whose states are never pruned
+	Checkpoints []uint64
+
+	// CheckpointInterval keeps the state of every n-th block, 0 disables it
+	CheckpointInterval uint64
+
+	// BatchSize is the number of deletions written at once
+	BatchSize int
+}
+
+// Pruner deletes the trie nodes of the states which left the retention
+// window.
+//
+// Every node written by a commit gets a reference count, the number of
+// stored nodes pointing to it plus the number of blocks pinning it as their
+// state root. Pruning a block releases its roots, a node whose count drops to
+// zero is deleted and releases its own children in turn. The nodes written
+// before pruning was enabled have no count and are never deleted.
+type Pruner struct {
+	logger hclog.Logger
+	db     ethdb.Database
+	state  *State
+	config *PrunerConfig
+
+	checkpoints map[uint64]struct{}
+
+	// lock serializes the counting of the commits with the pruning
+	lock sync.Mutex
+
+	// last pruned block, nil until the first pin
+	lastPruned *uint64
+}
+
+// NewPruner returns a pruner of the state, the nodes written by the state
+// commits from now on are reference counted. It must be created before the
+// state commits any block which is going to be pinned, and before the state
+// is shared with other goroutines.
+func NewPruner(logger hclog.Logger, st *State, db ethdb.Database, config *PrunerConfig) (*Pruner, error) {
+	if config.Retain == 0 {
+		return nil, ErrPruneRetain
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultPruneBatchSize
+	}
+
+	p := &Pruner{
+		logger:      logger.Named("pruner"),
+		db:          db,
+		state:       st,
+		config:      config,
+		checkpoints: make(map[uint64]struct{}, len(config.Checkpoints)),
+	}
+
+	for _, number := range config.Checkpoints {
+		p.checkpoints[number] = struct{}{}
+	}
+
+	data, ok, err := db.Get(ethdb.PruneDBI, lastPrunedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		last := binary.BigEndian.Uint64(data)
+		p.lastPruned = &last
+	}
+
+	st.storage = &refStorage{Storage: st.storage, pruner: p}
+
+	// the cached tries still commit to the plain storage
+	st.trieStateCache.Purge()
+	st.accountStateCache.Purge()
+
+	return p, nil
+}
+
+// LastPruned returns the highest pruned block, false if nothing was pruned
+func (p *Pruner) LastPruned() (uint64, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.lastPruned == nil {
+		return 0, false
+	}
+
+	return *p.lastPruned, true
+}
+
+func (p *Pruner) isCheckpoint(number uint64) bool {
+	if _, ok := p.checkpoints[number]; ok {
+		return true
+	}
+
+	return p.config.CheckpointInterval > 0 && number%p.config.CheckpointInterval == 0
+}
+
+// Pin keeps the state root of the block until the block is pruned
+func (p *Pruner) Pin(number uint64, root types.Hash) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	batch := p.db.Batch()
+	defer batch.Discard()
+
+	if p.lastPruned == nil {
+		// nothing before the first pinned block can be released
+		last := uint64(0)
+		if number > 0 {
+			last = number - 1
+		}
+
+		p.lastPruned = &last
+		batch.Set(ethdb.PruneDBI, lastPrunedKey, encodeUint64(last))
+	}
+
+	if root != types.EmptyRootHash {
+		count, tracked, err := p.refCount(root.Bytes())
+		if err != nil {
+			return err
+		}
+
+		if tracked {
+			batch.Set(ethdb.PruneDBI, refCountKey(root.Bytes()), encodeUint64(count+1))
+		}
+	}
+
+	roots, err := p.pinnedRoots(number)
+	if err != nil {
+		return err
+	}
+
+	batch.Set(ethdb.PruneDBI, pinnedRootKey(number), append(roots, root.Bytes()...))
+
+	return batch.Write()
+}
+
+// Prune releases the states of the blocks up to head minus the retention,
+// except the checkpoints. It returns the number of deleted nodes.
+func (p *Pruner) Prune(head uint64) (int, error) {
+	if head < p.config.Retain {
+		return 0, nil
+	}
+
+	target := head - p.config.Retain
+
+	deleted := 0
+
+	for {
+		last, ok := p.LastPruned()
+		if !ok || last >= target {
+			break
+		}
+
+		n, err := p.pruneBlock(last + 1)
+		if err != nil {
+			return deleted, err
+		}
+
+		deleted += n
+	}
+
+	if deleted > 0 {
+		p.logger.Debug("pruned states", "to", target, "nodes", deleted)
+	}
+
+	return deleted, nil
+}
+
+// pruneBlock releases the state roots pinned by the block
+func (p *Pruner) pruneBlock(number uint64) (int, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	batch := newPruneBatch(p)
+
+	// the batch is replaced on every flush
+	defer func() { batch.Discard() }()
+
+	if !p.isCheckpoint(number) {
+		roots, err := p.pinnedRoots(number)
+		if err != nil {
+			return 0, err
+		}
+
+		for i := 0; i+types.HashLength <= len(roots); i += types.HashLength {
+			if err := batch.release(roots[i : i+types.HashLength]); err != nil {
+				return batch.deleted, err
+			}
+		}
+
+		batch.Remove(ethdb.PruneDBI, pinnedRootKey(number))
+	}
+
+	batch.Set(ethdb.PruneDBI, lastPrunedKey, encodeUint64(number))
+
+	if err := batch.flush(); err != nil {
+		return batch.deleted, err
+	}
+
+	p.lastPruned = &number
+
+	return batch.deleted, nil
+}
+
+// refCount returns the reference count of the node, false if the node is
+// not tracked
+func (p *Pruner) refCount(hash []byte) (uint64, bool, error) {
+	data, ok, err := p.db.Get(ethdb.PruneDBI, refCountKey(hash))
+	if err != nil || !ok {
+		return 0, false, err
+	}
+
+	return binary.BigEndian.Uint64(data), true, nil
+}
+
+func (p *Pruner) pinnedRoots(number uint64) ([]byte, error) {
+	data, _, err := p.db.Get(ethdb.PruneDBI, pinnedRootKey(number))
+
+	return data, err
+}
+
+// commit writes the nodes of a state commit together with their reference
+// counts. Only the nodes which are not stored yet are counted, their
+// children get a reference unless they are untracked.
+func (p *Pruner) commit(batch ethdb.Batch, nodes map[string][]byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var (
+		fresh  = make([]string, 0, len(nodes))
+		counts = make(map[string]uint64, len(nodes))
+	)
+
+	for hash := range nodes {
+		_, ok, err := p.db.Get(ethdb.TrieDBI, []byte(hash))
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			continue
+		}
+
+		count, _, err := p.refCount([]byte(hash))
+		if err != nil {
+			return err
+		}
+
+		fresh = append(fresh, hash)
+		counts[hash] = count
+	}
+
+	for _, hash := range fresh {
+		refs, err := nodeRefs(nodes[hash])
+		if err != nil {
+			return err
+		}
+
+		for _, ref := range refs {
+			if _, ok := counts[string(ref)]; ok {
+				counts[string(ref)]++
+
+				continue
+			}
+
+			count, tracked, err := p.refCount(ref)
+			if err != nil {
+				return err
+			}
+
+			if tracked {
+				counts[string(ref)] = count + 1
+			}
+		}
+	}
+
+	for _, hash := range fresh {
+		batch.Set(ethdb.TrieDBI, []byte(hash), nodes[hash])
+	}
+
+	for hash, count := range counts {
+		batch.Set(ethdb.PruneDBI, refCountKey([]byte(hash)), encodeUint64(count))
+	}
+
+	return batch.Write()
+}
+
+// pruneBatch collects the deletions of the released nodes, the reference
+// counts it changed are read back before they are written
+type pruneBatch struct {
+	ethdb.Batch
+
+	pruner  *Pruner
+	counts  map[string]uint64
+	evicted []types.Hash
+	size    int
+	deleted int
+}
+
+func newPruneBatch(p *Pruner) *pruneBatch {
+	return &pruneBatch{
+		Batch:  p.db.Batch(),
+		pruner: p,
+		counts: map[string]uint64{},
+	}
+}
+
+func (b *pruneBatch) refCount(hash []byte) (uint64, bool, error) {
+	if count, ok := b.counts[string(hash)]; ok {
+		return count, true, nil
+	}
+
+	return b.pruner.refCount(hash)
+}
+
+// release drops a reference of the node, the nodes left without references
+// are deleted and release their children
+func (b *pruneBatch) release(root []byte) error {
+	stack := [][]byte{root}
+
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		count, tracked, err := b.refCount(hash)
+		if err != nil {
+			return err
+		}
+
+		if !tracked {
+			continue
+		}
+
+		if count > 1 {
+			b.counts[string(hash)] = count - 1
+			b.Set(ethdb.PruneDBI, refCountKey(hash), encodeUint64(count-1))
+
+			continue
+		}
+
+		data, ok, err := b.pruner.db.Get(ethdb.TrieDBI, hash)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			refs, err := nodeRefs(data)
+			if err != nil {
+				return err
+			}
+
+			stack = append(stack, refs...)
+		}
+
+		delete(b.counts, string(hash))
+		b.Remove(ethdb.TrieDBI, hash)
+		b.Remove(ethdb.PruneDBI, refCountKey(hash))
+		b.evicted = append(b.evicted, types.BytesToHash(hash))
+		b.deleted++
+
+		if b.size++; b.size >= b.pruner.config.BatchSize {
+			if err := b.flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// flush writes the batch and evicts the deleted tries from the state caches
+func (b *pruneBatch) flush() error {
+	if err := b.Batch.Write(); err != nil {
+		return err
+	}
+
+	for _, hash := range b.evicted {
+		b.pruner.state.trieStateCache.Remove(hash)
+		b.pruner.state.accountStateCache.Remove(hash)
+	}
+
+	b.Batch = b.pruner.db.Batch()
+	b.counts = map[string]uint64{}
+	b.evicted = nil
+	b.size = 0
+
+	return nil
+}
+
+// refStorage hands out batches which count the references of the nodes
+type refStorage struct {
+	Storage
+
+	pruner *Pruner
+}
+
+func (s *refStorage) Batch() ethdb.Batch {
+	return &refBatch{
+		Batch:  s.Storage.Batch(),
+		pruner: s.pruner,
+		nodes:  map[string][]byte{},
+	}
+}
+
+// refBatch holds the trie nodes back until the batch is written
+type refBatch struct {
+	ethdb.Batch
+
+	pruner *Pruner
+	nodes  map[string][]byte
+}
+
+func (b *refBatch) Set(dbi string, k, v []byte) error {
+	if dbi != ethdb.TrieDBI {
+		return b.Batch.Set(dbi, k, v)
+	}
+
+	// the hasher reuses its buffers
+	b.nodes[string(k)] = append([]byte{}, v...)
+
+	return nil
+}
+
+func (b *refBatch) Write() error {
+	return b.pruner.commit(b.Batch, b.nodes)
+}
+
+// Discard drops the held back nodes along with the pending writes
+func (b *refBatch) Discard() {
+	b.nodes = map[string][]byte{}
+	b.Batch.Discard()
+}
+
+// nodeRefs returns the hashes a stored node refers to, its hashed children
+// and the storage roots of the accounts in its leaves
+func nodeRefs(data []byte) ([][]byte, error) {
+	p := parserPool.Get()
+	defer parserPool.Put(p)
+
+	v, err := p.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendNodeRefs(nil, v), nil
+}
+
+func appendNodeRefs(refs [][]byte, v *fastrlp.Value) [][]byte {
+	if v.Type() != fastrlp.TypeArray {
+		return refs
+	}
+
+	switch v.Elems() {
+	case 2:
+		key := v.Get(0)
+		if key.Type() == fastrlp.TypeBytes && hasTerminator(decodeCompact(key.Raw())) {
+			return appendAccountRef(refs, v.Get(1))
+		}
+
+		return appendChildRef(refs, v.Get(1))
+	case 17:
+		for i := 0; i < 16; i++ {
+			refs = appendChildRef(refs, v.Get(i))
+		}
+	}
+
+	return refs
+}
+
+// appendChildRef appends the hash of a child, embedded children are
+// walked instead
+func appendChildRef(refs [][]byte, v *fastrlp.Value) [][]byte {
+	if v.Type() == fastrlp.TypeArray {
+		return appendNodeRefs(refs, v)
+	}
+
+	if len(v.Raw()) == types.HashLength {
+		refs = append(refs, append([]byte{}, v.Raw()...))
+	}
+
+	return refs
+}
+
+// appendAccountRef appends the storage root of the leaf value if it is an
+// account, storage leaves hold a plain value
+func appendAccountRef(refs [][]byte, v *fastrlp.Value) [][]byte {
+	if v.Type() != fastrlp.TypeBytes {
+		return refs
+	}
+
+	p := parserPool.Get()
+	defer parserPool.Put(p)
+
+	account, err := p.Parse(v.Raw())
+	if err != nil || account.Type() != fastrlp.TypeArray || account.Elems() != 4 {
+		return refs
+	}
+
+	root := account.Get(2)
+	if root.Type() != fastrlp.TypeBytes || len(root.Raw()) != types.HashLength ||
+		types.BytesToHash(root.Raw()) == types.EmptyRootHash {
+		return refs
+	}
+
+	return append(refs, append([]byte{}, root.Raw()...))
+}
+
+func refCountKey(hash []byte) []byte {
+	return append(append([]byte{}, refCountPrefix...), hash...)
+}
+
+func pinnedRootKey(number uint64) []byte {
+	return append(append([]byte{}, pinnedRootPrefix...), encodeUint64(number)...)
+}
+
+func encodeUint64(n uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, n)
+
+	return buf
+}
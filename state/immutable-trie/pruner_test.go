@@ -0,0 +1,159 @@
+package itrie
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sunvim/dogesyncer/ethdb"
+	"github.com/sunvim/dogesyncer/ethdb/mdbx"
+	"github.com/sunvim/dogesyncer/state"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+var (
+	prunerAddr1 = types.StringToAddress("1")
+	prunerAddr2 = types.StringToAddress("2")
+)
+
+// commitPrunerBlock commits a state on top of the parent which changes both
+// accounts and adds a storage slot to the first one
+func commitPrunerBlock(t *testing.T, st *State, parent types.Hash, number uint64) types.Hash {
+	t.Helper()
+
+	snap, err := st.NewSnapshotAt(parent)
+	assert.NoError(t, err)
+
+	storageRoot := types.EmptyRootHash
+
+	if data, ok := snap.Get(hashit(prunerAddr1.Bytes())); ok {
+		var account state.Account
+
+		assert.NoError(t, account.UnmarshalRlp(data))
+		storageRoot = account.Root
+	}
+
+	objs := []*state.Object{
+		{
+			Address: prunerAddr1,
+			Balance: big.NewInt(int64(number)),
+			Root:    storageRoot,
+			Storage: []*state.StorageObject{
+				{
+					Key: types.BytesToHash([]byte{byte(number)}).Bytes(),
+					Val: types.BytesToHash([]byte{byte(number)}).Bytes(),
+				},
+			},
+		},
+		{
+			Address: prunerAddr2,
+			Balance: big.NewInt(int64(number)),
+			Root:    types.EmptyRootHash,
+		},
+	}
+
+	_, root := snap.Commit(objs)
+
+	return types.BytesToHash(root)
+}
+
+// assertReachable checks all the nodes of the state are stored
+func assertReachable(t *testing.T, db ethdb.Database, root types.Hash) {
+	t.Helper()
+
+	stack := [][]byte{root.Bytes()}
+
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		data, ok, err := db.Get(ethdb.TrieDBI, hash)
+		assert.NoError(t, err)
+
+		if !assert.True(t, ok, "missing node %x of state %s", hash, root) {
+			return
+		}
+
+		refs, err := nodeRefs(data)
+		assert.NoError(t, err)
+
+		stack = append(stack, refs...)
+	}
+}
+
+func TestPruner(t *testing.T) {
+	db := mdbx.NewMDBX(t.TempDir(), hclog.NewNullLogger())
+	defer db.Close()
+
+	st := NewState(NewKVStorage(db), nil)
+
+	// the state written before the pruner is not tracked
+	roots := map[uint64]types.Hash{
+		0: commitPrunerBlock(t, st, types.EmptyRootHash, 0),
+	}
+
+	pruner, err := NewPruner(hclog.NewNullLogger(), st, db, &PrunerConfig{
+		Retain:      2,
+		Checkpoints: []uint64{3},
+	})
+	assert.NoError(t, err)
+
+	for i := uint64(1); i <= 6; i++ {
+		roots[i] = commitPrunerBlock(t, st, roots[i-1], i)
+		assert.NoError(t, pruner.Pin(i, roots[i]))
+	}
+
+	deleted, err := pruner.Prune(6)
+	assert.NoError(t, err)
+	assert.NotZero(t, deleted)
+
+	last, ok := pruner.LastPruned()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(4), last)
+
+	// the untracked, the checkpoint and the retained states are kept
+	for _, i := range []uint64{0, 3, 5, 6} {
+		assertReachable(t, db, roots[i])
+	}
+
+	// the other roots are gone, also from the caches
+	for _, i := range []uint64{1, 2, 4} {
+		_, ok, err := db.Get(ethdb.TrieDBI, roots[i].Bytes())
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = st.NewSnapshotAt(roots[i])
+		assert.ErrorIs(t, err, state.ErrStateNotAvailable)
+	}
+
+	// pruning again is a noop
+	deleted, err = pruner.Prune(6)
+	assert.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	// the counts survive a restart
+	pruner, err = NewPruner(hclog.NewNullLogger(), NewState(NewKVStorage(db), nil), db, &PrunerConfig{Retain: 2})
+	assert.NoError(t, err)
+
+	last, ok = pruner.LastPruned()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(4), last)
+
+	_, err = pruner.Prune(7)
+	assert.NoError(t, err)
+
+	_, ok, err = db.Get(ethdb.TrieDBI, roots[5].Bytes())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assertReachable(t, db, roots[0])
+	assertReachable(t, db, roots[3])
+	assertReachable(t, db, roots[6])
+}
+
+func TestPruner_Retain(t *testing.T) {
+	_, err := NewPruner(hclog.NewNullLogger(), NewState(NewMemoryStorage(), nil), nil, &PrunerConfig{})
+	assert.ErrorIs(t, err, ErrPruneRetain)
+}
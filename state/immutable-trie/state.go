@@ -23,6 +23,9 @@ type State struct {
 	trieStateCache    *lru.Cache
 	accountStateCache *lru.Cache
 
+	// flat reads the recent states without walking the tries, nil if disabled
+	flat *FlatState
+
 	metrics *Metrics
 }
 
@@ -43,6 +46,10 @@ func NewState(storage Storage, metrics *Metrics) *State {
 }
 
 func (s *State) NewSnapshot() state.Snapshot {
+	return s.newTrie()
+}
+
+func (s *State) newTrie() *Trie {
 	t := NewTrie()
 	t.state = s
 	t.storage = s.storage
@@ -89,9 +96,20 @@ func (s *State) GetCode(hash types.Hash) ([]byte, bool) {
 }
 
 func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
+	if s.flat != nil {
+		if snap := s.flat.snapshotAt(root); snap != nil {
+			return snap, nil
+		}
+	}
+
+	return s.trieAt(root)
+}
+
+// trieAt returns the trie of the root, reading through the caches
+func (s *State) trieAt(root types.Hash) (*Trie, error) {
 	if root == types.EmptyRootHash {
 		// empty state
-		return s.NewSnapshot(), nil
+		return s.newTrie(), nil
 	}
 
 	tt, ok := s.trieStateCache.Get(root)
@@ -125,7 +143,7 @@ func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
 	}
 
 	if !ok {
-		return nil, fmt.Errorf("state not found at hash %s", root)
+		return nil, fmt.Errorf("%w: state root %s", state.ErrStateNotAvailable, root)
 	}
 
 	s.metrics.StateLruCacheMiss.Add(1)
@@ -120,10 +120,18 @@ func (m *memBatch) Set(dbi string, p, v []byte) error {
 	return nil
 }
 
+func (m *memBatch) Remove(dbi string, p []byte) error {
+	delete(*m.db, hex.EncodeToHex(p))
+	return nil
+}
+
 func (m *memBatch) Write() error {
 	return nil
 }
 
+// Discard is a noop, the memory batch writes through
+func (m *memBatch) Discard() {}
+
 // GetNode retrieves a node from storage
 func GetNode(root []byte, storage Storage) (Node, bool, error) {
 	data, ok, _ := storage.Get(root)
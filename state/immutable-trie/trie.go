@@ -117,6 +117,11 @@ func hashit(k []byte) []byte {
 
 var accountArenaPool fastrlp.ArenaPool
 
+// encodeStorageValue returns the storage trie value of the slot
+func encodeStorageValue(ar *fastrlp.Arena, val []byte) []byte {
+	return ar.NewBytes(bytes.TrimLeft(val, "\x00")).MarshalTo(nil)
+}
+
 var stateArenaPool fastrlp.ArenaPool // TODO, Remove once we do update in fastrlp
 
 func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
@@ -144,16 +149,11 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 			}
 
 			if len(obj.Storage) != 0 {
-				localSnapshot, err := t.state.NewSnapshotAt(obj.Root)
+				trie, err := t.state.trieAt(obj.Root)
 				if err != nil {
 					panic(err)
 				}
 
-				trie, ok := localSnapshot.(*Trie)
-				if !ok {
-					panic("invalid type assertion")
-				}
-
 				localTxn := trie.Txn()
 				localTxn.batch = batch
 
@@ -162,8 +162,7 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 					if entry.Deleted {
 						localTxn.Delete(k)
 					} else {
-						vv := ar1.NewBytes(bytes.TrimLeft(entry.Val, "\x00"))
-						localTxn.Insert(k, vv.MarshalTo(nil))
+						localTxn.Insert(k, encodeStorageValue(ar1, entry.Val))
 					}
 				}
 
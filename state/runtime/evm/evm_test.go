@@ -24,7 +24,9 @@ func newMockContract(value *big.Int, gas uint64, code []byte) *runtime.Contract
 
 // mockHost is a struct which meets the requirements of runtime.Host interface but throws panic in each methods
 // we don't test all opcodes in this test
-type mockHost struct{}
+type mockHost struct {
+	cancelled bool
+}
 
 func (m *mockHost) AccountExists(addr types.Address) bool {
 	panic("Not implemented in tests")
@@ -91,14 +93,19 @@ func (m *mockHost) GetEVMLogger() runtime.EVMLogger {
 	return runtime.NewDummyLogger()
 }
 
+func (m *mockHost) Cancelled() bool {
+	return m.cancelled
+}
+
 func TestRun(t *testing.T) {
 	tests := []struct {
-		name     string
-		value    *big.Int
-		gas      uint64
-		code     []byte
-		config   *chain.ForksInTime
-		expected *runtime.ExecutionResult
+		name      string
+		value     *big.Int
+		gas       uint64
+		code      []byte
+		config    *chain.ForksInTime
+		cancelled bool
+		expected  *runtime.ExecutionResult
 	}{
 		{
 			name:  "should succeed because of no codes",
@@ -152,13 +159,25 @@ func TestRun(t *testing.T) {
 				Err:     errRevert,
 			},
 		},
+		{
+			name:      "should fail and consume all gas when cancelled",
+			value:     big.NewInt(0),
+			gas:       5000,
+			code:      []byte{PUSH1, 0x01, PUSH1, 0x02, ADD},
+			cancelled: true,
+			expected: &runtime.ExecutionResult{
+				ReturnValue: nil,
+				GasLeft:     0,
+				Err:         runtime.ErrExecutionCancelled,
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			evm := NewEVM()
 			contract := newMockContract(tt.value, tt.gas, tt.code)
-			host := &mockHost{}
+			host := &mockHost{cancelled: tt.cancelled}
 			config := tt.config
 			if config == nil {
 				config = &chain.ForksInTime{}
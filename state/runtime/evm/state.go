@@ -284,8 +284,15 @@ func (c *state) Run() (ret []byte, vmerr error) {
 	}(needDebug, &vmerr)
 
 	codeSize := len(c.code)
+	cancellable := c.host != nil
 
 	for !c.stop {
+		if cancellable && c.host.Cancelled() {
+			c.exit(runtime.ErrExecutionCancelled)
+
+			break
+		}
+
 		if needDebug {
 			// capture pre-execution values for tracing
 			executedIp, memory, stack, logged, gasBefore, gasAfter =
@@ -11,6 +11,9 @@ import (
 type Txn interface {
 	GetState(addr types.Address, key types.Hash) types.Hash
 	GetRefund() uint64
+	GetBalance(addr types.Address) *big.Int
+	GetNonce(addr types.Address) uint64
+	GetCode(addr types.Address) []byte
 }
 
 // ScopeContext contains the things that are per-call, such as stack and memory,
@@ -25,11 +28,19 @@ type ScopeContext struct {
 // CaptureState is called for each step of the VM with the current VM state.
 // Note that reference types are actual VM data structures; make copies if you need to
 // retain them beyond the current call.
+// CaptureTxStart is called before the transaction changes any state, its txn is a
+// copy of the state at that point which is not affected by the transaction.
 type EVMLogger interface {
+	// Transaction level
+	CaptureTxStart(txn Txn, env TxContext, msg *types.Transaction)
+	CaptureTxEnd(restGas uint64)
+	// Top call frame
 	CaptureStart(txn Txn, from, to types.Address, create bool, input []byte, gas uint64, value *big.Int)
-	CaptureState(ctx *ScopeContext, pc uint64, opCode int, gas, cost uint64, rData []byte, depth int, err error)
+	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error)
+	// Rest of call frames
 	CaptureEnter(opCode int, from, to types.Address, input []byte, gas uint64, value *big.Int)
 	CaptureExit(output []byte, gasUsed uint64, err error)
+	// Opcode level
+	CaptureState(ctx *ScopeContext, pc uint64, opCode int, gas, cost uint64, rData []byte, depth int, err error)
 	CaptureFault(ctx *ScopeContext, pc uint64, opCode int, gas, cost uint64, depth int, err error)
-	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error)
 }
@@ -70,6 +70,8 @@ type Host interface {
 	Empty(addr types.Address) bool
 	GetNonce(addr types.Address) uint64
 	GetEVMLogger() EVMLogger
+	// Cancelled reports whether the execution is to be aborted
+	Cancelled() bool
 }
 
 // ExecutionResult includes all output after executing given evm
@@ -129,6 +131,7 @@ var (
 	ErrExecutionReverted        = errors.New("execution was reverted")
 	ErrCodeStoreOutOfGas        = errors.New("contract creation code storage out of gas")
 	ErrCodeEmpty                = errors.New("contract code empty")
+	ErrExecutionCancelled       = errors.New("execution cancelled")
 )
 
 type CallType int
@@ -2,6 +2,7 @@ package state
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
 
@@ -12,6 +13,10 @@ import (
 	"github.com/sunvim/dogesyncer/types"
 )
 
+// ErrStateNotAvailable is returned for a state root which is not stored,
+// either it was never written or it was pruned
+var ErrStateNotAvailable = errors.New("missing trie node / state not available")
+
 type State interface {
 	NewSnapshotAt(types.Hash) (Snapshot, error)
 	NewSnapshot() Snapshot
@@ -23,6 +28,12 @@ type Snapshot interface {
 	Commit(objs []*Object) (Snapshot, []byte)
 }
 
+// StorageSnapshotter is implemented by the snapshots which read the storage
+// of their accounts without opening the storage trie
+type StorageSnapshotter interface {
+	StorageAt(addrHash types.Hash, root types.Hash) (Snapshot, error)
+}
+
 // account trie
 type accountTrie interface {
 	Get(k []byte) ([]byte, bool)
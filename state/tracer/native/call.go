@@ -0,0 +1,265 @@
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/sunvim/dogesyncer/helper/hex"
+	"github.com/sunvim/dogesyncer/state/runtime"
+	"github.com/sunvim/dogesyncer/state/runtime/evm"
+	"github.com/sunvim/dogesyncer/state/tracer"
+	"github.com/sunvim/dogesyncer/types"
+	"github.com/umbracle/go-web3/abi"
+)
+
+func init() {
+	register("callTracer", newCallTracer)
+}
+
+// callFrame is a call of the transaction, nested calls are its children
+type callFrame struct {
+	Type         evm.OpCode
+	From         types.Address
+	To           *types.Address
+	Value        *big.Int
+	Gas          uint64
+	GasUsed      uint64
+	Input        []byte
+	Output       []byte
+	Error        string
+	RevertReason string
+	Calls        []*callFrame
+}
+
+// callFrameMarshaling is the json encoding of a call frame
+type callFrameMarshaling struct {
+	Type         string         `json:"type"`
+	From         types.Address  `json:"from"`
+	To           *types.Address `json:"to,omitempty"`
+	Value        string         `json:"value,omitempty"`
+	Gas          string         `json:"gas"`
+	GasUsed      string         `json:"gasUsed"`
+	Input        string         `json:"input"`
+	Output       string         `json:"output,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	RevertReason string         `json:"revertReason,omitempty"`
+	Calls        []*callFrame   `json:"calls,omitempty"`
+}
+
+func (f *callFrame) MarshalJSON() ([]byte, error) {
+	enc := &callFrameMarshaling{
+		Type:         f.Type.String(),
+		From:         f.From,
+		To:           f.To,
+		Gas:          hex.EncodeUint64(f.Gas),
+		GasUsed:      hex.EncodeUint64(f.GasUsed),
+		Input:        hex.EncodeToHex(f.Input),
+		Error:        f.Error,
+		RevertReason: f.RevertReason,
+		Calls:        f.Calls,
+	}
+
+	if f.Value != nil {
+		enc.Value = hex.EncodeBig(f.Value)
+	}
+
+	if len(f.Output) > 0 {
+		enc.Output = hex.EncodeToHex(f.Output)
+	}
+
+	return json.Marshal(enc)
+}
+
+// processOutput records the result of the call, failed creations have no
+// recipient and reverts keep their output and reason
+func (f *callFrame) processOutput(output []byte, err error) {
+	if err == nil {
+		f.Output = output
+
+		return
+	}
+
+	f.Error = err.Error()
+
+	if f.Type == evm.CREATE || f.Type == evm.CREATE2 {
+		f.To = nil
+	}
+
+	if !errors.Is(err, runtime.ErrExecutionReverted) || len(output) == 0 {
+		return
+	}
+
+	f.Output = output
+
+	if reason, err := abi.UnpackRevertError(output); err == nil {
+		f.RevertReason = reason
+	}
+}
+
+type callTracerConfig struct {
+	OnlyTopCall bool `json:"onlyTopCall"` // If true, call tracer won't collect any subcalls
+}
+
+// callTracer collects the call frames of a transaction
+type callTracer struct {
+	config    callTracerConfig
+	callstack []*callFrame
+	gasLimit  uint64
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newCallTracer returns a native go tracer which tracks
+// call frames of a tx, and implements tracer.Tracer.
+func newCallTracer(ctx *tracer.Context, cfg json.RawMessage) (tracer.Tracer, error) {
+	var config callTracerConfig
+
+	if len(cfg) > 0 {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+
+	// First callframe contains tx context info
+	// and is populated on start and end.
+	return &callTracer{
+		config:    config,
+		callstack: make([]*callFrame, 1),
+	}, nil
+}
+
+func (t *callTracer) CaptureTxStart(txn runtime.Txn, env runtime.TxContext, msg *types.Transaction) {
+	t.gasLimit = msg.Gas
+}
+
+func (t *callTracer) CaptureTxEnd(restGas uint64) {
+	if t.callstack[0] == nil {
+		return
+	}
+
+	t.callstack[0].GasUsed = t.gasLimit - restGas
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *callTracer) CaptureStart(txn runtime.Txn, from, to types.Address,
+	create bool, input []byte, gas uint64, value *big.Int) {
+	frame := &callFrame{
+		Type:  evm.CALL,
+		From:  from,
+		To:    to.Ptr(),
+		Input: copyBytes(input),
+		Gas:   t.gasLimit,
+		Value: copyBig(value),
+	}
+
+	if create {
+		frame.Type = evm.CREATE
+	}
+
+	t.callstack[0] = frame
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	if t.callstack[0] == nil {
+		return
+	}
+
+	t.callstack[0].processOutput(copyBytes(output), err)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *callTracer) CaptureEnter(opCode int, from, to types.Address,
+	input []byte, gas uint64, value *big.Int) {
+	if t.config.OnlyTopCall {
+		return
+	}
+
+	// Skip if tracing was interrupted
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+
+	t.callstack = append(t.callstack, &callFrame{
+		Type:  evm.OpCode(opCode),
+		From:  from,
+		To:    to.Ptr(),
+		Input: copyBytes(input),
+		Gas:   gas,
+		Value: copyBig(value),
+	})
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *callTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if t.config.OnlyTopCall {
+		return
+	}
+
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+
+	size := len(t.callstack)
+	if size <= 1 {
+		return
+	}
+
+	// pop call
+	call := t.callstack[size-1]
+	t.callstack = t.callstack[:size-1]
+	size--
+
+	call.GasUsed = gasUsed
+	call.processOutput(copyBytes(output), err)
+	t.callstack[size-1].Calls = append(t.callstack[size-1].Calls, call)
+}
+
+func (t *callTracer) CaptureState(ctx *runtime.ScopeContext, pc uint64, opCode int,
+	gas, cost uint64, rData []byte, depth int, err error) {
+}
+
+func (t *callTracer) CaptureFault(ctx *runtime.ScopeContext, pc uint64, opCode int,
+	gas, cost uint64, depth int, err error) {
+}
+
+// GetResult returns the json-encoded nested list of call traces, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return nil, t.reason
+	}
+
+	if len(t.callstack) != 1 || t.callstack[0] == nil {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+
+	return json.Marshal(t.callstack[0])
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *callTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+
+	return append([]byte{}, b...)
+}
+
+func copyBig(b *big.Int) *big.Int {
+	if b == nil {
+		return nil
+	}
+
+	return new(big.Int).Set(b)
+}
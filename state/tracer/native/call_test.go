@@ -0,0 +1,70 @@
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/state/runtime"
+	"github.com/sunvim/dogesyncer/state/runtime/evm"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+type callResult struct {
+	Type    string        `json:"type"`
+	From    types.Address `json:"from"`
+	To      types.Address `json:"to"`
+	Gas     string        `json:"gas"`
+	GasUsed string        `json:"gasUsed"`
+	Error   string        `json:"error"`
+	Calls   []*callResult `json:"calls"`
+}
+
+func TestCallTracer(t *testing.T) {
+	result := &callResult{}
+	assert.NoError(t, json.Unmarshal(traceTx(t, "callTracer", ""), result))
+
+	assert.Equal(t, "CALL", result.Type)
+	assert.Equal(t, sender, result.From)
+	assert.Equal(t, caller, result.To)
+	assert.Equal(t, "0xf4240", result.Gas)
+	assert.NotEqual(t, "0x0", result.GasUsed)
+	assert.Empty(t, result.Error)
+
+	assert.Len(t, result.Calls, 1)
+	assert.Equal(t, "CALL", result.Calls[0].Type)
+	assert.Equal(t, caller, result.Calls[0].From)
+	assert.Equal(t, callee, result.Calls[0].To)
+	assert.Equal(t, "0xffff", result.Calls[0].Gas)
+	assert.Empty(t, result.Calls[0].Calls)
+}
+
+func TestCallTracer_OnlyTopCall(t *testing.T) {
+	result := &callResult{}
+	assert.NoError(t, json.Unmarshal(traceTx(t, "callTracer", `{"onlyTopCall":true}`), result))
+
+	assert.Equal(t, caller, result.To)
+	assert.Empty(t, result.Calls)
+}
+
+func TestCallFrame_ProcessOutput(t *testing.T) {
+	// Error(string) of "boom"
+	revert := append(
+		[]byte{0x08, 0xc3, 0x79, 0xa0},
+		types.BytesToHash([]byte{0x20}).Bytes()...,
+	)
+	revert = append(revert, types.BytesToHash([]byte{0x04}).Bytes()...)
+	revert = append(revert, append([]byte("boom"), make([]byte, 28)...)...)
+
+	frame := &callFrame{Type: evm.CALL}
+	frame.processOutput(revert, runtime.ErrExecutionReverted)
+	assert.Equal(t, runtime.ErrExecutionReverted.Error(), frame.Error)
+	assert.Equal(t, revert, frame.Output)
+	assert.Equal(t, "boom", frame.RevertReason)
+
+	create := &callFrame{Type: evm.CREATE, To: callee.Ptr()}
+	create.processOutput([]byte{0x01}, errors.New("out of gas"))
+	assert.Nil(t, create.To)
+	assert.Nil(t, create.Output)
+}
@@ -0,0 +1,297 @@
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/sunvim/dogesyncer/crypto"
+	"github.com/sunvim/dogesyncer/helper/hex"
+	"github.com/sunvim/dogesyncer/state/runtime"
+	"github.com/sunvim/dogesyncer/state/runtime/evm"
+	"github.com/sunvim/dogesyncer/state/tracer"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+func init() {
+	register("prestateTracer", newPrestateTracer)
+}
+
+type stateMap = map[types.Address]*account
+
+// account is the part of an account the transaction touched
+type account struct {
+	Balance *big.Int
+	Code    []byte
+	Nonce   uint64
+	Storage map[types.Hash]types.Hash
+}
+
+// accountMarshaling is the json encoding of an account
+type accountMarshaling struct {
+	Balance string                    `json:"balance,omitempty"`
+	Code    string                    `json:"code,omitempty"`
+	Nonce   uint64                    `json:"nonce,omitempty"`
+	Storage map[types.Hash]types.Hash `json:"storage,omitempty"`
+}
+
+func (a *account) MarshalJSON() ([]byte, error) {
+	enc := &accountMarshaling{
+		Nonce:   a.Nonce,
+		Storage: a.Storage,
+	}
+
+	if a.Balance != nil {
+		enc.Balance = hex.EncodeBig(a.Balance)
+	}
+
+	if len(a.Code) > 0 {
+		enc.Code = hex.EncodeToHex(a.Code)
+	}
+
+	return json.Marshal(enc)
+}
+
+func (a *account) exists() bool {
+	return a.Nonce > 0 || len(a.Code) > 0 || len(a.Storage) > 0 || (a.Balance != nil && a.Balance.Sign() != 0)
+}
+
+type prestateTracerConfig struct {
+	DiffMode bool `json:"diffMode"` // If true, this tracer will return state modifications
+}
+
+// prestateTracer collects the state of the accounts a transaction touches
+// before it executes, and what it changes of them in diff mode
+type prestateTracer struct {
+	config  prestateTracerConfig
+	preTxn  runtime.Txn // the state before the transaction
+	txn     runtime.Txn // the state the transaction changes
+	pre     stateMap
+	post    stateMap
+	created map[types.Address]bool
+	deleted map[types.Address]bool
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newPrestateTracer returns a native go tracer which collects the accounts
+// touched by a tx, and implements tracer.Tracer.
+func newPrestateTracer(ctx *tracer.Context, cfg json.RawMessage) (tracer.Tracer, error) {
+	var config prestateTracerConfig
+
+	if len(cfg) > 0 {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+
+	return &prestateTracer{
+		config:  config,
+		pre:     stateMap{},
+		post:    stateMap{},
+		created: make(map[types.Address]bool),
+		deleted: make(map[types.Address]bool),
+	}, nil
+}
+
+// CaptureTxStart looks up the sender, the recipient and the coinbase before
+// the transaction changes them
+func (t *prestateTracer) CaptureTxStart(txn runtime.Txn, env runtime.TxContext, msg *types.Transaction) {
+	t.preTxn = txn
+
+	t.lookupAccount(msg.From)
+	t.lookupAccount(env.Coinbase)
+
+	if msg.To != nil {
+		t.lookupAccount(*msg.To)
+
+		return
+	}
+
+	to := crypto.CreateAddress(msg.From, msg.Nonce)
+	t.lookupAccount(to)
+	t.created[to] = true
+}
+
+// CaptureTxEnd computes the modified accounts in diff mode, and drops the
+// accounts created by the transaction from the pre state
+func (t *prestateTracer) CaptureTxEnd(restGas uint64) {
+	if t.config.DiffMode {
+		t.computeDiff()
+	}
+
+	// the new created contracts' prestate were empty, so delete them
+	for addr := range t.created {
+		// the created contract maybe exists in statedb before the creating tx
+		if s := t.pre[addr]; s != nil && !s.exists() {
+			delete(t.pre, addr)
+		}
+	}
+}
+
+func (t *prestateTracer) computeDiff() {
+	for addr, pre := range t.pre {
+		// The deleted account's state is pruned from `post` but kept in `pre`
+		if t.deleted[addr] {
+			continue
+		}
+
+		modified := false
+		post := &account{Storage: make(map[types.Hash]types.Hash)}
+
+		if balance := t.txn.GetBalance(addr); balance.Cmp(pre.Balance) != 0 {
+			modified = true
+			post.Balance = copyBig(balance)
+		}
+
+		if nonce := t.txn.GetNonce(addr); nonce != pre.Nonce {
+			modified = true
+			post.Nonce = nonce
+		}
+
+		if code := t.txn.GetCode(addr); !bytes.Equal(code, pre.Code) {
+			modified = true
+			post.Code = copyBytes(code)
+		}
+
+		for key, val := range pre.Storage {
+			// don't include the empty slot
+			if val == types.ZeroHash {
+				delete(pre.Storage, key)
+			}
+
+			newVal := t.txn.GetState(addr, key)
+			if val == newVal {
+				// Omit unchanged slots
+				delete(pre.Storage, key)
+			} else {
+				modified = true
+
+				if newVal != types.ZeroHash {
+					post.Storage[key] = newVal
+				}
+			}
+		}
+
+		if modified {
+			t.post[addr] = post
+		} else {
+			// if state is not modified, then no need to include into the pre state
+			delete(t.pre, addr)
+		}
+	}
+}
+
+func (t *prestateTracer) CaptureStart(txn runtime.Txn, from, to types.Address,
+	create bool, input []byte, gas uint64, value *big.Int) {
+	t.txn = txn
+}
+
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+}
+
+// CaptureEnter looks up the accounts created by the transaction, their
+// address is only known once the creation starts
+func (t *prestateTracer) CaptureEnter(opCode int, from, to types.Address,
+	input []byte, gas uint64, value *big.Int) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+
+	if op := evm.OpCode(opCode); op == evm.CREATE || op == evm.CREATE2 {
+		t.lookupAccount(to)
+		t.created[to] = true
+	}
+}
+
+func (t *prestateTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+}
+
+// CaptureState looks up the accounts and the storage slots the opcode touches
+func (t *prestateTracer) CaptureState(ctx *runtime.ScopeContext, pc uint64, opCode int,
+	gas, cost uint64, rData []byte, depth int, err error) {
+	if err != nil || atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+
+	var (
+		stack    = ctx.Stack
+		stackLen = len(stack)
+		caller   = ctx.ContractAddress
+		op       = evm.OpCode(opCode)
+	)
+
+	switch {
+	case stackLen >= 1 && (op == evm.SLOAD || op == evm.SSTORE):
+		slot := types.BytesToHash(stack[stackLen-1].Bytes())
+		t.lookupStorage(caller, slot)
+	case stackLen >= 1 && (op == evm.EXTCODECOPY || op == evm.EXTCODEHASH ||
+		op == evm.EXTCODESIZE || op == evm.BALANCE || op == evm.SELFDESTRUCT):
+		addr := types.BytesToAddress(stack[stackLen-1].Bytes())
+		t.lookupAccount(addr)
+
+		if op == evm.SELFDESTRUCT {
+			t.deleted[caller] = true
+		}
+	case stackLen >= 5 && (op == evm.DELEGATECALL || op == evm.CALL || op == evm.STATICCALL || op == evm.CALLCODE):
+		addr := types.BytesToAddress(stack[stackLen-2].Bytes())
+		t.lookupAccount(addr)
+	}
+}
+
+func (t *prestateTracer) CaptureFault(ctx *runtime.ScopeContext, pc uint64, opCode int,
+	gas, cost uint64, depth int, err error) {
+}
+
+// GetResult returns the json-encoded pre state, or the pre and post states
+// in diff mode
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return nil, t.reason
+	}
+
+	if t.config.DiffMode {
+		return json.Marshal(struct {
+			Post stateMap `json:"post"`
+			Pre  stateMap `json:"pre"`
+		}{t.post, t.pre})
+	}
+
+	return json.Marshal(t.pre)
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *prestateTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// lookupAccount fetches details of an account before the transaction and
+// adds it to the prestate if it doesn't exist there.
+func (t *prestateTracer) lookupAccount(addr types.Address) {
+	if _, ok := t.pre[addr]; ok {
+		return
+	}
+
+	t.pre[addr] = &account{
+		Balance: copyBig(t.preTxn.GetBalance(addr)),
+		Nonce:   t.preTxn.GetNonce(addr),
+		Code:    copyBytes(t.preTxn.GetCode(addr)),
+		Storage: make(map[types.Hash]types.Hash),
+	}
+}
+
+// lookupStorage fetches the requested storage slot before the transaction
+// and adds it to the prestate of the given contract.
+func (t *prestateTracer) lookupStorage(addr types.Address, key types.Hash) {
+	t.lookupAccount(addr)
+
+	if _, ok := t.pre[addr].Storage[key]; ok {
+		return
+	}
+
+	t.pre[addr].Storage[key] = t.preTxn.GetState(addr, key)
+}
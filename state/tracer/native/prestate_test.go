@@ -0,0 +1,53 @@
+package native
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+type accountResult struct {
+	Balance string                    `json:"balance"`
+	Code    string                    `json:"code"`
+	Nonce   uint64                    `json:"nonce"`
+	Storage map[types.Hash]types.Hash `json:"storage"`
+}
+
+func TestPrestateTracer(t *testing.T) {
+	pre := map[types.Address]*accountResult{}
+	assert.NoError(t, json.Unmarshal(traceTx(t, "prestateTracer", ""), &pre))
+
+	assert.Len(t, pre, 4)
+	assert.Equal(t, "0xde0b6b3a7640000", pre[sender].Balance)
+	assert.Equal(t, uint64(0), pre[sender].Nonce)
+	assert.Contains(t, pre, coinbase)
+
+	// the slot loaded by the caller and the slot stored by the callee
+	assert.Equal(t, types.StringToHash("0x07"), pre[caller].Storage[types.ZeroHash])
+	assert.Equal(t, types.ZeroHash, pre[callee].Storage[types.StringToHash("0x01")])
+	assert.NotEmpty(t, pre[callee].Code)
+}
+
+func TestPrestateTracer_DiffMode(t *testing.T) {
+	var diff struct {
+		Pre  map[types.Address]*accountResult `json:"pre"`
+		Post map[types.Address]*accountResult `json:"post"`
+	}
+	assert.NoError(t, json.Unmarshal(traceTx(t, "prestateTracer", `{"diffMode":true}`), &diff))
+
+	// the caller only reads its storage
+	assert.NotContains(t, diff.Pre, caller)
+	assert.NotContains(t, diff.Post, caller)
+
+	// the sender pays the fees to the coinbase
+	assert.Equal(t, uint64(1), diff.Post[sender].Nonce)
+	assert.NotEmpty(t, diff.Post[sender].Balance)
+	assert.NotEmpty(t, diff.Post[coinbase].Balance)
+
+	// empty slots are left out of the pre state
+	assert.Empty(t, diff.Pre[callee].Storage)
+	assert.Equal(t, types.StringToHash("0x2a"), diff.Post[callee].Storage[types.StringToHash("0x01")])
+	assert.Empty(t, diff.Post[callee].Code)
+}
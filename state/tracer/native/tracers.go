@@ -0,0 +1,42 @@
+// Package native is a collection of tracers written in go, they are
+// selected by name through the tracer registry.
+package native
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/sunvim/dogesyncer/state/tracer"
+)
+
+// ctorFn is the constructor signature of a native tracer
+type ctorFn func(*tracer.Context, json.RawMessage) (tracer.Tracer, error)
+
+var (
+	// ctors is a map of package-local tracer constructors
+	ctors map[string]ctorFn
+
+	errTracerNotFound = errors.New("no tracer found")
+)
+
+func init() {
+	tracer.RegisterLookup(false, lookup)
+}
+
+// register is used by native tracers to register their presence
+func register(name string, ctor ctorFn) {
+	if ctors == nil {
+		ctors = make(map[string]ctorFn)
+	}
+
+	ctors[name] = ctor
+}
+
+// lookup returns a tracer, if one can be matched to the given name
+func lookup(name string, ctx *tracer.Context, cfg json.RawMessage) (tracer.Tracer, error) {
+	if ctor, ok := ctors[name]; ok {
+		return ctor(ctx, cfg)
+	}
+
+	return nil, errTracerNotFound
+}
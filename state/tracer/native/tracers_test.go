@@ -0,0 +1,88 @@
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/chain"
+	"github.com/sunvim/dogesyncer/helper/hex"
+	"github.com/sunvim/dogesyncer/state"
+	itrie "github.com/sunvim/dogesyncer/state/immutable-trie"
+	"github.com/sunvim/dogesyncer/state/runtime/evm"
+	"github.com/sunvim/dogesyncer/state/tracer"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+var (
+	sender   = types.StringToAddress("0x1000")
+	coinbase = types.StringToAddress("0x2000")
+	callee   = types.StringToAddress("0x3000")
+	caller   = types.StringToAddress("0x4000")
+)
+
+// callerCode loads slot 0 and calls the callee
+func callerCode() []byte {
+	code := hex.MustDecodeHex("0x600054506000600060006000600073")
+	code = append(code, callee.Bytes()...)
+
+	return append(code, hex.MustDecodeHex("0x61fffff15000")...)
+}
+
+// calleeCode stores 0x2a at slot 1
+var calleeCode = hex.MustDecodeHex("0x602a60015500")
+
+// traceTx executes a call of the caller contract with the named tracer
+func traceTx(t *testing.T, name string, cfg string) json.RawMessage {
+	t.Helper()
+
+	executor := state.NewExecutor(
+		&chain.Params{Forks: chain.AllForksEnabled, ChainID: 100},
+		itrie.NewState(itrie.NewMemoryStorage(), nil),
+		hclog.NewNullLogger(),
+	)
+	executor.SetRuntime(evm.NewEVM())
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(uint64) types.Hash { return types.ZeroHash }
+	}
+
+	root := executor.WriteGenesis(map[types.Address]*chain.GenesisAccount{
+		sender: {Balance: big.NewInt(1e18)},
+		caller: {Code: callerCode(), Storage: map[types.Hash]types.Hash{
+			types.ZeroHash: types.StringToHash("0x07"),
+		}},
+		callee: {Code: calleeCode},
+	})
+
+	transition, err := executor.BeginTxn(root, &types.Header{Number: 1, GasLimit: 10000000}, coinbase)
+	assert.NoError(t, err)
+
+	tr, err := tracer.New(name, &tracer.Context{}, json.RawMessage(cfg))
+	assert.NoError(t, err)
+
+	transition.SetEVMLogger(tr)
+
+	assert.NoError(t, transition.Write(&types.Transaction{
+		From:     sender,
+		To:       &caller,
+		Gas:      1000000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+		Input:    []byte{},
+	}))
+
+	result, err := tr.GetResult()
+	assert.NoError(t, err)
+
+	return result
+}
+
+func TestLookup(t *testing.T) {
+	_, err := tracer.New("unknownTracer", &tracer.Context{}, nil)
+	assert.Error(t, err)
+
+	_, err = tracer.New("callTracer", &tracer.Context{}, json.RawMessage(`{"onlyTopCall":1}`))
+	assert.Error(t, err)
+}
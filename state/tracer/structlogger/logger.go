@@ -3,11 +3,14 @@ package structlogger
 import (
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
+	"sync/atomic"
 	"time"
 
+	helperhex "github.com/sunvim/dogesyncer/helper/hex"
 	"github.com/sunvim/dogesyncer/state/runtime"
 	"github.com/sunvim/dogesyncer/state/runtime/evm"
 	"github.com/sunvim/dogesyncer/types"
@@ -26,6 +29,14 @@ func (s Storage) Copy() Storage {
 	return cpy
 }
 
+// Config are the configuration options for the structured logger
+type Config struct {
+	EnableMemory   bool // enable memory capture
+	DisableStack   bool // disable stack capture
+	DisableStorage bool // disable storage capture
+	Limit          int  // maximum number of logs to capture, zero means unlimited
+}
+
 // StructLog is emitted to the EVM each cycle and lists information about the current internal state
 // prior to the execution of the statement.
 type StructLog struct {
@@ -72,21 +83,32 @@ func (s *StructLog) GetErrorString() string {
 // a track record of modified storage which is used in reporting snapshots of the
 // contract their storage.
 type StructLogger struct {
+	cfg Config
 	txn runtime.Txn
 
-	storage map[types.Address]Storage
-	logs    []*StructLog
-	output  []byte
-	err     error
+	storage  map[types.Address]Storage
+	logs     []*StructLog
+	output   []byte
+	err      error
+	gasLimit uint64
+	usedGas  uint64
+
+	interrupt uint32 // atomic flag to signal execution interruption
+	reason    error  // textual reason for the interruption
 }
 
-// NewStructLogger returns a new logger
-func NewStructLogger(txn runtime.Txn) *StructLogger {
+// NewStructLogger returns a new logger, a nil config captures
+// the stack and the storage but no memory
+func NewStructLogger(txn runtime.Txn, cfg *Config) *StructLogger {
 	logger := &StructLogger{
 		txn:     txn,
 		storage: make(map[types.Address]Storage),
 	}
 
+	if cfg != nil {
+		logger.cfg = *cfg
+	}
+
 	return logger
 }
 
@@ -96,6 +118,20 @@ func (l *StructLogger) Reset() {
 	l.output = make([]byte, 0)
 	l.logs = l.logs[:0]
 	l.err = nil
+	l.gasLimit = 0
+	l.usedGas = 0
+	atomic.StoreUint32(&l.interrupt, 0)
+	l.reason = nil
+}
+
+// CaptureTxStart implements the EVMLogger interface to remember the gas limit of the transaction.
+func (l *StructLogger) CaptureTxStart(txn runtime.Txn, env runtime.TxContext, msg *types.Transaction) {
+	l.gasLimit = msg.Gas
+}
+
+// CaptureTxEnd implements the EVMLogger interface to compute the gas used by the transaction.
+func (l *StructLogger) CaptureTxEnd(restGas uint64) {
+	l.usedGas = l.gasLimit - restGas
 }
 
 // CaptureStart implements the EVMLogger interface to initialize the tracing operation.
@@ -116,18 +152,34 @@ func (l *StructLogger) CaptureState(
 	depth int,
 	err error,
 ) {
-	// memory := ctx.Memory
+	// If tracing was interrupted, set the error and stop
+	if atomic.LoadUint32(&l.interrupt) > 0 {
+		return
+	}
+
+	// check if already accumulated the specified number of logs
+	if l.cfg.Limit != 0 && l.cfg.Limit <= len(l.logs) {
+		return
+	}
+
+	memory := ctx.Memory
 	stack := ctx.Stack
 	contractAddress := ctx.ContractAddress
 
-	// // Copy a snapshot of the current memory state to a new buffer
-	// mem := make([]byte, len(memory))
-	// copy(mem, memory)
+	// Copy a snapshot of the current memory state to a new buffer
+	var mem []byte
+	if l.cfg.EnableMemory {
+		mem = make([]byte, len(memory))
+		copy(mem, memory)
+	}
 
 	// Copy a snapshot of the current stack state to a new buffer
-	stck := make([]*big.Int, len(stack))
-	for i, item := range stack {
-		stck[i] = new(big.Int).SetBytes(item.Bytes())
+	var stck []*big.Int
+	if !l.cfg.DisableStack {
+		stck = make([]*big.Int, len(stack))
+		for i, item := range stack {
+			stck[i] = new(big.Int).Set(item)
+		}
 	}
 
 	// Copy stack data
@@ -136,7 +188,7 @@ func (l *StructLogger) CaptureState(
 
 	// Copy a snapshot of the current storage to a new container
 	var storage Storage
-	if opCode == evm.SLOAD || opCode == evm.SSTORE {
+	if !l.cfg.DisableStorage && (opCode == evm.SLOAD || opCode == evm.SSTORE) {
 		// initialise new changed values storage container for this contract
 		// if not present.
 		if l.storage[contractAddress] == nil {
@@ -177,12 +229,12 @@ func (l *StructLogger) CaptureState(
 
 	// create a new snapshot of the EVM.
 	l.logs = append(l.logs, &StructLog{
-		Pc:      pc,
-		Op:      opCode,
-		Gas:     gas,
-		GasCost: cost,
-		// Memory:        mem,
-		// MemorySize:    len(memory),
+		Pc:            pc,
+		Op:            opCode,
+		Gas:           gas,
+		GasCost:       cost,
+		Memory:        mem,
+		MemorySize:    len(memory),
 		Stack:         stck,
 		ReturnData:    rdata,
 		Storage:       storage,
@@ -210,6 +262,37 @@ func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration
 	l.err = err
 }
 
+// GetResult implements the Tracer interface, it returns the captured
+// logs in the format of the debug_trace methods.
+func (l *StructLogger) GetResult() (json.RawMessage, error) {
+	// Tracing aborted
+	if atomic.LoadUint32(&l.interrupt) > 0 {
+		return nil, l.reason
+	}
+
+	failed := l.err != nil
+	returnData := l.output
+
+	// Return data when successful and revert reason when reverted, otherwise empty.
+	if failed && !errors.Is(l.err, runtime.ErrExecutionReverted) {
+		returnData = []byte{}
+	}
+
+	return json.Marshal(&ExecutionResult{
+		Gas:         l.usedGas,
+		Failed:      failed,
+		ReturnValue: hex.EncodeToString(returnData),
+		StructLogs:  FormatLogs(l.logs),
+	})
+}
+
+// Stop implements the Tracer interface, it terminates the capture
+// of further logs.
+func (l *StructLogger) Stop(err error) {
+	l.reason = err
+	atomic.StoreUint32(&l.interrupt, 1)
+}
+
 // StructLogs returns the captured log entries.
 func (l *StructLogger) StructLogs() []*StructLog { return l.logs }
 
@@ -282,3 +365,73 @@ func WriteLogs(writer io.Writer, logs []*types.Log) {
 		fmt.Fprintln(writer)
 	}
 }
+
+// ExecutionResult groups all structured logs emitted by the EVM
+// while replaying a transaction in debug mode.
+type ExecutionResult struct {
+	Gas         uint64         `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue string         `json:"returnValue"`
+	StructLogs  []StructLogRes `json:"structLogs"`
+}
+
+// StructLogRes stores a structured log emitted by the EVM while replaying a
+// transaction in debug mode
+type StructLogRes struct {
+	Pc            uint64             `json:"pc"`
+	Op            string             `json:"op"`
+	Gas           uint64             `json:"gas"`
+	GasCost       uint64             `json:"gasCost"`
+	Depth         int                `json:"depth"`
+	Error         string             `json:"error,omitempty"`
+	Stack         *[]string          `json:"stack,omitempty"`
+	Memory        *[]string          `json:"memory,omitempty"`
+	Storage       *map[string]string `json:"storage,omitempty"`
+	RefundCounter uint64             `json:"refund,omitempty"`
+}
+
+// FormatLogs formats EVM returned structured logs for json output
+func FormatLogs(logs []*StructLog) []StructLogRes {
+	formatted := make([]StructLogRes, len(logs))
+
+	for index, trace := range logs {
+		formatted[index] = StructLogRes{
+			Pc:            trace.Pc,
+			Op:            trace.GetOpName(),
+			Gas:           trace.Gas,
+			GasCost:       trace.GasCost,
+			Depth:         trace.Depth,
+			Error:         trace.GetErrorString(),
+			RefundCounter: trace.RefundCounter,
+		}
+
+		if trace.Stack != nil {
+			stack := make([]string, len(trace.Stack))
+			for i, stackValue := range trace.Stack {
+				stack[i] = helperhex.EncodeBig(stackValue)
+			}
+
+			formatted[index].Stack = &stack
+		}
+
+		if trace.Memory != nil {
+			memory := make([]string, 0, (len(trace.Memory)+31)/32)
+			for i := 0; i+32 <= len(trace.Memory); i += 32 {
+				memory = append(memory, hex.EncodeToString(trace.Memory[i:i+32]))
+			}
+
+			formatted[index].Memory = &memory
+		}
+
+		if trace.Storage != nil {
+			storage := make(map[string]string, len(trace.Storage))
+			for i, storageValue := range trace.Storage {
+				storage[hex.EncodeToString(i.Bytes())] = hex.EncodeToString(storageValue.Bytes())
+			}
+
+			formatted[index].Storage = &storage
+		}
+	}
+
+	return formatted
+}
@@ -0,0 +1,147 @@
+package structlogger
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/sunvim/dogesyncer/state/runtime"
+	"github.com/sunvim/dogesyncer/state/runtime/evm"
+	"github.com/sunvim/dogesyncer/types"
+)
+
+type mockTxn struct {
+	state map[types.Hash]types.Hash
+}
+
+func (m *mockTxn) GetState(addr types.Address, key types.Hash) types.Hash {
+	return m.state[key]
+}
+
+func (m *mockTxn) GetRefund() uint64 {
+	return 0
+}
+
+func (m *mockTxn) GetBalance(addr types.Address) *big.Int {
+	return big.NewInt(0)
+}
+
+func (m *mockTxn) GetNonce(addr types.Address) uint64 {
+	return 0
+}
+
+func (m *mockTxn) GetCode(addr types.Address) []byte {
+	return nil
+}
+
+// captureSload captures a SLOAD of the key
+func captureSload(l *StructLogger, key types.Hash) {
+	l.CaptureState(
+		&runtime.ScopeContext{
+			Memory: make([]byte, 64),
+			Stack:  []*big.Int{new(big.Int).SetBytes(key.Bytes())},
+		},
+		0,
+		evm.SLOAD,
+		1000,
+		800,
+		nil,
+		1,
+		nil,
+	)
+}
+
+func getResult(t *testing.T, l *StructLogger) *ExecutionResult {
+	t.Helper()
+
+	data, err := l.GetResult()
+	assert.NoError(t, err)
+
+	result := &ExecutionResult{}
+	assert.NoError(t, json.Unmarshal(data, result))
+
+	return result
+}
+
+func TestStructLogger_Config(t *testing.T) {
+	key := types.StringToHash("1")
+	txn := &mockTxn{state: map[types.Hash]types.Hash{key: types.StringToHash("2")}}
+
+	t.Run("default", func(t *testing.T) {
+		l := NewStructLogger(txn, nil)
+		captureSload(l, key)
+
+		result := getResult(t, l)
+		assert.Len(t, result.StructLogs, 1)
+
+		log := result.StructLogs[0]
+		assert.Equal(t, "SLOAD", log.Op)
+		assert.NotNil(t, log.Stack)
+		assert.NotNil(t, log.Storage)
+		assert.Nil(t, log.Memory)
+		assert.Equal(t, "0x1", (*log.Stack)[0])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		l := NewStructLogger(txn, &Config{
+			EnableMemory:   true,
+			DisableStack:   true,
+			DisableStorage: true,
+		})
+		captureSload(l, key)
+
+		log := getResult(t, l).StructLogs[0]
+		assert.Nil(t, log.Stack)
+		assert.Nil(t, log.Storage)
+		assert.Len(t, *log.Memory, 2)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		l := NewStructLogger(txn, &Config{Limit: 2})
+
+		for i := 0; i < 5; i++ {
+			captureSload(l, key)
+		}
+
+		assert.Len(t, getResult(t, l).StructLogs, 2)
+	})
+}
+
+func TestStructLogger_Result(t *testing.T) {
+	l := NewStructLogger(&mockTxn{}, nil)
+
+	l.CaptureTxStart(&mockTxn{}, runtime.TxContext{}, &types.Transaction{Gas: 100000})
+	l.CaptureEnd([]byte{0x01, 0x02}, 0, 0, nil)
+	l.CaptureTxEnd(60000)
+
+	result := getResult(t, l)
+	assert.Equal(t, uint64(40000), result.Gas)
+	assert.False(t, result.Failed)
+	assert.Equal(t, "0102", result.ReturnValue)
+
+	// only reverts keep the return data of failures
+	l.CaptureEnd([]byte{0x01}, 0, 0, runtime.ErrOutOfGas)
+
+	result = getResult(t, l)
+	assert.True(t, result.Failed)
+	assert.Equal(t, "", result.ReturnValue)
+}
+
+func TestStructLogger_Stop(t *testing.T) {
+	var (
+		key  = types.StringToHash("1")
+		l    = NewStructLogger(&mockTxn{}, nil)
+		stop = errors.New("execution timeout")
+	)
+
+	captureSload(l, key)
+	l.Stop(stop)
+	captureSload(l, key)
+
+	assert.Len(t, l.StructLogs(), 1)
+
+	_, err := l.GetResult()
+	assert.ErrorIs(t, err, stop)
+}
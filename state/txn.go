@@ -67,6 +67,18 @@ func (txn *Txn) Snapshot() int {
 	return id
 }
 
+// Copy returns a copy of the txn at this point in time, changes made to
+// either of them afterwards are not visible to the other
+func (txn *Txn) Copy() *Txn {
+	return &Txn{
+		snapshot:  txn.snapshot,
+		state:     txn.state,
+		snapshots: []*iradix.Tree{},
+		txn:       txn.txn.CommitOnly().Txn(),
+		hash:      keccak.NewKeccak256(),
+	}
+}
+
 // RevertToSnapshot reverts to a given snapshot
 func (txn *Txn) RevertToSnapshot(id int) {
 	if id > len(txn.snapshots) {
@@ -114,6 +126,11 @@ func (txn *Txn) getStateObject(addr types.Address) (*StateObject, bool) {
 	// Load trie from memory if there is some state
 	if account.Root == emptyStateHash {
 		account.Trie = txn.state.NewSnapshot()
+	} else if ss, ok := txn.snapshot.(StorageSnapshotter); ok {
+		account.Trie, err = ss.StorageAt(types.BytesToHash(txn.hashit(addr.Bytes())), account.Root)
+		if err != nil {
+			return nil, false
+		}
 	} else {
 		account.Trie, err = txn.state.NewSnapshotAt(account.Root)
 		if err != nil {
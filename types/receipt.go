@@ -145,6 +145,8 @@ func (b *Bloom) setEncode(hasher *keccak.Keccak, h []byte) {
 func (b *Bloom) IsLogInBloom(log *Log) bool {
 	hasher := keccak.DefaultKeccakPool.Get()
 
+	defer keccak.DefaultKeccakPool.Put(hasher)
+
 	// Check if the log address is present
 	addressPresent := b.isByteArrPresent(hasher, log.Address.Bytes())
 	if !addressPresent {
@@ -160,11 +162,18 @@ func (b *Bloom) IsLogInBloom(log *Log) bool {
 		}
 	}
 
-	keccak.DefaultKeccakPool.Put(hasher)
-
 	return true
 }
 
+// IsBytesPresent checks if the address or topic has a possible presence in
+// the bloom filter
+func (b *Bloom) IsBytesPresent(data []byte) bool {
+	hasher := keccak.DefaultKeccakPool.Get()
+	defer keccak.DefaultKeccakPool.Put(hasher)
+
+	return b.isByteArrPresent(hasher, data)
+}
+
 // isByteArrPresent checks if the byte array is possibly present in the Bloom filter
 func (b *Bloom) isByteArrPresent(hasher *keccak.Keccak, data []byte) bool {
 	hasher.Reset()
@@ -181,7 +190,7 @@ func (b *Bloom) isByteArrPresent(hasher *keccak.Keccak, data []byte) bool {
 
 		referenceByte := b[byteLocation]
 
-		isSet := int(referenceByte & (1 << (bitLocation - 1)))
+		isSet := int(referenceByte & (1 << bitLocation))
 
 		if isSet == 0 {
 			return false
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestBloomLookup(t *testing.T) {
+	log := &Log{
+		Address: StringToAddress("0x1"),
+		Topics: []Hash{
+			StringToHash("0x2"),
+			StringToHash("0x3"),
+		},
+	}
+
+	bloom := CreateBloom([]*Receipt{{Logs: []*Log{log}}})
+
+	if !bloom.IsLogInBloom(log) {
+		t.Fatal("expected log to be in bloom")
+	}
+
+	if !bloom.IsBytesPresent(log.Address.Bytes()) {
+		t.Fatal("expected address to be in bloom")
+	}
+
+	for _, topic := range log.Topics {
+		if !bloom.IsBytesPresent(topic.Bytes()) {
+			t.Fatalf("expected topic %s to be in bloom", topic)
+		}
+	}
+
+	other := &Log{Address: StringToAddress("0x4")}
+	if bloom.IsLogInBloom(other) {
+		t.Fatal("expected log not to be in bloom")
+	}
+
+	var empty Bloom
+	if empty.IsBytesPresent(log.Address.Bytes()) {
+		t.Fatal("expected empty bloom to contain nothing")
+	}
+}